@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"polling/internal/models"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// audit records event stamped with the IP and ID of the request. The actor
+// defaults to the authenticated user. The mutation has already happened by
+// the time this runs, so failures are logged instead of failing the request.
+func (app *application) audit(r *http.Request, event models.AuditEvent) {
+	if event.ActorID == nil {
+		if userID := app.optionalUserID(r); userID != 0 {
+			event.ActorID = &userID
+		}
+	}
+
+	event.IP = clientIP(r)
+	event.RequestID = middleware.GetReqID(r.Context())
+
+	err := app.DB.RecordAuditEvent(event)
+
+	if err != nil {
+		log.Println("audit:", event.Action, err)
+	}
+}
+
+// auditValue encodes v as the before or after value of an audit event.
+func auditValue(v any) json.RawMessage {
+	out, err := json.Marshal(v)
+
+	if err != nil {
+		log.Println("audit:", err)
+		return nil
+	}
+
+	return out
+}
@@ -1,13 +1,24 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+const (
+	guestCookieName   = "guest_token"
+	guestCookieExpiry = time.Hour * 24 * 365
+)
+
 type Auth struct {
 	Issuer        string
 	Audience      string
@@ -107,3 +118,67 @@ func (j *Auth) GetExpiredRefreshCookie() *http.Cookie {
 		HttpOnly: true,
 	}
 }
+
+// NewGuestToken returns a new random guest voter ID together with the signed
+// cookie value carrying it.
+func (j *Auth) NewGuestToken() (string, string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	id := hex.EncodeToString(b)
+
+	return id, id + "." + j.signGuestID(id), nil
+}
+
+// VerifyGuestToken checks the signature of a guest cookie value and returns
+// the guest voter ID it carries.
+func (j *Auth) VerifyGuestToken(value string) (string, bool) {
+	id, signature, found := strings.Cut(value, ".")
+
+	if !found || id == "" {
+		return "", false
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(j.signGuestID(id))) {
+		return "", false
+	}
+
+	return id, true
+}
+
+func (j *Auth) signGuestID(id string) string {
+	mac := hmac.New(sha256.New, []byte(j.Secret))
+	mac.Write([]byte("guest:" + id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CalendarToken returns the token that lets calendar apps fetch the meeting
+// feed of the user without logging in.
+func (j *Auth) CalendarToken(userID int) string {
+	mac := hmac.New(sha256.New, []byte(j.Secret))
+	mac.Write([]byte("calendar:" + strconv.Itoa(userID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCalendarToken checks a meeting feed token of the user.
+func (j *Auth) VerifyCalendarToken(userID int, token string) bool {
+	return hmac.Equal([]byte(token), []byte(j.CalendarToken(userID)))
+}
+
+func (j *Auth) GetGuestCookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     guestCookieName,
+		Path:     "/",
+		Value:    value,
+		Expires:  time.Now().Add(guestCookieExpiry),
+		MaxAge:   int(guestCookieExpiry.Seconds()),
+		SameSite: http.SameSiteLaxMode,
+		Domain:   j.CookieDomain,
+		HttpOnly: true,
+	}
+}
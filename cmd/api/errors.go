@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"polling/internal/repository"
+	"polling/internal/validator"
+)
+
+var (
+	errMissingUser        = repository.Unauthenticated("missing_user", "missing user")
+	errInvalidCredentials = repository.Unauthenticated("invalid_credentials", "invalid username or password")
+	errNotPollEditor      = repository.Forbidden("not_poll_editor", "you are not authorized to update this poll")
+	errNotOrgMember       = repository.Forbidden("not_organization_member", "you are not a member of this organization")
+	errPrivateResults     = repository.Forbidden("private_results", "results of this poll are private")
+	errPollNotFound       = repository.NotFound("poll_not_found", "poll not found")
+	errOptionNotFound     = repository.NotFound("option_not_found", "option not found")
+	errPollNotOpen        = repository.Forbidden("poll_not_open", "this poll is not open for voting")
+	errPollFrozen         = repository.Conflict("poll_frozen", "this part of the poll can no longer change once it is published")
+	errTooManyRequests    = repository.RateLimited("too_many_requests", "too many requests")
+)
+
+// problem is an RFC 7807 problem details body. Code is a stable identifier
+// clients can branch on, Detail is meant for humans.
+type problem struct {
+	Type   string                 `json:"type"`
+	Title  string                 `json:"title"`
+	Status int                    `json:"status"`
+	Detail string                 `json:"detail"`
+	Code   string                 `json:"code"`
+	Errors []validator.FieldError `json:"errors,omitempty"`
+}
+
+// errorStatus maps the kind of a domain error to its HTTP status code.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, repository.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, repository.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, repository.ErrPreconditionRequired):
+		return http.StatusPreconditionRequired
+	}
+
+	return http.StatusInternalServerError
+}
+
+// writeError writes err as a problem. Errors that are not domain errors are
+// logged and reported as a generic internal error, so their text never
+// reaches the client.
+func (app *application) writeError(w http.ResponseWriter, err error) error {
+	var domainErr *repository.Error
+
+	if !errors.As(err, &domainErr) {
+		log.Println("internal error:", err)
+
+		return app.writeProblem(w, problem{
+			Status: http.StatusInternalServerError,
+			Detail: "internal server error",
+			Code:   "internal_error",
+		})
+	}
+
+	return app.writeProblem(w, problem{
+		Status: errorStatus(domainErr),
+		Detail: domainErr.Message,
+		Code:   domainErr.Code,
+	})
+}
+
+func (app *application) writeValidationErrors(w http.ResponseWriter, fieldErrors []validator.FieldError) error {
+	return app.writeProblem(w, problem{
+		Status: http.StatusBadRequest,
+		Detail: "validation failed",
+		Code:   "validation_failed",
+		Errors: fieldErrors,
+	})
+}
+
+func (app *application) writeProblem(w http.ResponseWriter, p problem) error {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/problem+json")
+
+	return app.writeJSON(w, p.Status, p, headers)
+}
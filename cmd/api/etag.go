@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"polling/internal/repository"
+	"strconv"
+	"strings"
+)
+
+var (
+	errMissingIfMatch = repository.PreconditionRequired("if_match_required", "the If-Match header is required")
+	errStaleVersion   = repository.PreconditionFailed("version_mismatch", "the resource has been changed since it was read")
+)
+
+// representationTag formats the entity tag of body, the representation of a
+// version of a resource as one viewer gets it. The hash after the version
+// tells apart what changes without an edit, such as votes coming in, a quiz
+// revealing its answer key or each viewer's shuffled option order.
+func representationTag(version int, body any) string {
+	tag := strconv.Itoa(version)
+	out, err := json.Marshal(body)
+
+	if err == nil {
+		hash := fnv.New64a()
+		hash.Write(out)
+		tag += "-" + strconv.FormatUint(hash.Sum64(), 16)
+	}
+
+	return `"` + tag + `"`
+}
+
+// matchesETag reports whether a comma separated If-None-Match header value
+// lists tag. Weak tags compare by their opaque part.
+func matchesETag(header string, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesVersion reports whether a comma separated If-Match header value
+// lists a tag of version, either the bare version or a representation tag of
+// it. Only the version counts, so votes cast since the read do not fail it.
+func matchesVersion(header string, version int) bool {
+	prefix := `"` + strconv.Itoa(version)
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == "*" || candidate == prefix+`"` || strings.HasPrefix(candidate, prefix+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified sets the ETag of body, the response about to be written, and,
+// when the request already holds it, answers 304 and reports true. The body
+// may depend on who is asking, so caches have to key on the Authorization
+// header as well.
+func (app *application) notModified(w http.ResponseWriter, r *http.Request, version int, body any) bool {
+	tag := representationTag(version, body)
+
+	w.Header().Set("ETag", tag)
+	w.Header().Add("Vary", "Authorization")
+
+	if !matchesETag(r.Header.Get("If-None-Match"), tag) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatchVersion checks the If-Match header of a request against the current
+// version of the resource it changes. The returned version is handed to the
+// repository, which checks it again when writing.
+func (app *application) ifMatchVersion(r *http.Request, current int) (int, error) {
+	header := r.Header.Get("If-Match")
+
+	if header == "" {
+		return 0, errMissingIfMatch
+	}
+
+	if !matchesVersion(header, current) {
+		return 0, errStaleVersion
+	}
+
+	return current, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpdatePollIfMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		ifMatch        string
+		expectedStatus int
+	}{
+		{name: "current version", ifMatch: `"1"`, expectedStatus: http.StatusOK},
+		{name: "weak tag", ifMatch: `W/"1"`, expectedStatus: http.StatusOK},
+		{name: "any version", ifMatch: "*", expectedStatus: http.StatusOK},
+		{name: "one of several", ifMatch: `"0", "1"`, expectedStatus: http.StatusOK},
+		{name: "representation tag", ifMatch: `"1-8f3a0c2d"`, expectedStatus: http.StatusOK},
+		{name: "stale representation tag", ifMatch: `"0-8f3a0c2d"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "stale version", ifMatch: `"0"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "missing header", ifMatch: "", expectedStatus: http.StatusPreconditionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]string{"title": "Updated"})
+
+			req := httptest.NewRequest("PUT", "/polls/1", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = addURLParamToRequest(req, "pollID", "1")
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.UpdatePoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRemovePollOptionIfMatch(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("DELETE", "/polls/1/options/1", nil)
+	req.Header.Set("If-Match", `"2"`)
+	req = addURLParamToRequest(req, "pollID", "1")
+	req = addURLParamToRequest(req, "optionID", "1")
+
+	token, err := generateTestJWT(app.auth, 1)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	authHandler := app.authRequired(http.HandlerFunc(app.RemovePollOption))
+	authHandler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status %d, got %d", http.StatusPreconditionFailed, rr.Code)
+	}
+
+	var response problem
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if response.Code != "version_mismatch" {
+		t.Errorf("expected code version_mismatch, got %s", response.Code)
+	}
+}
+
+func TestGetPollIfNoneMatch(t *testing.T) {
+	get := func(app *application, userID int, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/polls/7", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		req = addURLParamToRequest(req, "pollID", "7")
+
+		if userID != 0 {
+			token, err := generateTestJWT(app.auth, userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rr := httptest.NewRecorder()
+
+		handler := app.authOptional(http.HandlerFunc(app.GetPoll))
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	app := setuptestApp(TestAppConfig{})
+
+	tag := get(app, 2, "").Header().Get("ETag")
+
+	if !strings.HasPrefix(tag, `"1-`) {
+		t.Fatalf("expected an ETag of version 1, got %s", tag)
+	}
+
+	tests := []struct {
+		name           string
+		userID         int
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{name: "no cached copy", userID: 2, expectedStatus: http.StatusOK},
+		{name: "cached copy is current", userID: 2, ifNoneMatch: tag, expectedStatus: http.StatusNotModified},
+		{name: "cached copy is stale", userID: 2, ifNoneMatch: `"0"`, expectedStatus: http.StatusOK},
+		// poll 7 shuffles its options for every viewer
+		{name: "cached copy of another viewer", userID: 3, ifNoneMatch: tag, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := get(app, tt.userID, tt.ifNoneMatch)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if tt.expectedStatus == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Errorf("expected empty body, got %s", rr.Body.String())
+			}
+		})
+	}
+}
@@ -4,10 +4,9 @@ import (
 	"errors"
 	"net/http"
 	"polling/internal/models"
-	"strconv"
+	"polling/internal/repository"
+	"polling/internal/validator"
 	"time"
-
-	"github.com/go-chi/chi/v5"
 )
 
 // user routes handlers
@@ -28,11 +27,6 @@ func (app *application) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if payload.Username == "" || payload.Password == "" || payload.FirstName == "" || payload.LastName == "" {
-		app.writeError(w, errors.New("missing one or more required field ['username','password','first_name', 'last_name']"))
-		return
-	}
-
 	user := models.User{
 		Username:  payload.Username,
 		FirstName: payload.FirstName,
@@ -40,15 +34,41 @@ func (app *application) Signup(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	user.HashPassword(payload.Password)
 
-	err = app.DB.CreateUser(user)
+	v := validator.New()
+	user.Validate(v, payload.Password)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	err = user.HashPassword(payload.Password)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	user.ID, err = app.DB.CreateUser(user)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditUserSignup,
+		ActorID:    &user.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   &user.ID,
+		After: auditValue(map[string]string{
+			"username":   user.Username,
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+		}),
+	})
+
 	app.writeMessage(w, "User successfuly created")
 }
 
@@ -65,13 +85,23 @@ func (app *application) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if payload.Username == "" || payload.Password == "" {
-		app.writeError(w, errors.New("missing one or more required field ['username','password']"))
+	v := validator.New()
+	v.Required("username", payload.Username)
+	v.Required("password", payload.Password)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
 		return
 	}
 
 	user, err := app.DB.GetUserByUsername(payload.Username)
 
+	// unknown users get the same answer as wrong passwords
+	if errors.Is(err, repository.ErrNotFound) {
+		app.writeError(w, errInvalidCredentials)
+		return
+	}
+
 	if err != nil {
 		app.writeError(w, err)
 		return
@@ -80,7 +110,7 @@ func (app *application) Login(w http.ResponseWriter, r *http.Request) {
 	valid := user.CheckPassword(payload.Password)
 
 	if !valid {
-		app.writeError(w, errors.New("password incorrect"), http.StatusUnauthorized)
+		app.writeError(w, errInvalidCredentials)
 		return
 	}
 
@@ -100,6 +130,13 @@ func (app *application) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditUserLogin,
+		TargetType: models.AuditTargetUser,
+		TargetID:   &user.ID,
+	})
+
 	refreshCookie := app.auth.GetRefreshCookie(tokens.RefreshToken)
 
 	http.SetCookie(w, refreshCookie)
@@ -118,14 +155,7 @@ func (app *application) Login(w http.ResponseWriter, r *http.Request) {
 // poll routes handlers
 
 func (app *application) CreatePoll(w http.ResponseWriter, r *http.Request) {
-	userIDstr, ok := r.Context().Value("userID").(string)
-
-	if !ok {
-		app.writeError(w, errors.New("missing user"))
-		return
-	}
-
-	userID, err := strconv.Atoi(userIDstr)
+	userID, err := app.authenticatedUserID(r)
 
 	if err != nil {
 		app.writeError(w, err)
@@ -133,8 +163,37 @@ func (app *application) CreatePoll(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var payload struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
+		Title            string     `json:"title"`
+		Description      string     `json:"description"`
+		OrganizationID   *int       `json:"organization_id"`
+		MembersOnly      bool       `json:"members_only"`
+		PrivateResults   bool       `json:"private_results"`
+		AllowGuests      bool       `json:"allow_guests"`
+		RandomizeOptions bool       `json:"randomize_options"`
+		AllowSuggestions bool       `json:"allow_suggestions"`
+		SuggestionLimit  *int       `json:"suggestion_limit"`
+		Kind             string     `json:"kind"`
+		MaxAnswerLength  *int       `json:"max_answer_length"`
+		Quiz             bool       `json:"quiz"`
+		TimeBonus        int        `json:"time_bonus"`
+		CreditBudget     *int       `json:"credit_budget"`
+		Weighted         bool       `json:"weighted"`
+		NoneOfTheAbove   bool       `json:"none_of_the_above"`
+		Quorum           int        `json:"quorum"`
+		QuorumPercent    int        `json:"quorum_percent"`
+		Threshold        string     `json:"threshold"`
+		OpensAt          *time.Time `json:"opens_at"`
+		ClosesAt         *time.Time `json:"closes_at"`
+		Publish          bool       `json:"publish"`
+		Options          []struct {
+			Text        string     `json:"text"`
+			Description string     `json:"description"`
+			LinkURL     string     `json:"link_url"`
+			IsCorrect   bool       `json:"is_correct"`
+			StartsAt    *time.Time `json:"starts_at"`
+			EndsAt      *time.Time `json:"ends_at"`
+			TimeZone    string     `json:"time_zone"`
+		} `json:"options"`
 	}
 
 	err = app.readJSON(w, r, &payload)
@@ -144,24 +203,96 @@ func (app *application) CreatePoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if payload.Title == "" {
-		app.writeError(w, errors.New("missing one or more required field ['title']"))
+	poll := models.Poll{
+		Title:            payload.Title,
+		Description:      payload.Description,
+		UserID:           userID,
+		OrganizationID:   payload.OrganizationID,
+		MembersOnly:      payload.MembersOnly,
+		PrivateResults:   payload.PrivateResults,
+		AllowGuests:      payload.AllowGuests,
+		RandomizeOptions: payload.RandomizeOptions,
+		AllowSuggestions: payload.AllowSuggestions,
+		SuggestionLimit:  models.DefaultSuggestionLimit,
+		Kind:             payload.Kind,
+		MaxAnswerLength:  models.DefaultTextAnswerLength,
+		Quiz:             payload.Quiz,
+		TimeBonus:        payload.TimeBonus,
+		CreditBudget:     models.DefaultCreditBudget,
+		Weighted:         payload.Weighted,
+		NoneOfTheAbove:   payload.NoneOfTheAbove,
+		Quorum:           payload.Quorum,
+		QuorumPercent:    payload.QuorumPercent,
+		Threshold:        payload.Threshold,
+		Status:           models.PollStatusDraft,
+		OpensAt:          payload.OpensAt,
+		ClosesAt:         payload.ClosesAt,
+	}
+
+	if payload.SuggestionLimit != nil {
+		poll.SuggestionLimit = *payload.SuggestionLimit
+	}
+
+	if payload.MaxAnswerLength != nil {
+		poll.MaxAnswerLength = *payload.MaxAnswerLength
+	}
+
+	if payload.CreditBudget != nil {
+		poll.CreditBudget = *payload.CreditBudget
+	}
+
+	for _, option := range payload.Options {
+		poll.Options = append(poll.Options, &models.PollOption{
+			Text:        option.Text,
+			Description: option.Description,
+			LinkURL:     option.LinkURL,
+			IsCorrect:   option.IsCorrect,
+			StartsAt:    option.StartsAt,
+			EndsAt:      option.EndsAt,
+			TimeZone:    option.TimeZone,
+		})
+	}
+
+	v := validator.New()
+	poll.Validate(v)
+
+	if payload.Publish && v.Valid() {
+		err = poll.CheckPublishable(time.Now())
+
+		if err != nil {
+			v.AddError("publish", validator.CodeInvalid, err.Error())
+		}
+	}
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	if poll.OrganizationID != nil && app.DB.GetOrganizationRole(*poll.OrganizationID, userID) == "" {
+		app.writeError(w, errNotOrgMember)
 		return
 	}
 
-	poll := models.Poll{
-		Title:       payload.Title,
-		Description: payload.Description,
-		UserID:      userID,
+	if payload.Publish {
+		poll.Status = models.PollStatusPublished
 	}
 
-	created, err := app.DB.CreatePoll(poll)
+	created, err := app.DB.CreatePollWithOptions(poll)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditPollCreate,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &created.ID,
+		PollID:     &created.ID,
+		After:      auditValue(created),
+	})
+
 	app.writeJSON(w, http.StatusOK, created)
 }
 
@@ -174,24 +305,60 @@ func (app *application) GetAllPolls(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	app.writeJSON(w, http.StatusOK, polls)
+	userID := app.optionalUserID(r)
+
+	// list published polls and the drafts the caller can edit
+	visible := []*models.Poll{}
+
+	for _, poll := range polls {
+		if !app.canViewPoll(poll, userID) {
+			continue
+		}
+
+		if !app.canViewResults(poll, userID) {
+			poll.HideVotes()
+		}
+
+		app.hideAnswerKey(poll, userID)
+
+		visible = append(visible, poll)
+	}
+
+	app.writeJSON(w, http.StatusOK, visible)
 }
 
 func (app *application) AddPollOptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
 	// read url parameters
-	pollIDStr := chi.URLParam(r, "pollID")
+	pollID, err := app.readIDParam(r, "pollID")
 
-	pollID, err := strconv.Atoi(pollIDStr)
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
 
 	if err != nil {
-		app.writeError(w, errors.New("invalid poll ID"))
+		app.writeError(w, err)
 		return
 	}
 
-	err = app.checkPollOwnership(w, r)
+	poll, err := app.DB.GetPollByID(pollID)
 
 	if err != nil {
-		app.writeError(w, err, http.StatusUnauthorized)
+		app.writeError(w, err)
+		return
+	}
+
+	if poll.IsPublished() {
+		app.writeError(w, errPollFrozen)
 		return
 	}
 
@@ -206,22 +373,51 @@ func (app *application) AddPollOptions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.DB.AddPollOptions(pollID, payload.Options)
+	v := validator.New()
+	v.Check(len(payload.Options) > 0, "options", validator.CodeRequired, "options is required")
+	v.Check(!poll.IsText(), "options", validator.CodeInvalid, "text polls have no options")
+
+	texts := make([]string, len(payload.Options))
+
+	for i, option := range payload.Options {
+		models.ValidateOption(v, validator.Index("options", i, ""), &option)
+		poll.CheckCorrect(v, validator.Index("options", i, "is_correct"), &option)
+		poll.CheckSlot(v, validator.Index("options", i, ""), &option)
+		texts[i] = option.Text
+	}
+
+	v.NoDuplicates(texts, poll.OptionTexts(0), func(i int) string {
+		return validator.Index("options", i, "text")
+	})
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	err = app.DB.AddPollOptions(pollID, payload.Options, userID)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionCreate,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &pollID,
+		PollID:     &pollID,
+		After:      auditValue(payload.Options),
+	})
+
 	app.writeMessage(w, "options added successfully!")
 }
 
 func (app *application) GetPoll(w http.ResponseWriter, r *http.Request) {
-	pollIDStr := chi.URLParam(r, "pollID")
+	pollID, err := app.readIDParam(r, "pollID")
 
-	pollID, err := strconv.Atoi(pollIDStr)
 	if err != nil {
-		app.writeError(w, errors.New("invalid poll ID"))
+		app.writeError(w, err)
 		return
 	}
 
@@ -232,32 +428,170 @@ func (app *application) GetPoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID := app.optionalUserID(r)
+
+	if !app.canViewPoll(poll, userID) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	poll, err = app.settleOutcome(poll)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canViewResults(poll, userID) {
+		poll.HideVotes()
+	}
+
+	app.hideAnswerKey(poll, userID)
+
+	// editors see the real order, since that is what they reorder
+	if poll.RandomizeOptions && !(userID != 0 && app.canEditPoll(poll.ID, userID)) {
+		poll.ShuffleOptions(app.viewerSeed(r, poll.ID, userID))
+	}
+
+	if app.notModified(w, r, poll.Version, poll) {
+		return
+	}
+
 	app.writeJSON(w, http.StatusOK, poll)
 }
 
+func (app *application) GetPollResults(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.DB.GetPollByID(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	userID := app.optionalUserID(r)
+
+	if !app.canViewPoll(poll, userID) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	if !app.canViewResults(poll, userID) {
+		app.writeError(w, errPrivateResults)
+		return
+	}
+
+	if !poll.IsRanked() && r.URL.Query().Get("method") != "" {
+		app.writeError(w, repository.Validation("invalid_parameter", "only ranked polls take a counting method"))
+		return
+	}
+
+	results, err := app.pollResults(r, poll)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if app.notModified(w, r, poll.Version, results) {
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, results)
+}
+
+// pollResults counts the votes of the poll the way its kind is tallied.
+func (app *application) pollResults(r *http.Request, poll *models.Poll) (any, error) {
+	if poll.IsRanked() {
+		return app.rankedResults(r, poll)
+	}
+
+	if poll.IsQuadratic() {
+		ballots, err := app.DB.GetQuadraticBallots(poll.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return poll.TallyQuadratic(ballots), nil
+	}
+
+	if poll.IsSchedule() {
+		availabilities, err := app.DB.GetAvailabilities(poll.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return poll.TallySchedule(availabilities), nil
+	}
+
+	return app.choiceResults(poll)
+}
+
+func (app *application) GetPollHistory(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canViewPoll(poll, app.optionalUserID(r)) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	history, err := app.DB.GetPollHistory(poll.ID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if app.notModified(w, r, poll.Version, history) {
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, history)
+}
+
 func (app *application) UpdatePoll(w http.ResponseWriter, r *http.Request) {
-	// read url parameters
-	pollIDStr := chi.URLParam(r, "pollID")
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
 
-	pollID, err := strconv.Atoi(pollIDStr)
+	// read url parameters
+	pollID, err := app.readIDParam(r, "pollID")
 
 	if err != nil {
-		app.writeError(w, errors.New("invalid poll ID"))
+		app.writeError(w, err)
 		return
 	}
 
 	// check if user is authorized to update poll
-	err = app.checkPollOwnership(w, r)
+	err = app.checkPollEditor(w, r)
 
 	if err != nil {
-		app.writeError(w, err, http.StatusUnauthorized)
+		app.writeError(w, err)
 		return
 	}
 
 	// read data
 	var payload struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		OpensAt     *time.Time `json:"opens_at"`
+		ClosesAt    *time.Time `json:"closes_at"`
 	}
 
 	err = app.readJSON(w, r, &payload)
@@ -267,68 +601,216 @@ func (app *application) UpdatePoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// update poll
-	poll := models.Poll{
-		Title:       payload.Title,
-		Description: payload.Description,
+	before, err := app.DB.GetPollByID(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, before.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	// PUT replaces the content of the poll, its settings are kept
+	after := *before
+	after.Title = payload.Title
+	after.Description = payload.Description
+	after.OpensAt = payload.OpensAt
+	after.ClosesAt = payload.ClosesAt
+	after.Version = version
+
+	app.savePoll(w, r, userID, before, &after)
+}
+
+// savePoll validates and stores the changed copy after of the poll before.
+// Once the poll is published, when and by whom it can be voted on is frozen.
+func (app *application) savePoll(w http.ResponseWriter, r *http.Request, userID int, before *models.Poll, after *models.Poll) {
+	if before.ChangesFrozenSettings(after) {
+		app.writeError(w, errPollFrozen)
+		return
 	}
 
-	err = app.DB.UpdatePollByID(pollID, poll)
+	v := validator.New()
+	after.Validate(v)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	err := app.DB.UpdatePollByID(before.ID, *after, userID)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	after.Version++
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditPollUpdate,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &before.ID,
+		PollID:     &before.ID,
+		Before:     auditValue(before),
+		After:      auditValue(after),
+	})
+
 	app.writeMessage(w, "Poll updated")
+}
+
+func (app *application) PublishPoll(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollOwnership(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.DB.GetPollByID(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = poll.CheckPublishable(time.Now())
+
+	if err != nil {
+		app.writeError(w, repository.Validation("not_publishable", err.Error()))
+		return
+	}
+
+	err = app.DB.PublishPoll(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditPollPublish,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &pollID,
+		PollID:     &pollID,
+		Before:     auditValue(map[string]string{"status": poll.Status}),
+		After:      auditValue(map[string]string{"status": models.PollStatusPublished}),
+	})
+
+	app.writeMessage(w, "Poll published")
 }
 
 func (app *application) RemovePoll(w http.ResponseWriter, r *http.Request) {
 
-	pollIDStr := chi.URLParam(r, "pollID")
-
-	pollID, err := strconv.Atoi(pollIDStr)
+	pollID, err := app.readIDParam(r, "pollID")
 
 	if err != nil {
-		app.writeError(w, errors.New("invalid poll ID"))
+		app.writeError(w, err)
 		return
 	}
 
 	err = app.checkPollOwnership(w, r)
 
 	if err != nil {
-		app.writeError(w, err, http.StatusUnauthorized)
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.DB.GetPollByID(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, poll.Version)
+
+	if err != nil {
+		app.writeError(w, err)
 		return
 	}
-	err = app.DB.DeletePollByID(pollID)
+
+	err = app.DB.DeletePollByID(pollID, version)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditPollDelete,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &pollID,
+		PollID:     &pollID,
+		Before:     auditValue(poll),
+	})
+
 	app.writeMessage(w, "Poll deleted")
 }
 
 func (app *application) UpdatePollOption(w http.ResponseWriter, r *http.Request) {
-	optionIDStr := chi.URLParam(r, "optionID")
+	userID, err := app.authenticatedUserID(r)
 
-	optionID, err := strconv.Atoi(optionIDStr)
 	if err != nil {
-		app.writeError(w, errors.New("invalid ID"))
+		app.writeError(w, err)
 		return
 	}
 
-	err = app.checkPollOwnership(w, r)
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	option := poll.OptionByID(optionID)
+
+	if option == nil {
+		app.writeError(w, errOptionNotFound)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, option.Version)
 
 	if err != nil {
-		app.writeError(w, err, http.StatusUnauthorized)
+		app.writeError(w, err)
 		return
 	}
 
 	var payload struct {
-		Text string `json:"text"`
+		Text        string     `json:"text"`
+		Description string     `json:"description"`
+		LinkURL     string     `json:"link_url"`
+		IsCorrect   bool       `json:"is_correct"`
+		StartsAt    *time.Time `json:"starts_at"`
+		EndsAt      *time.Time `json:"ends_at"`
+		TimeZone    string     `json:"time_zone"`
+		ResetVotes  bool       `json:"reset_votes"`
 	}
 
 	err = app.readJSON(w, r, &payload)
@@ -338,73 +820,201 @@ func (app *application) UpdatePollOption(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = app.DB.UpdateOptionByID(optionID, payload.Text)
+	after := *option
+	after.Text = payload.Text
+	after.Description = payload.Description
+	after.LinkURL = payload.LinkURL
+	after.IsCorrect = payload.IsCorrect
+	after.StartsAt = payload.StartsAt
+	after.EndsAt = payload.EndsAt
+	after.TimeZone = payload.TimeZone
+	after.Version = version
+
+	app.saveOption(w, r, userID, poll, option, after, payload.ResetVotes)
+}
+
+// saveOption validates and stores after, the changed copy of option.
+func (app *application) saveOption(w http.ResponseWriter, r *http.Request, userID int, poll *models.Poll,
+	option *models.PollOption, after models.PollOption, resetVotes bool) {
+	// votes stay with the wording they were cast against, only managers may
+	// throw them away instead
+	if resetVotes && !app.canManagePoll(poll.ID, userID) {
+		app.writeError(w, repository.Forbidden("reset_votes_forbidden", "only the poll owner can reset votes"))
+		return
+	}
+
+	v := validator.New()
+	models.ValidateOption(v, "", &after)
+	poll.CheckCorrect(v, "is_correct", &after)
+	poll.CheckSlot(v, "", &after)
+	v.NoDuplicates([]string{after.Text}, poll.OptionTexts(option.ID), func(int) string {
+		return "text"
+	})
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	if poll.IsPublished() && option.ChangesFrozenFields(&after) {
+		app.writeError(w, errPollFrozen)
+		return
+	}
+
+	err := app.DB.UpdateOptionByID(option.ID, after, userID, resetVotes)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionUpdate,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &option.ID,
+		PollID:     &poll.ID,
+		Before:     auditValue(option),
+		After: auditValue(map[string]any{
+			"id":          option.ID,
+			"text":        after.Text,
+			"description": after.Description,
+			"link_url":    after.LinkURL,
+			"reset_votes": resetVotes,
+		}),
+	})
+
 	app.writeMessage(w, "Option updated")
 }
 
 func (app *application) RemovePollOption(w http.ResponseWriter, r *http.Request) {
-	optionIDStr := chi.URLParam(r, "optionID")
+	optionID, err := app.readIDParam(r, "optionID")
 
-	optionID, err := strconv.Atoi(optionIDStr)
 	if err != nil {
-		app.writeError(w, errors.New("invalid ID"))
+		app.writeError(w, err)
 		return
 	}
 
-	err = app.checkPollOwnership(w, r)
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	option := poll.OptionByID(optionID)
+
+	if option == nil {
+		app.writeError(w, errOptionNotFound)
+		return
+	}
+
+	if poll.IsPublished() {
+		app.writeError(w, errPollFrozen)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, option.Version)
 
 	if err != nil {
-		app.writeError(w, err, http.StatusUnauthorized)
+		app.writeError(w, err)
 		return
 	}
 
-	err = app.DB.DeleteOptionByID(optionID)
+	err = app.DB.DeleteOptionByID(optionID, version)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionDelete,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &poll.ID,
+		Before:     auditValue(option),
+	})
+
 	app.writeMessage(w, "Option deleted")
 }
 
 func (app *application) Vote(w http.ResponseWriter, r *http.Request) {
-	userIDstr, ok := r.Context().Value("userID").(string)
+	userID, err := app.authenticatedUserID(r)
 
-	if !ok {
-		app.writeError(w, errors.New("missing user"))
+	if err != nil {
+		app.writeError(w, err)
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDstr)
+	pollID, err := app.readIDParam(r, "pollID")
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
-	pollIDStr := chi.URLParam(r, "pollID")
+	optionID, err := app.readIDParam(r, "optionID")
 
-	pollID, err := strconv.Atoi(pollIDStr)
 	if err != nil {
-		app.writeError(w, errors.New("invalid poll ID"))
+		app.writeError(w, err)
 		return
 	}
 
-	optionIDStr := chi.URLParam(r, "optionID")
+	poll, err := app.DB.GetPollByID(pollID)
 
-	optionID, err := strconv.Atoi(optionIDStr)
 	if err != nil {
-		app.writeError(w, errors.New("invalid option ID"))
+		app.writeError(w, err)
+		return
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	if poll.MembersOnly && poll.OrganizationID != nil {
+		if app.DB.GetOrganizationRole(*poll.OrganizationID, userID) == "" {
+			app.writeError(w, repository.Forbidden("members_only", "only organization members can vote on this poll"))
+			return
+		}
+	}
+
+	if poll.IsRanked() || poll.IsQuadratic() {
+		app.writeError(w, errBallotPoll)
+		return
+	}
+
+	if poll.IsSchedule() {
+		app.writeError(w, errSchedulePoll)
 		return
 	}
 
+	if poll.Quiz && poll.HasVoted(userID) {
+		app.writeError(w, errAnswerLocked)
+		return
+	}
+
+	if poll.Weighted {
+		_, err = app.DB.GetVoterWeight(poll.ID, userID)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			app.writeError(w, errNotOnAllowlist)
+			return
+		}
+
+		if err != nil {
+			app.writeError(w, err)
+			return
+		}
+	}
+
 	err = app.DB.Vote(pollID, optionID, userID)
 
 	if err != nil {
@@ -412,49 +1022,221 @@ func (app *application) Vote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditVote,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &pollID,
+		After:      auditValue(map[string]int{"option_id": optionID}),
+	})
+
 	app.writeMessage(w, "Voted successfully")
 
 }
 
 func (app *application) Unvote(w http.ResponseWriter, r *http.Request) {
-	userIDstr, ok := r.Context().Value("userID").(string)
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	if poll.OptionByID(optionID) == nil {
+		app.writeError(w, errOptionNotFound)
+		return
+	}
+
+	if poll.Quiz {
+		app.writeError(w, errAnswerLocked)
+		return
+	}
+
+	err = app.DB.Unvote(poll.ID, optionID, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditUnvote,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &poll.ID,
+		Before:     auditValue(map[string]int{"option_id": optionID}),
+	})
+
+	app.writeMessage(w, "Unvoted successfully")
+}
+
+func (app *application) GuestVote(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.DB.GetPollByID(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !poll.AllowGuests {
+		app.writeError(w, repository.Forbidden("guests_not_allowed", "guest voting is not enabled for this poll"))
+		return
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	guestID, err := app.guestVoter(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.guestTokenLimiter.Allow(guestID) {
+		app.writeError(w, errTooManyRequests)
+		return
+	}
+
+	err = app.DB.GuestVote(pollID, optionID, guestID, clientIP(r))
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditGuestVote,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &pollID,
+		After:      auditValue(map[string]any{"option_id": optionID, "guest_id": guestID}),
+	})
+
+	app.writeMessage(w, "Voted successfully")
+}
+
+func (app *application) GuestUnvote(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
 
-	if !ok {
-		app.writeError(w, errors.New("missing user"))
+	if err != nil {
+		app.writeError(w, err)
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDstr)
+	poll, err := app.DB.GetPollByID(pollID)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
-	optionIDStr := chi.URLParam(r, "optionID")
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	guestID, err := app.guestVoter(w, r)
 
-	optionID, err := strconv.Atoi(optionIDStr)
 	if err != nil {
-		app.writeError(w, errors.New("invalid ID"))
+		app.writeError(w, err)
 		return
 	}
 
-	err = app.DB.Unvote(optionID, userID)
+	if !app.guestTokenLimiter.Allow(guestID) {
+		app.writeError(w, errTooManyRequests)
+		return
+	}
+
+	err = app.DB.GuestUnvote(pollID, guestID)
 
 	if err != nil {
 		app.writeError(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditGuestUnvote,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &pollID,
+		PollID:     &pollID,
+		Before:     auditValue(map[string]string{"guest_id": guestID}),
+	})
+
 	app.writeMessage(w, "Unvoted successfully")
 }
 
 func (app *application) GetOptionVotes(w http.ResponseWriter, r *http.Request) {
-	optionIDStr := chi.URLParam(r, "optionID")
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.DB.GetPollByID(pollID)
 
-	optionID, err := strconv.Atoi(optionIDStr)
 	if err != nil {
-		app.writeError(w, errors.New("invalid ID"))
+		app.writeError(w, err)
+		return
+	}
+
+	userID := app.optionalUserID(r)
+
+	if !app.canViewPoll(poll, userID) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	if !app.canViewResults(poll, userID) {
+		app.writeError(w, errPrivateResults)
+		return
+	}
+
+	if poll.OptionByID(optionID) == nil {
+		app.writeError(w, errOptionNotFound)
 		return
 	}
 
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+	"polling/internal/wordfreq"
+	"time"
+)
+
+var errNotTextPoll = repository.Conflict("not_text_poll", "this poll does not take text answers")
+
+// text answer routes handlers
+
+// SubmitTextAnswer stores the answer of the user to an open text poll.
+// Answering again replaces the earlier answer.
+func (app *application) SubmitTextAnswer(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canViewPoll(poll, userID) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	if !poll.IsText() {
+		app.writeError(w, errNotTextPoll)
+		return
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	if poll.MembersOnly && poll.OrganizationID != nil {
+		if app.DB.GetOrganizationRole(*poll.OrganizationID, userID) == "" {
+			app.writeError(w, repository.Forbidden("members_only", "only organization members can answer this poll"))
+			return
+		}
+	}
+
+	var payload struct {
+		Text string `json:"text"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	models.ValidateTextAnswer(v, "text", poll, payload.Text)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	answer, err := app.DB.SaveTextAnswer(poll.ID, userID, payload.Text)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditTextAnswerSave,
+		TargetType: models.AuditTargetTextAnswer,
+		TargetID:   &answer.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(answer),
+	})
+
+	app.writeJSON(w, http.StatusOK, answer)
+}
+
+// GetTextAnswers lists the answers to a text poll a page at a time, newest
+// first. The answers can be searched and filtered by their moderation flags.
+func (app *application) GetTextAnswers(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.textPollFromRequest(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	filter := models.TextAnswerFilter{Search: r.URL.Query().Get("q")}
+
+	filter.Hidden, err = app.readQueryBool(r, "hidden")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	filter.Flagged, err = app.readQueryBool(r, "flagged")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	filter.Page, err = app.readQueryInt(r, "page", 1)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	filter.PageSize, err = app.readQueryInt(r, "page_size", models.DefaultTextAnswerPageSize)
+
+	if err != nil || filter.PageSize > models.MaxTextAnswerPageSize {
+		app.writeError(w, repository.Validation("invalid_parameter", "invalid page_size parameter"))
+		return
+	}
+
+	page, err := app.DB.GetTextAnswers(poll.ID, filter)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, page)
+}
+
+// GetTextAnswerSummary returns the words used most often in the answers to a
+// text poll. Hidden answers are left out.
+func (app *application) GetTextAnswerSummary(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.textPollFromRequest(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	limit, err := app.readQueryInt(r, "limit", models.DefaultSummaryWords)
+
+	if err != nil || limit > models.MaxSummaryWords {
+		app.writeError(w, repository.Validation("invalid_parameter", "invalid limit parameter"))
+		return
+	}
+
+	texts, err := app.DB.GetVisibleTextAnswers(poll.ID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, models.TextSummary{
+		PollID:  poll.ID,
+		Answers: len(texts),
+		Words:   wordfreq.Count(texts, limit),
+	})
+}
+
+// ModerateTextAnswer hides or flags a single answer to a text poll. Flags
+// left out of the payload keep their value.
+func (app *application) ModerateTextAnswer(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.textPollFromRequest(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	answerID, err := app.readIDParam(r, "answerID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Hidden  *bool `json:"hidden"`
+		Flagged *bool `json:"flagged"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(payload.Hidden != nil || payload.Flagged != nil, "hidden", validator.CodeRequired, "hidden or flagged is required")
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	answer, err := app.DB.ModerateTextAnswer(poll.ID, answerID, payload.Hidden, payload.Flagged)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditTextAnswerModerate,
+		TargetType: models.AuditTargetTextAnswer,
+		TargetID:   &answer.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(map[string]bool{"hidden": answer.Hidden, "flagged": answer.Flagged}),
+	})
+
+	app.writeJSON(w, http.StatusOK, answer)
+}
+
+// textPollFromRequest loads the text poll named by the pollID URL parameter
+// for someone who manages it.
+func (app *application) textPollFromRequest(w http.ResponseWriter, r *http.Request) (*models.Poll, error) {
+	err := app.checkPollOwnership(w, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !poll.IsText() {
+		return nil, errNotTextPoll
+	}
+
+	return poll, nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"testing"
+)
+
+func TestSubmitTextAnswer(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		text           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "valid answer", pollID: "9", text: "Better coffee", expectedStatus: http.StatusOK},
+		{name: "multibyte answer at the limit", pollID: "9", text: "ééééééééééééééééééé!", expectedStatus: http.StatusOK},
+		{name: "empty answer", pollID: "9", text: "", expectedStatus: http.StatusBadRequest},
+		{name: "answer too long", pollID: "9", text: "Coffee machine on every floor", expectedStatus: http.StatusBadRequest},
+		{name: "choice poll", pollID: "4", text: "Better coffee", expectedStatus: http.StatusConflict, expectedCode: "not_text_poll"},
+		{name: "draft poll", pollID: "1", text: "Better coffee", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]string{"text": tt.text})
+
+			req := httptest.NewRequest("PUT", "/polls/"+tt.pollID+"/answers", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, 2)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SubmitTextAnswer))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var answer models.TextAnswer
+			err = json.Unmarshal(rr.Body.Bytes(), &answer)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if answer.Text != tt.text || answer.UserID != 2 {
+				t.Errorf("expected answer %q by user 2, got %q by user %d", tt.text, answer.Text, answer.UserID)
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditTextAnswerSave {
+				t.Errorf("expected a %s audit event, got %v", models.AuditTextAnswerSave, events)
+			}
+		})
+	}
+}
+
+func TestGetTextAnswers(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		query          string
+		userID         int
+		expectedStatus int
+		expectedIDs    []int
+		expectedTotal  int
+	}{
+		{name: "every answer", pollID: "9", query: "", userID: 1, expectedStatus: http.StatusOK, expectedIDs: []int{3, 2, 1}, expectedTotal: 3},
+		{name: "second page", pollID: "9", query: "?page=2&page_size=2", userID: 1, expectedStatus: http.StatusOK, expectedIDs: []int{1}, expectedTotal: 3},
+		{name: "search", pollID: "9", query: "?q=MACHINE", userID: 1, expectedStatus: http.StatusOK, expectedIDs: []int{2}, expectedTotal: 1},
+		{name: "visible only", pollID: "9", query: "?hidden=false", userID: 1, expectedStatus: http.StatusOK, expectedIDs: []int{3, 1}, expectedTotal: 2},
+		{name: "flagged only", pollID: "9", query: "?flagged=true", userID: 1, expectedStatus: http.StatusOK, expectedIDs: []int{3}, expectedTotal: 1},
+		{name: "invalid page", pollID: "9", query: "?page=0", userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "page too large", pollID: "9", query: "?page_size=500", userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "invalid flag", pollID: "9", query: "?hidden=maybe", userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "choice poll", pollID: "4", query: "", userID: 1, expectedStatus: http.StatusConflict},
+		{name: "not the owner", pollID: "9", query: "", userID: 2, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/"+tt.pollID+"/answers"+tt.query, nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.GetTextAnswers))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var page models.TextAnswerPage
+			err = json.Unmarshal(rr.Body.Bytes(), &page)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if page.Total != tt.expectedTotal {
+				t.Errorf("expected total %d, got %d", tt.expectedTotal, page.Total)
+			}
+
+			ids := []int{}
+			for _, answer := range page.Answers {
+				ids = append(ids, answer.ID)
+			}
+
+			if len(ids) != len(tt.expectedIDs) {
+				t.Fatalf("expected answers %v, got %v", tt.expectedIDs, ids)
+			}
+
+			for i := range ids {
+				if ids[i] != tt.expectedIDs[i] {
+					t.Fatalf("expected answers %v, got %v", tt.expectedIDs, ids)
+				}
+			}
+		})
+	}
+}
+
+func TestGetTextAnswerSummary(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/9/answers/summary?limit=2", nil)
+	req = addURLParamToRequest(req, "pollID", "9")
+
+	token, err := generateTestJWT(app.auth, 1)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	authHandler := app.authRequired(http.HandlerFunc(app.GetTextAnswerSummary))
+	authHandler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var summary models.TextSummary
+	err = json.Unmarshal(rr.Body.Bytes(), &summary)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	// the hidden answer about the coffee machine is left out
+	if summary.Answers != 2 {
+		t.Errorf("expected 2 answers, got %d", summary.Answers)
+	}
+
+	if len(summary.Words) != 2 || summary.Words[0].Word != "coffee" || summary.Words[0].Count != 2 {
+		t.Fatalf("expected coffee to be used twice, got %v", summary.Words)
+	}
+
+	if summary.Words[1].Word != "better" {
+		t.Errorf("expected better to come second, got %v", summary.Words)
+	}
+}
+
+func TestModerateTextAnswer(t *testing.T) {
+	tests := []struct {
+		name            string
+		answerID        string
+		payload         string
+		userID          int
+		expectedStatus  int
+		expectedHidden  bool
+		expectedFlagged bool
+	}{
+		{name: "hide", answerID: "1", payload: `{"hidden":true}`, userID: 1, expectedStatus: http.StatusOK, expectedHidden: true},
+		{name: "unflag", answerID: "3", payload: `{"flagged":false}`, userID: 1, expectedStatus: http.StatusOK},
+		{name: "unhide and flag", answerID: "2", payload: `{"hidden":false,"flagged":true}`, userID: 1, expectedStatus: http.StatusOK, expectedFlagged: true},
+		{name: "nothing to change", answerID: "1", payload: `{}`, userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "unknown answer", answerID: "99", payload: `{"hidden":true}`, userID: 1, expectedStatus: http.StatusNotFound},
+		{name: "not the owner", answerID: "1", payload: `{"hidden":true}`, userID: 2, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("PUT", "/polls/9/answers/"+tt.answerID+"/moderation", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", "9")
+			req = addURLParamToRequest(req, "answerID", tt.answerID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.ModerateTextAnswer))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var answer models.TextAnswer
+			err = json.Unmarshal(rr.Body.Bytes(), &answer)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if answer.Hidden != tt.expectedHidden || answer.Flagged != tt.expectedFlagged {
+				t.Errorf("expected hidden %v and flagged %v, got %v and %v", tt.expectedHidden, tt.expectedFlagged,
+					answer.Hidden, answer.Flagged)
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditTextAnswerModerate {
+				t.Errorf("expected a %s audit event, got %v", models.AuditTextAnswerModerate, events)
+			}
+		})
+	}
+}
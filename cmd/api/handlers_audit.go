@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"strconv"
+)
+
+// audit routes handlers
+
+// GetAuditEvents lists audit events by poll and/or actor. Admins may query
+// anything, poll managers the events of their polls, and everyone else only
+// their own actions.
+func (app *application) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var filter models.AuditFilter
+
+	filter.PollID, err = app.readQueryID(r, "poll_id")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	filter.ActorID, err = app.readQueryID(r, "user_id")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+
+		if err != nil || filter.Limit < 1 {
+			app.writeError(w, repository.Validation("invalid_parameter", "invalid limit parameter"))
+			return
+		}
+	}
+
+	switch {
+	case app.DB.IsAdmin(userID):
+	case filter.PollID != nil && app.canManagePoll(*filter.PollID, userID):
+	case filter.PollID == nil && filter.ActorID != nil && *filter.ActorID == userID:
+	default:
+		app.writeError(w, repository.Forbidden("audit_forbidden", "you are not authorized to view these audit events"))
+		return
+	}
+
+	events, err := app.DB.GetAuditEvents(filter)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, events)
+}
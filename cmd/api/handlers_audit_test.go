@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+func TestUpdatePollIsAudited(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	jsonPayload, _ := json.Marshal(map[string]string{"title": "Updated", "description": "Updated"})
+
+	req := httptest.NewRequest("PUT", "/polls/1", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("If-Match", `"1"`)
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "203.0.113.7:4000"
+	req = addURLParamToRequest(req, "pollID", "1")
+
+	token, err := generateTestJWT(app.auth, 1)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	handler := middleware.RequestID(app.authRequired(http.HandlerFunc(app.UpdatePoll)))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	events := app.DB.(*mocks.MockDBRepo).AuditEvents
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(events))
+	}
+
+	event := events[0]
+
+	if event.Action != models.AuditPollUpdate {
+		t.Errorf("expected action %s, got %s", models.AuditPollUpdate, event.Action)
+	}
+	if event.ActorID == nil || *event.ActorID != 1 {
+		t.Errorf("expected actor 1, got %v", event.ActorID)
+	}
+	if event.PollID == nil || *event.PollID != 1 {
+		t.Errorf("expected poll 1, got %v", event.PollID)
+	}
+	if event.IP != "203.0.113.7" {
+		t.Errorf("expected IP 203.0.113.7, got %s", event.IP)
+	}
+	if event.RequestID == "" {
+		t.Error("expected a request ID")
+	}
+
+	var before, after models.Poll
+	json.Unmarshal(event.Before, &before)
+	json.Unmarshal(event.After, &after)
+
+	if before.Title != "Draft Poll" || after.Title != "Updated" {
+		t.Errorf("expected title to change from 'Draft Poll' to 'Updated', got '%s' -> '%s'", before.Title, after.Title)
+	}
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		query          string
+		expectedStatus int
+	}{
+		{name: "admin queries anything", userID: 9, query: "", expectedStatus: http.StatusOK},
+		{name: "poll owner queries poll", userID: 1, query: "?poll_id=1", expectedStatus: http.StatusOK},
+		{name: "organization admin queries poll", userID: 2, query: "?poll_id=3", expectedStatus: http.StatusOK},
+		{name: "user queries own actions", userID: 4, query: "?user_id=4", expectedStatus: http.StatusOK},
+		{name: "user queries other users", userID: 4, query: "?user_id=1", expectedStatus: http.StatusForbidden},
+		{name: "outsider queries poll", userID: 4, query: "?poll_id=1", expectedStatus: http.StatusForbidden},
+		{name: "no filter", userID: 1, query: "", expectedStatus: http.StatusForbidden},
+		{name: "invalid poll ID", userID: 1, query: "?poll_id=abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/audit"+tt.query, nil)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.GetAuditEvents))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+	"time"
+)
+
+var (
+	errNotBallotPoll = repository.Conflict("not_ballot_poll", "this poll does not take ballots")
+	errBallotPoll    = repository.Conflict("ballot_poll", "this poll is voted on with a ballot")
+)
+
+// ballot routes handlers
+
+// SubmitBallot stores the ballot of the user on an open ranked or quadratic
+// poll. Ranked ballots order the options, quadratic ballots give votes to
+// them. Submitting again replaces the earlier ballot.
+func (app *application) SubmitBallot(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canViewPoll(poll, userID) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	if !poll.IsRanked() && !poll.IsQuadratic() {
+		app.writeError(w, errNotBallotPoll)
+		return
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	if poll.MembersOnly && poll.OrganizationID != nil {
+		if app.DB.GetOrganizationRole(*poll.OrganizationID, userID) == "" {
+			app.writeError(w, repository.Forbidden("members_only", "only organization members can vote on this poll"))
+			return
+		}
+	}
+
+	var payload struct {
+		Ranking []int                  `json:"ranking"`
+		Votes   []models.QuadraticVote `json:"votes"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if poll.IsQuadratic() {
+		app.saveQuadraticBallot(w, r, userID, poll, payload.Votes)
+		return
+	}
+
+	v := validator.New()
+	models.ValidateBallot(v, "ranking", poll, payload.Ranking)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	ballot, err := app.DB.SaveBallot(poll.ID, userID, payload.Ranking)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditBallotSave,
+		TargetType: models.AuditTargetBallot,
+		TargetID:   &ballot.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(ballot),
+	})
+
+	app.writeJSON(w, http.StatusOK, ballot)
+}
+
+// saveQuadraticBallot stores the votes of the user on a quadratic poll once
+// they fit the credit budget.
+func (app *application) saveQuadraticBallot(w http.ResponseWriter, r *http.Request, userID int, poll *models.Poll,
+	votes []models.QuadraticVote) {
+	v := validator.New()
+	models.ValidateQuadraticBallot(v, "votes", poll, votes)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	ballot, err := app.DB.SaveQuadraticBallot(poll.ID, userID, votes)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditBallotSave,
+		TargetType: models.AuditTargetBallot,
+		PollID:     &poll.ID,
+		After:      auditValue(ballot),
+	})
+
+	app.writeJSON(w, http.StatusOK, ballot)
+}
+
+// rankedResults counts the ballots of a ranked poll by the method named in
+// the method query parameter, instant-runoff unless told otherwise.
+func (app *application) rankedResults(r *http.Request, poll *models.Poll) (*models.RankedResults, error) {
+	method := r.URL.Query().Get("method")
+
+	switch method {
+	case "":
+		method = models.TabulationIRV
+	case models.TabulationIRV, models.TabulationSchulze:
+	default:
+		return nil, repository.Validation("invalid_parameter", "invalid method parameter")
+	}
+
+	ballots, err := app.DB.GetBallots(poll.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return poll.TallyBallots(ballots, method), nil
+}
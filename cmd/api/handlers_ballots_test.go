@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"reflect"
+	"testing"
+)
+
+func TestSubmitBallot(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		ranking        []int
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "full ranking", pollID: "12", ranking: []int{5, 1, 3, 2, 4}, expectedStatus: http.StatusOK},
+		{name: "partial ranking", pollID: "12", ranking: []int{3}, expectedStatus: http.StatusOK},
+		{name: "duplicate option", pollID: "12", ranking: []int{3, 3}, expectedStatus: http.StatusBadRequest},
+		{name: "choice poll", pollID: "4", ranking: []int{1, 2}, expectedStatus: http.StatusConflict, expectedCode: "not_ballot_poll"},
+		{name: "draft poll", pollID: "1", ranking: []int{1, 2}, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string][]int{"ranking": tt.ranking})
+
+			req := httptest.NewRequest("PUT", "/polls/"+tt.pollID+"/ballot", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, 2)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SubmitBallot))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var ballot models.Ballot
+			err = json.Unmarshal(rr.Body.Bytes(), &ballot)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if !reflect.DeepEqual(ballot.Ranking, tt.ranking) || ballot.UserID != 2 {
+				t.Errorf("expected ranking %v by user 2, got %v by user %d", tt.ranking, ballot.Ranking, ballot.UserID)
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditBallotSave {
+				t.Errorf("expected a %s audit event, got %v", models.AuditBallotSave, events)
+			}
+		})
+	}
+}
+
+func TestVoteOnBallotPoll(t *testing.T) {
+	for _, pollID := range []string{"12", "13"} {
+		t.Run("poll "+pollID, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("PUT", "/polls/"+pollID+"/options/1/votes", nil)
+			req = addURLParamToRequest(req, "pollID", pollID)
+			req = addURLParamToRequest(req, "optionID", "1")
+
+			token, err := generateTestJWT(app.auth, 2)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.Vote))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusConflict {
+				t.Errorf("expected status %d, got %d", http.StatusConflict, rr.Code)
+			}
+		})
+	}
+}
+
+func TestGetRankedPollResults(t *testing.T) {
+	tests := []struct {
+		name            string
+		pollID          string
+		query           string
+		expectedStatus  int
+		expectedMethod  string
+		expectedWinners []int
+	}{
+		{name: "instant-runoff by default", pollID: "12", query: "", expectedStatus: http.StatusOK, expectedMethod: models.TabulationIRV, expectedWinners: []int{1}},
+		{name: "instant-runoff", pollID: "12", query: "?method=irv", expectedStatus: http.StatusOK, expectedMethod: models.TabulationIRV, expectedWinners: []int{1}},
+		{name: "Schulze", pollID: "12", query: "?method=schulze", expectedStatus: http.StatusOK, expectedMethod: models.TabulationSchulze, expectedWinners: []int{5}},
+		{name: "unknown method", pollID: "12", query: "?method=borda", expectedStatus: http.StatusBadRequest},
+		{name: "method on a choice poll", pollID: "4", query: "?method=schulze", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/"+tt.pollID+"/results"+tt.query, nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var results models.RankedResults
+			err := json.Unmarshal(rr.Body.Bytes(), &results)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if results.Method != tt.expectedMethod || results.Ballots != 45 {
+				t.Errorf("expected 45 ballots counted by %s, got %d by %s", tt.expectedMethod, results.Ballots, results.Method)
+			}
+
+			var winners []int
+
+			switch tt.expectedMethod {
+			case models.TabulationSchulze:
+				if results.Schulze == nil || results.InstantRunoff != nil {
+					t.Fatalf("expected only a Schulze count, got %s", rr.Body.String())
+				}
+				winners = results.Schulze.Winners
+
+				if len(results.Schulze.StrongestPaths) != 5 || len(results.Schulze.Cycles) != 1 {
+					t.Errorf("expected a 5x5 strongest path table and one cycle, got %s", rr.Body.String())
+				}
+			default:
+				if results.InstantRunoff == nil || results.Schulze != nil {
+					t.Fatalf("expected only an instant-runoff count, got %s", rr.Body.String())
+				}
+				winners = results.InstantRunoff.Winners
+			}
+
+			if !reflect.DeepEqual(winners, tt.expectedWinners) {
+				t.Errorf("expected winners %v, got %v", tt.expectedWinners, winners)
+			}
+		})
+	}
+}
+
+func TestSubmitQuadraticBallot(t *testing.T) {
+	tests := []struct {
+		name            string
+		votes           []models.QuadraticVote
+		expectedStatus  int
+		expectedCredits int
+	}{
+		{name: "whole budget", votes: []models.QuadraticVote{{OptionID: 1, Votes: 3}, {OptionID: 2, Votes: 4}}, expectedStatus: http.StatusOK, expectedCredits: 25},
+		{name: "part of the budget", votes: []models.QuadraticVote{{OptionID: 3, Votes: 2}}, expectedStatus: http.StatusOK, expectedCredits: 4},
+		{name: "over budget", votes: []models.QuadraticVote{{OptionID: 1, Votes: 4}, {OptionID: 2, Votes: 4}}, expectedStatus: http.StatusBadRequest},
+		{name: "no votes", votes: []models.QuadraticVote{}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]any{"votes": tt.votes})
+
+			req := httptest.NewRequest("PUT", "/polls/13/ballot", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", "13")
+
+			token, err := generateTestJWT(app.auth, 2)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SubmitBallot))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var ballot models.QuadraticBallot
+			err = json.Unmarshal(rr.Body.Bytes(), &ballot)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if ballot.Credits != tt.expectedCredits || ballot.UserID != 2 {
+				t.Errorf("expected %d credits used by user 2, got %d by user %d", tt.expectedCredits, ballot.Credits, ballot.UserID)
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditBallotSave {
+				t.Errorf("expected a %s audit event, got %v", models.AuditBallotSave, events)
+			}
+		})
+	}
+}
+
+func TestGetQuadraticPollResults(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/13/results", nil)
+	req = addURLParamToRequest(req, "pollID", "13")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var results models.QuadraticResults
+	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if results.CreditBudget != 25 || results.Ballots != 2 || results.TotalVotes != 12 {
+		t.Errorf("expected 2 ballots with 12 votes on a budget of 25, got %s", rr.Body.String())
+	}
+
+	options := []models.QuadraticOptionResult{
+		{OptionID: 1, Text: "Dark mode", Votes: 7, Credits: 25, Voters: 2},
+		{OptionID: 2, Text: "Offline sync", Votes: 4, Credits: 16, Voters: 1},
+		{OptionID: 3, Text: "Calendar export", Votes: 1, Credits: 1, Voters: 1},
+	}
+
+	for i, expected := range options {
+		if i >= len(results.Options) || *results.Options[i] != expected {
+			t.Errorf("expected option result %+v, got %s", expected, rr.Body.String())
+		}
+	}
+
+	// votes on the removed option 4 are not counted
+	voters := []models.VoterCredits{
+		{UserID: 2, Votes: 5, Credits: 17, Remaining: 8},
+		{UserID: 3, Votes: 7, Credits: 25, Remaining: 0},
+	}
+
+	for i, expected := range voters {
+		if i >= len(results.Voters) || *results.Voters[i] != expected {
+			t.Errorf("expected voter credits %+v, got %s", expected, rr.Body.String())
+		}
+	}
+}
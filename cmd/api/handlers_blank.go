@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+	"time"
+)
+
+var (
+	errNoBlankVotes = repository.Conflict("no_blank_votes", "only choice polls that are not quizzes take blank votes")
+	errCannotReopen = repository.Conflict("cannot_reopen", "only closed polls that none of the above won can be reopened")
+)
+
+// blank vote routes handlers
+
+// CastBlankVote lets the user abstain on an open choice poll, or vote for
+// none of the above when the poll offers it. Any votes the user cast for
+// options are dropped, voting for an option later withdraws the blank vote.
+func (app *application) CastBlankVote(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.blankVotePollFromRequest(r, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Choice string `json:"choice"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	models.ValidateBlankVote(v, "choice", poll, payload.Choice)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	vote, err := app.DB.SaveBlankVote(poll.ID, userID, payload.Choice)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditBlankVote,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(map[string]string{"choice": payload.Choice}),
+	})
+
+	app.writeJSON(w, http.StatusOK, vote)
+}
+
+// WithdrawBlankVote removes the abstention or none of the above vote of the
+// user on an open choice poll.
+func (app *application) WithdrawBlankVote(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.blankVotePollFromRequest(r, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.DB.RemoveBlankVote(poll.ID, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditBlankUnvote,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+	})
+
+	app.writeMessage(w, "Blank vote withdrawn")
+}
+
+// ReopenPoll turns a closed poll that none of the above won back into a
+// draft, so it can be run again with other options. Its votes are kept but no
+// longer count once it is published again.
+func (app *application) ReopenPoll(w http.ResponseWriter, r *http.Request) {
+	err := app.checkPollOwnership(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, poll.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	results, err := app.choiceResults(poll)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !poll.CanReopen(results, time.Now()) {
+		app.writeError(w, errCannotReopen)
+		return
+	}
+
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.DB.ReopenPoll(poll.ID, version, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditPollReopen,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		Before:     auditValue(map[string]any{"poll": poll, "results": results}),
+		After:      auditValue(map[string]string{"status": models.PollStatusDraft}),
+	})
+
+	app.writeMessage(w, "Poll reopened as a draft")
+}
+
+// choiceResults counts the votes of a choice poll, by the weights of the
+// voters on weighted polls, together with its blank votes.
+func (app *application) choiceResults(poll *models.Poll) (*models.PollResults, error) {
+	results := poll.Tally()
+
+	if poll.Weighted {
+		weights, err := app.DB.GetVoterWeights(poll.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		results = poll.TallyWeighted(weights)
+	}
+
+	if !poll.TakesBlankVotes() {
+		return results, nil
+	}
+
+	votes, err := app.DB.GetBlankVotes(poll.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	poll.CountBlankVotes(results, votes)
+
+	return results, nil
+}
+
+// blankVotePollFromRequest loads the poll named by the pollID URL parameter
+// if the user may cast a blank vote on it right now.
+func (app *application) blankVotePollFromRequest(r *http.Request, userID int) (*models.Poll, error) {
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !app.canViewPoll(poll, userID) {
+		return nil, errPollNotFound
+	}
+
+	if !poll.TakesBlankVotes() {
+		return nil, errNoBlankVotes
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		return nil, errPollNotOpen
+	}
+
+	if poll.MembersOnly && poll.OrganizationID != nil {
+		if app.DB.GetOrganizationRole(*poll.OrganizationID, userID) == "" {
+			return nil, repository.Forbidden("members_only", "only organization members can vote on this poll")
+		}
+	}
+
+	if poll.Weighted {
+		_, err = app.DB.GetVoterWeight(poll.ID, userID)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errNotOnAllowlist
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return poll, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"strings"
+	"testing"
+)
+
+func TestCastBlankVote(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		userID         int
+		choice         string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "abstain", pollID: "4", userID: 2, choice: models.BlankAbstain, expectedStatus: http.StatusOK},
+		{name: "none of the above", pollID: "19", userID: 2, choice: models.BlankNoneOfTheAbove, expectedStatus: http.StatusOK},
+		{name: "none of the above not offered", pollID: "4", userID: 2, choice: models.BlankNoneOfTheAbove, expectedStatus: http.StatusBadRequest},
+		{name: "closed poll", pollID: "18", userID: 2, choice: models.BlankAbstain, expectedStatus: http.StatusForbidden, expectedCode: "poll_not_open"},
+		{name: "ranked poll", pollID: "12", userID: 2, choice: models.BlankAbstain, expectedStatus: http.StatusConflict, expectedCode: "no_blank_votes"},
+		{name: "quiz", pollID: "10", userID: 2, choice: models.BlankAbstain, expectedStatus: http.StatusConflict, expectedCode: "no_blank_votes"},
+		{name: "weighted poll voter on the allowlist", pollID: "14", userID: 5, choice: models.BlankAbstain, expectedStatus: http.StatusOK},
+		{name: "weighted poll voter not on the allowlist", pollID: "14", userID: 6, choice: models.BlankAbstain, expectedStatus: http.StatusForbidden, expectedCode: "not_on_allowlist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]string{"choice": tt.choice})
+
+			req := httptest.NewRequest("PUT", "/polls/"+tt.pollID+"/blank-vote", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.CastBlankVote))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditBlankVote {
+				t.Errorf("expected a %s audit event, got %v", models.AuditBlankVote, events)
+			}
+		})
+	}
+}
+
+func TestWithdrawBlankVote(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "voter who abstained", userID: 5, expectedStatus: http.StatusOK},
+		{name: "voter without a blank vote", userID: 2, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("DELETE", "/polls/19/blank-vote", nil)
+			req = addURLParamToRequest(req, "pollID", "19")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.WithdrawBlankVote))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetResultsWithBlankVotes(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/19/results", nil)
+	req = addURLParamToRequest(req, "pollID", "19")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var results models.PollResults
+	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	// one vote for an option and two for none of the above, the abstention
+	// stays out of the turnout
+	if results.TotalVotes != 1 || results.Turnout != 3 || results.Abstentions != 1 {
+		t.Errorf("expected 1 vote, a turnout of 3 and 1 abstention, got %s", rr.Body.String())
+	}
+
+	if results.NoneOfTheAbove == nil || *results.NoneOfTheAbove != 2 || !results.NoneOfTheAboveWins {
+		t.Errorf("expected none of the above to win with 2 votes, got %s", rr.Body.String())
+	}
+}
+
+func TestResultsWithoutNoneOfTheAbove(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/4/results", nil)
+	req = addURLParamToRequest(req, "pollID", "4")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+	handler.ServeHTTP(rr, req)
+
+	if strings.Contains(rr.Body.String(), "none_of_the_above") {
+		t.Errorf("expected no none of the above count, got %s", rr.Body.String())
+	}
+}
+
+func TestReopenPoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		userID         int
+		ifMatch        string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "closed poll none of the above won", pollID: "18", userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusOK},
+		{name: "poll still open", pollID: "19", userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusConflict, expectedCode: "cannot_reopen"},
+		{name: "poll without none of the above", pollID: "5", userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusConflict, expectedCode: "cannot_reopen"},
+		{name: "not the owner", pollID: "18", userID: 2, ifMatch: `"1"`, expectedStatus: http.StatusForbidden},
+		{name: "missing If-Match", pollID: "18", userID: 1, expectedStatus: http.StatusPreconditionRequired},
+		{name: "stale If-Match", pollID: "18", userID: 1, ifMatch: `"2"`, expectedStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/reopen", nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.ReopenPoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditPollReopen || events[0].Before == nil {
+				t.Errorf("expected a %s audit event holding the poll and its results, got %v", models.AuditPollReopen, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+)
+
+// collaborator routes handlers
+
+func (app *application) GetPollCollaborators(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canManagePoll(pollID, userID) && app.DB.GetPollCollaboratorRole(pollID, userID) == "" {
+		app.writeError(w, repository.Forbidden("not_poll_collaborator", "you are not authorized to view this poll's collaborators"))
+		return
+	}
+
+	collaborators, err := app.DB.GetPollCollaborators(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, collaborators)
+}
+
+func (app *application) AddPollCollaborator(w http.ResponseWriter, r *http.Request) {
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollOwnership(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Required("username", payload.Username)
+	v.In("role", payload.Role, models.CollaboratorRoleEditor, models.CollaboratorRoleViewer)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	user, err := app.DB.GetUserByUsername(payload.Username)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if app.DB.IsPollOwner(pollID, user.ID) {
+		app.writeError(w, repository.Validation("owner_not_collaborator", "the poll owner cannot be a collaborator"))
+		return
+	}
+
+	previousRole := app.DB.GetPollCollaboratorRole(pollID, user.ID)
+
+	err = app.DB.AddPollCollaborator(pollID, user.ID, payload.Role)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	event := models.AuditEvent{
+		Action:     models.AuditCollaboratorAdd,
+		TargetType: models.AuditTargetUser,
+		TargetID:   &user.ID,
+		PollID:     &pollID,
+		After:      auditValue(map[string]string{"role": payload.Role}),
+	}
+
+	if previousRole != "" {
+		event.Before = auditValue(map[string]string{"role": previousRole})
+	}
+
+	app.audit(r, event)
+
+	app.writeMessage(w, "Collaborator added")
+}
+
+func (app *application) RemovePollCollaborator(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	collaboratorID, err := app.readIDParam(r, "userID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	// collaborators can always leave a poll
+	if collaboratorID != userID && !app.canManagePoll(pollID, userID) {
+		app.writeError(w, errNotPollEditor)
+		return
+	}
+
+	role := app.DB.GetPollCollaboratorRole(pollID, collaboratorID)
+
+	err = app.DB.RemovePollCollaborator(pollID, collaboratorID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditCollaboratorRemove,
+		TargetType: models.AuditTargetUser,
+		TargetID:   &collaboratorID,
+		PollID:     &pollID,
+		Before:     auditValue(map[string]string{"role": role}),
+	})
+
+	app.writeMessage(w, "Collaborator removed")
+}
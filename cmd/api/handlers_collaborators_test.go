@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCollaboratorUpdatePollOption(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "owner", userID: 1, expectedStatus: http.StatusOK},
+		{name: "editor", userID: 5, expectedStatus: http.StatusOK},
+		{name: "viewer", userID: 6, expectedStatus: http.StatusForbidden},
+		{name: "outsider", userID: 4, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]string{"text": "fixed typo"})
+
+			req := httptest.NewRequest("PUT", "/polls/1/options/1", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("If-Match", `"1"`)
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", "1")
+			req = addURLParamToRequest(req, "optionID", "1")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.UpdatePollOption))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestPrivatePollResults(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		withAuth       bool
+		expectedStatus int
+	}{
+		{name: "anonymous", withAuth: false, expectedStatus: http.StatusForbidden},
+		{name: "owner", userID: 1, withAuth: true, expectedStatus: http.StatusOK},
+		{name: "result viewer", userID: 6, withAuth: true, expectedStatus: http.StatusOK},
+		{name: "outsider", userID: 4, withAuth: true, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/5/results", nil)
+			req = addURLParamToRequest(req, "pollID", "5")
+
+			if tt.withAuth {
+				token, err := generateTestJWT(app.auth, tt.userID)
+				if err != nil {
+					t.Fatalf("Failed to generate token: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
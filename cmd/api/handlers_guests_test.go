@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newGuestVoteRequest(pollID string, cookie *http.Cookie) *http.Request {
+	req := httptest.NewRequest("PUT", "/polls/"+pollID+"/options/1/guest-votes", nil)
+	req = addURLParamToRequest(req, "pollID", pollID)
+	req = addURLParamToRequest(req, "optionID", "1")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func guestCookie(rr *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == guestCookieName {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestGuestVote(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	// guests are rejected unless the poll opts in
+	rr := httptest.NewRecorder()
+	app.GuestVote(rr, newGuestVoteRequest("1", nil))
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, rr.Code)
+	}
+
+	// the first vote issues a signed guest cookie
+	rr = httptest.NewRecorder()
+	app.GuestVote(rr, newGuestVoteRequest("4", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	cookie := guestCookie(rr)
+	if cookie == nil {
+		t.Fatal("Expected guest cookie to be set")
+	}
+
+	// a valid cookie is reused
+	rr = httptest.NewRecorder()
+	app.GuestVote(rr, newGuestVoteRequest("4", cookie))
+
+	if guestCookie(rr) != nil {
+		t.Error("Expected the existing guest cookie to be reused")
+	}
+
+	// a tampered cookie is replaced
+	rr = httptest.NewRecorder()
+	app.GuestVote(rr, newGuestVoteRequest("4", &http.Cookie{Name: guestCookieName, Value: "forged.signature"}))
+
+	if replaced := guestCookie(rr); replaced == nil || replaced.Value == cookie.Value {
+		t.Error("Expected a tampered guest cookie to be replaced")
+	}
+}
+
+func TestGuestVoteRateLimit(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+	app.guestTokenLimiter = newRateLimiter(1, time.Minute)
+
+	rr := httptest.NewRecorder()
+	app.GuestVote(rr, newGuestVoteRequest("4", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	// the same guest token is over its limit
+	cookie := guestCookie(rr)
+
+	rr = httptest.NewRecorder()
+	app.GuestVote(rr, newGuestVoteRequest("4", cookie))
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+
+	// a fresh token from the same IP is limited by the IP limiter
+	app.guestTokenLimiter = nil
+	app.guestIPLimiter = newRateLimiter(1, time.Minute)
+	handler := app.guestRateLimit(http.HandlerFunc(app.GuestVote))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newGuestVoteRequest("4", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newGuestVoteRequest("4", nil))
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/storage"
+	"polling/internal/thumbnail"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	maxImageSize   = 10 << 20 // 10MB
+	maxImagePixels = 40_000_000
+	thumbnailSize  = 320
+)
+
+// imageExtensions maps the image types accepted for upload to the extension
+// their blobs are stored with.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+var (
+	errImageTooLarge    = repository.Validation("image_too_large", "images must be at most 10MB")
+	errUnsupportedImage = repository.Validation("unsupported_image", "images must be PNG, JPEG or GIF files")
+	errMissingImage     = repository.Validation("missing_image", "the request must carry an image in the 'image' form field")
+	errMediaNotFound    = repository.NotFound("media_not_found", "media not found")
+)
+
+// UploadOptionImage attaches the image sent as the "image" field of a
+// multipart form to an option, replacing any earlier image. A thumbnail is
+// generated and stored next to it. Like other option edits it needs the
+// version of the option in If-Match, and images of published polls are
+// frozen.
+func (app *application) UploadOptionImage(w http.ResponseWriter, r *http.Request) {
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	option := poll.OptionByID(optionID)
+
+	if option == nil {
+		app.writeError(w, errOptionNotFound)
+		return
+	}
+
+	// the image is what voters of a design poll vote on
+	if poll.IsPublished() {
+		app.writeError(w, errPollFrozen)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, option.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	data, err := app.readImage(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	ext, thumb, err := makeThumbnail(data)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	name, err := randomName()
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	after := *option
+	after.Version = version + 1
+	after.ImageKey = fmt.Sprintf("options/%d/%s%s", optionID, name, ext)
+	after.ThumbnailKey = fmt.Sprintf("options/%d/%s_thumb.jpg", optionID, name)
+
+	err = app.Media.Put(r.Context(), after.ImageKey, bytes.NewReader(data))
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.Media.Put(r.Context(), after.ThumbnailKey, bytes.NewReader(thumb))
+
+	if err != nil {
+		app.deleteMedia(r, after.ImageKey)
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.DB.SetOptionImage(optionID, version, after.ImageKey, after.ThumbnailKey)
+
+	if err != nil {
+		app.deleteMedia(r, after.ImageKey, after.ThumbnailKey)
+		app.writeError(w, err)
+		return
+	}
+
+	app.deleteMedia(r, option.ImageKey, option.ThumbnailKey)
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionUpdate,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &poll.ID,
+		Before:     auditValue(map[string]string{"image_key": option.ImageKey}),
+		After:      auditValue(map[string]string{"image_key": after.ImageKey}),
+	})
+
+	app.writeJSON(w, http.StatusOK, after)
+}
+
+// RemoveOptionImage detaches the image from an option of an unpublished poll
+// and deletes it.
+func (app *application) RemoveOptionImage(w http.ResponseWriter, r *http.Request) {
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	option := poll.OptionByID(optionID)
+
+	if option == nil {
+		app.writeError(w, errOptionNotFound)
+		return
+	}
+
+	// the image is what voters of a design poll vote on
+	if poll.IsPublished() {
+		app.writeError(w, errPollFrozen)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, option.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.DB.SetOptionImage(optionID, version, "", "")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.deleteMedia(r, option.ImageKey, option.ThumbnailKey)
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionUpdate,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &poll.ID,
+		Before:     auditValue(map[string]string{"image_key": option.ImageKey}),
+		After:      auditValue(map[string]string{"image_key": ""}),
+	})
+
+	app.writeMessage(w, "Image removed")
+}
+
+// GetMedia serves a stored blob. Keys carry a random name and are never
+// reused, so responses can be cached for good.
+func (app *application) GetMedia(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+
+	blob, err := app.Media.Get(r.Context(), key)
+
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		app.writeError(w, errMediaNotFound)
+		return
+	}
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	defer blob.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	_, err = io.Copy(w, blob)
+
+	if err != nil {
+		log.Println("media:", err)
+	}
+}
+
+// readImage returns the content of the "image" field of a multipart request,
+// refusing anything larger than maxImageSize.
+func (app *application) readImage(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	// leave some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageSize+1<<20)
+
+	err := r.ParseMultipartForm(maxImageSize)
+
+	var tooLarge *http.MaxBytesError
+
+	if errors.As(err, &tooLarge) {
+		return nil, errImageTooLarge
+	}
+
+	if err != nil {
+		return nil, repository.Validation("invalid_multipart", "body must be a multipart form").Wrap(err)
+	}
+
+	file, _, err := r.FormFile("image")
+
+	if err != nil {
+		return nil, errMissingImage
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImageSize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxImageSize {
+		return nil, errImageTooLarge
+	}
+
+	return data, nil
+}
+
+// makeThumbnail checks that data holds a supported image and returns the
+// extension to store it with, together with a JPEG encoded thumbnail.
+func makeThumbnail(data []byte) (string, []byte, error) {
+	ext, ok := imageExtensions[http.DetectContentType(data)]
+
+	if !ok {
+		return "", nil, errUnsupportedImage
+	}
+
+	// refuse huge dimensions before decoding allocates memory for them
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+
+	if err != nil || config.Width*config.Height > maxImagePixels {
+		return "", nil, errUnsupportedImage
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+
+	if err != nil {
+		return "", nil, errUnsupportedImage
+	}
+
+	var thumb bytes.Buffer
+
+	err = jpeg.Encode(&thumb, thumbnail.Make(img, thumbnailSize), &jpeg.Options{Quality: 85})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return ext, thumb.Bytes(), nil
+}
+
+// deleteMedia removes blobs that are no longer referenced. Failures only leave
+// an orphaned file behind, so they are logged rather than reported.
+func (app *application) deleteMedia(r *http.Request, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+
+		err := app.Media.Delete(r.Context(), key)
+
+		if err != nil {
+			log.Println("media:", err)
+		}
+	}
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/storage"
+	"testing"
+)
+
+func testPNG(t *testing.T, w int, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func multipartImage(t *testing.T, field string, data []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	part, err := mw.CreateFormFile(field, "mockup.png")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	return &body, mw.FormDataContentType()
+}
+
+func TestUploadOptionImage(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		field          string
+		data           []byte
+		userID         int
+		ifMatch        string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "png", pollID: "1", field: "image", data: testPNG(t, 800, 400), userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusOK},
+		{name: "editor", pollID: "1", field: "image", data: testPNG(t, 10, 10), userID: 5, ifMatch: `"1"`, expectedStatus: http.StatusOK},
+		{name: "not an image", pollID: "1", field: "image", data: []byte("hello"), userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusBadRequest, expectedCode: "unsupported_image"},
+		{name: "wrong field", pollID: "1", field: "file", data: testPNG(t, 10, 10), userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusBadRequest, expectedCode: "missing_image"},
+		{name: "too large", pollID: "1", field: "image", data: make([]byte, maxImageSize+1), userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusBadRequest, expectedCode: "image_too_large"},
+		{name: "viewer", pollID: "1", field: "image", data: testPNG(t, 10, 10), userID: 6, ifMatch: `"1"`, expectedStatus: http.StatusForbidden},
+		{name: "missing If-Match", pollID: "1", field: "image", data: testPNG(t, 10, 10), userID: 1, expectedStatus: http.StatusPreconditionRequired},
+		{name: "stale If-Match", pollID: "1", field: "image", data: testPNG(t, 10, 10), userID: 1, ifMatch: `"2"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "published poll", pollID: "4", field: "image", data: testPNG(t, 10, 10), userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusConflict, expectedCode: "poll_frozen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			body, contentType := multipartImage(t, tt.field, tt.data)
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/options/1/image", body)
+			req.Header.Set("Content-Type", contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+			req = addURLParamToRequest(req, "optionID", "1")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.UploadOptionImage))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				json.Unmarshal(rr.Body.Bytes(), &response)
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if rr.Code != http.StatusOK {
+				if keys := app.Media.(*storage.MemoryStore).Keys(); len(keys) != 0 {
+					t.Errorf("expected nothing to be stored, got %v", keys)
+				}
+				return
+			}
+
+			var option models.PollOption
+			err = json.Unmarshal(rr.Body.Bytes(), &option)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if option.ImageKey == "" || option.ThumbnailKey == "" {
+				t.Fatalf("expected image and thumbnail keys, got %+v", option)
+			}
+
+			// the thumbnail is served through the media route
+			rr = httptest.NewRecorder()
+			app.routes().ServeHTTP(rr, httptest.NewRequest("GET", "/media/"+option.ThumbnailKey, nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d for the thumbnail, got %d", http.StatusOK, rr.Code)
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "image/jpeg" {
+				t.Errorf("expected image/jpeg, got %s", ct)
+			}
+
+			thumb, err := jpeg.Decode(rr.Body)
+			if err != nil {
+				t.Fatalf("Failed to decode thumbnail: %v", err)
+			}
+
+			if b := thumb.Bounds(); b.Dx() > thumbnailSize || b.Dy() > thumbnailSize {
+				t.Errorf("expected the thumbnail to fit into %dpx, got %v", thumbnailSize, b)
+			}
+		})
+	}
+}
+
+func TestGetMediaNotFound(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	for _, path := range []string{"/media/options/1/missing.png", "/media/../secret"} {
+		rr := httptest.NewRecorder()
+		app.routes().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, rr.Code)
+		}
+	}
+}
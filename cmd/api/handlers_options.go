@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/validator"
+	"time"
+)
+
+// ReorderPollOptions puts the options of a poll into the order given by their
+// IDs. The order has to list every option of the poll exactly once.
+func (app *application) ReorderPollOptions(w http.ResponseWriter, r *http.Request) {
+	err := app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, poll.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		OptionIDs []int `json:"option_ids"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(isPermutation(payload.OptionIDs, poll.OptionIDs()), "option_ids", validator.CodeInvalid,
+		"option_ids must list every option of the poll exactly once")
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	err = app.DB.ReorderOptions(poll.ID, payload.OptionIDs, version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionReorder,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		Before:     auditValue(poll.OptionIDs()),
+		After:      auditValue(payload.OptionIDs),
+	})
+
+	app.writeMessage(w, "Options reordered")
+}
+
+// ReplacePollOptions replaces the whole option set of a poll in one go.
+// Options with an ID are updated, options without one are added, and options
+// left out are moved to the trash. The options end up in the order given.
+// Published polls only accept new texts for their existing options.
+func (app *application) ReplacePollOptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, poll.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Options []struct {
+			ID          int        `json:"id"`
+			Text        string     `json:"text"`
+			Description string     `json:"description"`
+			LinkURL     string     `json:"link_url"`
+			IsCorrect   bool       `json:"is_correct"`
+			StartsAt    *time.Time `json:"starts_at"`
+			EndsAt      *time.Time `json:"ends_at"`
+			TimeZone    string     `json:"time_zone"`
+		} `json:"options"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	options := make([]models.PollOption, len(payload.Options))
+	texts := make([]string, len(payload.Options))
+	seen := map[int]bool{}
+
+	v := validator.New()
+	v.Check(!poll.IsText(), "options", validator.CodeInvalid, "text polls have no options")
+
+	for i, option := range payload.Options {
+		options[i] = models.PollOption{
+			ID:          option.ID,
+			Text:        option.Text,
+			Description: option.Description,
+			LinkURL:     option.LinkURL,
+			IsCorrect:   option.IsCorrect,
+			StartsAt:    option.StartsAt,
+			EndsAt:      option.EndsAt,
+			TimeZone:    option.TimeZone,
+		}
+		texts[i] = option.Text
+
+		models.ValidateOption(v, validator.Index("options", i, ""), &options[i])
+		poll.CheckCorrect(v, validator.Index("options", i, "is_correct"), &options[i])
+		poll.CheckSlot(v, validator.Index("options", i, ""), &options[i])
+
+		if option.ID != 0 {
+			v.Check(poll.OptionByID(option.ID) != nil, validator.Index("options", i, "id"), validator.CodeInvalid,
+				"option does not belong to this poll")
+			v.Check(!seen[option.ID], validator.Index("options", i, "id"), validator.CodeDuplicate,
+				"option is listed more than once")
+			seen[option.ID] = true
+		}
+	}
+
+	v.NoDuplicates(texts, nil, func(i int) string {
+		return validator.Index("options", i, "text")
+	})
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	if poll.IsPublished() && (len(seen) != len(poll.Options) || len(options) != len(poll.Options)) {
+		app.writeError(w, errPollFrozen)
+		return
+	}
+
+	if poll.IsPublished() {
+		for i := range options {
+			if poll.OptionByID(options[i].ID).ChangesFrozenFields(&options[i]) {
+				app.writeError(w, errPollFrozen)
+				return
+			}
+		}
+	}
+
+	err = app.DB.ReplacePollOptions(poll.ID, options, version, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionReplace,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		Before:     auditValue(poll.Options),
+		After:      auditValue(options),
+	})
+
+	app.writeMessage(w, "Options replaced")
+}
+
+// isPermutation reports whether ids holds exactly the elements of of, in any
+// order.
+func isPermutation(ids []int, of []int) bool {
+	if len(ids) != len(of) {
+		return false
+	}
+
+	counts := map[int]int{}
+
+	for _, id := range of {
+		counts[id]++
+	}
+
+	for _, id := range ids {
+		if counts[id] == 0 {
+			return false
+		}
+
+		counts[id]--
+	}
+
+	return true
+}
+
+// viewerSeed derives the seed for the option order a viewer of a randomized
+// poll gets. Signed in users are told apart by their ID, guests by their
+// cookie and everybody else by their IP address, so reloading the poll keeps
+// the order stable.
+func (app *application) viewerSeed(r *http.Request, pollID int, userID int) uint64 {
+	viewer := "ip:" + clientIP(r)
+
+	if userID != 0 {
+		viewer = fmt.Sprintf("user:%d", userID)
+	} else if cookie, err := r.Cookie(guestCookieName); err == nil {
+		if guestID, ok := app.auth.VerifyGuestToken(cookie.Value); ok {
+			viewer = "guest:" + guestID
+		}
+	}
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d|%s", pollID, viewer)
+
+	return h.Sum64()
+}
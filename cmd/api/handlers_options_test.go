@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestReorderPollOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		optionIDs      []int
+		userID         int
+		ifMatch        string
+		expectedStatus int
+	}{
+		{name: "new order", optionIDs: []int{2, 1}, userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusOK},
+		{name: "editor", optionIDs: []int{2, 1}, userID: 5, ifMatch: `"1"`, expectedStatus: http.StatusOK},
+		{name: "missing option", optionIDs: []int{1}, userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusBadRequest},
+		{name: "repeated option", optionIDs: []int{1, 1}, userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusBadRequest},
+		{name: "foreign option", optionIDs: []int{3, 1}, userID: 1, ifMatch: `"1"`, expectedStatus: http.StatusBadRequest},
+		{name: "stale version", optionIDs: []int{2, 1}, userID: 1, ifMatch: `"0"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "viewer", optionIDs: []int{2, 1}, userID: 6, ifMatch: `"1"`, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]any{"option_ids": tt.optionIDs})
+
+			req := httptest.NewRequest("PUT", "/polls/1/options/order", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			req = addURLParamToRequest(req, "pollID", "1")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.ReorderPollOptions))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestReplacePollOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		payload        string
+		expectedStatus int
+	}{
+		{
+			name:           "update, add and drop",
+			pollID:         "1",
+			payload:        `{"options": [{"id": 2, "text": "No"}, {"text": "Maybe"}]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "duplicate texts",
+			pollID:         "1",
+			payload:        `{"options": [{"id": 1, "text": "Same"}, {"text": "Same"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "foreign option",
+			pollID:         "1",
+			payload:        `{"options": [{"id": 9, "text": "Other"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "option listed twice",
+			pollID:         "1",
+			payload:        `{"options": [{"id": 1, "text": "Yes"}, {"id": 1, "text": "Again"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "published poll keeps its options",
+			pollID:         "4",
+			payload:        `{"options": [{"id": 1, "text": "Yes"}]}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "published poll cannot grow",
+			pollID:         "4",
+			payload:        `{"options": [{"id": 1, "text": "Yes"}, {"id": 2, "text": "No"}, {"text": "Maybe"}]}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "published poll can be reworded",
+			pollID:         "4",
+			payload:        `{"options": [{"id": 2, "text": "Nope"}, {"id": 1, "text": "Yep"}]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "published quiz keeps its answer key",
+			pollID:         "10",
+			payload:        `{"options": [{"id": 1, "text": "Paris"}, {"id": 2, "text": "London", "is_correct": true}]}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "published scheduling poll keeps its slots",
+			pollID: "16",
+			payload: `{"options": [
+				{"id": 1, "text": "Monday morning", "starts_at": "2026-11-02T09:00:00Z", "ends_at": "2026-11-02T11:00:00Z", "time_zone": "Europe/Berlin"},
+				{"id": 2, "text": "Tuesday morning", "starts_at": "2026-11-03T10:00:00Z", "ends_at": "2026-11-03T12:00:00Z", "time_zone": "Europe/Berlin"},
+				{"id": 3, "text": "Wednesday, New York office", "starts_at": "2026-11-04T09:00:00Z", "ends_at": "2026-11-04T11:00:00Z", "time_zone": "America/New_York"}
+			]}`,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("PUT", "/polls/"+tt.pollID+"/options", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", `"1"`)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.ReplacePollOptions))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRandomizedOptionOrder(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	optionOrder := func(userID int) []int {
+		req := httptest.NewRequest("GET", "/polls/7", nil)
+		req = addURLParamToRequest(req, "pollID", "7")
+
+		token, err := generateTestJWT(app.auth, userID)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rr := httptest.NewRecorder()
+
+		handler := app.authOptional(http.HandlerFunc(app.GetPoll))
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var poll models.Poll
+		err = json.Unmarshal(rr.Body.Bytes(), &poll)
+		if err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		return poll.OptionIDs()
+	}
+
+	natural := []int{1, 2, 3, 4, 5, 6}
+
+	if order := optionOrder(1); !reflect.DeepEqual(order, natural) {
+		t.Errorf("expected the owner to see the natural order, got %v", order)
+	}
+
+	first := optionOrder(4)
+
+	if !reflect.DeepEqual(first, optionOrder(4)) {
+		t.Error("expected the same viewer to get the same order twice")
+	}
+
+	shuffled := !reflect.DeepEqual(first, natural)
+
+	for userID := 10; userID < 20 && !shuffled; userID++ {
+		shuffled = !reflect.DeepEqual(optionOrder(userID), natural)
+	}
+
+	if !shuffled {
+		t.Error("expected viewers to get shuffled orders")
+	}
+}
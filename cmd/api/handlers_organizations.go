@@ -0,0 +1,409 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+)
+
+// organization routes handlers
+
+var orgRoles = []string{models.OrgRoleOwner, models.OrgRoleAdmin, models.OrgRoleMember}
+
+var errMemberNotFound = repository.NotFound("member_not_found", "user is not a member of this organization")
+
+func (app *application) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Name string `json:"name"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	org := models.Organization{Name: payload.Name}
+
+	v := validator.New()
+	org.Validate(v)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	created, err := app.DB.CreateOrganization(org, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOrganizationCreate,
+		TargetType: models.AuditTargetOrganization,
+		TargetID:   &created.ID,
+		After:      auditValue(created),
+	})
+
+	app.writeJSON(w, http.StatusOK, created)
+}
+
+func (app *application) GetMyOrganizations(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	orgs, err := app.DB.GetUserOrganizations(userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, orgs)
+}
+
+func (app *application) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID, role, err := app.organizationRole(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if role == "" {
+		app.writeError(w, errNotOrgMember)
+		return
+	}
+
+	org, err := app.DB.GetOrganizationByID(orgID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, org)
+}
+
+func (app *application) GetOrganizationPolls(w http.ResponseWriter, r *http.Request) {
+	orgID, role, err := app.organizationRole(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if role == "" {
+		app.writeError(w, errNotOrgMember)
+		return
+	}
+
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	polls, err := app.DB.GetOrganizationPolls(orgID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	// drafts are only listed for their authors and the organization managers
+	visible := []*models.Poll{}
+
+	for _, poll := range polls {
+		if !poll.IsPublished() && poll.UserID != userID && !models.CanManagePolls(role) {
+			continue
+		}
+
+		if !app.canViewResults(poll, userID) {
+			poll.HideVotes()
+		}
+
+		app.hideAnswerKey(poll, userID)
+
+		visible = append(visible, poll)
+	}
+
+	app.writeJSON(w, http.StatusOK, visible)
+}
+
+func (app *application) AddOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	orgID, role, err := app.organizationRole(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if payload.Role == "" {
+		payload.Role = models.OrgRoleMember
+	}
+
+	v := validator.New()
+	v.Required("username", payload.Username)
+	v.In("role", payload.Role, orgRoles...)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	err = checkRoleAssignment(role, "", payload.Role)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	user, err := app.DB.GetUserByUsername(payload.Username)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.DB.AddOrganizationMember(orgID, user.ID, payload.Role)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditMemberAdd,
+		TargetType: models.AuditTargetOrganization,
+		TargetID:   &orgID,
+		After:      auditValue(memberRole(user.ID, payload.Role)),
+	})
+
+	app.writeMessage(w, "Member added")
+}
+
+func (app *application) UpdateOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	orgID, role, err := app.organizationRole(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	memberID, err := app.readIDParam(r, "userID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Role string `json:"role"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.In("role", payload.Role, orgRoles...)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	currentRole := app.DB.GetOrganizationRole(orgID, memberID)
+
+	if currentRole == "" {
+		app.writeError(w, errMemberNotFound)
+		return
+	}
+
+	err = checkRoleAssignment(role, currentRole, payload.Role)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if currentRole == models.OrgRoleOwner && payload.Role != models.OrgRoleOwner {
+		err = app.checkRemainingOwner(orgID, memberID)
+
+		if err != nil {
+			app.writeError(w, err)
+			return
+		}
+	}
+
+	err = app.DB.UpdateOrganizationMemberRole(orgID, memberID, payload.Role)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditMemberUpdate,
+		TargetType: models.AuditTargetOrganization,
+		TargetID:   &orgID,
+		Before:     auditValue(memberRole(memberID, currentRole)),
+		After:      auditValue(memberRole(memberID, payload.Role)),
+	})
+
+	app.writeMessage(w, "Member updated")
+}
+
+func (app *application) RemoveOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	orgID, role, err := app.organizationRole(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	memberID, err := app.readIDParam(r, "userID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	currentRole := app.DB.GetOrganizationRole(orgID, memberID)
+
+	if currentRole == "" {
+		app.writeError(w, errMemberNotFound)
+		return
+	}
+
+	// members can always leave, removing someone else needs a managing role
+	if memberID != userID {
+		err = checkRoleAssignment(role, currentRole, models.OrgRoleMember)
+
+		if err != nil {
+			app.writeError(w, err)
+			return
+		}
+	}
+
+	if currentRole == models.OrgRoleOwner {
+		err = app.checkRemainingOwner(orgID, memberID)
+
+		if err != nil {
+			app.writeError(w, err)
+			return
+		}
+	}
+
+	err = app.DB.RemoveOrganizationMember(orgID, memberID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditMemberRemove,
+		TargetType: models.AuditTargetOrganization,
+		TargetID:   &orgID,
+		Before:     auditValue(memberRole(memberID, currentRole)),
+	})
+
+	app.writeMessage(w, "Member removed")
+}
+
+// organizationRole reads the orgID URL parameter and returns it together with
+// the role of the authenticated user in that organization.
+func (app *application) organizationRole(r *http.Request) (int, string, error) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	orgID, err := app.readIDParam(r, "orgID")
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	return orgID, app.DB.GetOrganizationRole(orgID, userID), nil
+}
+
+// checkRemainingOwner makes sure an organization keeps at least one owner
+// when memberID stops being one.
+func (app *application) checkRemainingOwner(orgID int, memberID int) error {
+	members, err := app.DB.GetOrganizationMembers(orgID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if member.UserID != memberID && member.Role == models.OrgRoleOwner {
+			return nil
+		}
+	}
+
+	return repository.Conflict("last_owner", "an organization must keep at least one owner")
+}
+
+// checkRoleAssignment reports whether a member with actorRole may change a
+// member from currentRole (empty for new members) to newRole. Owners may do
+// anything, admins may only manage plain members.
+func checkRoleAssignment(actorRole string, currentRole string, newRole string) error {
+	switch actorRole {
+	case models.OrgRoleOwner:
+		return nil
+	case models.OrgRoleAdmin:
+		if newRole == models.OrgRoleMember && (currentRole == "" || currentRole == models.OrgRoleMember) {
+			return nil
+		}
+	}
+
+	return repository.Forbidden("cannot_manage_member", "you are not allowed to manage this member")
+}
+
+// memberRole is the audit value of a membership.
+func memberRole(userID int, role string) map[string]any {
+	return map[string]any{"user_id": userID, "role": role}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"testing"
+)
+
+func TestAddOrganizationMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		payload        map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "owner adds admin",
+			userID:         1,
+			payload:        map[string]string{"username": "newuser", "role": "admin"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin adds member",
+			userID:         2,
+			payload:        map[string]string{"username": "newuser"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin cannot add admin",
+			userID:         2,
+			payload:        map[string]string{"username": "newuser", "role": "admin"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "member cannot add members",
+			userID:         3,
+			payload:        map[string]string{"username": "newuser"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid role",
+			userID:         1,
+			payload:        map[string]string{"username": "newuser", "role": "superuser"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{MockUser: &models.User{ID: 10, Username: "newuser"}})
+
+			jsonPayload, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+
+			req := httptest.NewRequest("POST", "/organizations/1/members", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "orgID", "1")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.AddOrganizationMember))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestOrganizationPollManagement(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "poll creator", userID: 3, expectedStatus: http.StatusOK},
+		{name: "organization owner", userID: 1, expectedStatus: http.StatusOK},
+		{name: "organization admin", userID: 2, expectedStatus: http.StatusOK},
+		{name: "outsider", userID: 4, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]string{"title": "Updated", "description": "Updated"})
+
+			req := httptest.NewRequest("PUT", "/polls/3", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("If-Match", `"1"`)
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", "3")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.UpdatePoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestMembersOnlyVote(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "organization member", userID: 3, expectedStatus: http.StatusOK},
+		{name: "outsider", userID: 4, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("PUT", "/polls/3/options/1/votes", nil)
+			req = addURLParamToRequest(req, "pollID", "3")
+			req = addURLParamToRequest(req, "optionID", "1")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.Vote))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
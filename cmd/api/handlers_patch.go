@@ -0,0 +1,196 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// pollPatch holds the fields of a poll a merge patch may change.
+type pollPatch struct {
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	OpensAt          *time.Time `json:"opens_at"`
+	ClosesAt         *time.Time `json:"closes_at"`
+	MembersOnly      bool       `json:"members_only"`
+	PrivateResults   bool       `json:"private_results"`
+	AllowGuests      bool       `json:"allow_guests"`
+	RandomizeOptions bool       `json:"randomize_options"`
+	AllowSuggestions bool       `json:"allow_suggestions"`
+	SuggestionLimit  int        `json:"suggestion_limit"`
+	MaxAnswerLength  int        `json:"max_answer_length"`
+	TimeBonus        int        `json:"time_bonus"`
+	CreditBudget     int        `json:"credit_budget"`
+	Weighted         bool       `json:"weighted"`
+	NoneOfTheAbove   bool       `json:"none_of_the_above"`
+	Quorum           int        `json:"quorum"`
+	QuorumPercent    int        `json:"quorum_percent"`
+	Threshold        string     `json:"threshold"`
+}
+
+// optionPatch holds the fields of an option a merge patch may change.
+type optionPatch struct {
+	Text        string     `json:"text"`
+	Description string     `json:"description"`
+	LinkURL     string     `json:"link_url"`
+	IsCorrect   bool       `json:"is_correct"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	TimeZone    string     `json:"time_zone"`
+}
+
+// PatchPoll applies an RFC 7396 merge patch to the poll, so only the fields
+// present in the body change.
+func (app *application) PatchPoll(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	before, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, before.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	patch := pollPatch{
+		Title:            before.Title,
+		Description:      before.Description,
+		OpensAt:          before.OpensAt,
+		ClosesAt:         before.ClosesAt,
+		MembersOnly:      before.MembersOnly,
+		PrivateResults:   before.PrivateResults,
+		AllowGuests:      before.AllowGuests,
+		RandomizeOptions: before.RandomizeOptions,
+		AllowSuggestions: before.AllowSuggestions,
+		SuggestionLimit:  before.SuggestionLimit,
+		MaxAnswerLength:  before.MaxAnswerLength,
+		TimeBonus:        before.TimeBonus,
+		CreditBudget:     before.CreditBudget,
+		Weighted:         before.Weighted,
+		NoneOfTheAbove:   before.NoneOfTheAbove,
+		Quorum:           before.Quorum,
+		QuorumPercent:    before.QuorumPercent,
+		Threshold:        before.Threshold,
+	}
+
+	err = app.readMergePatch(w, r, &patch)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	after := *before
+	after.Title = patch.Title
+	after.Description = patch.Description
+	after.OpensAt = patch.OpensAt
+	after.ClosesAt = patch.ClosesAt
+	after.MembersOnly = patch.MembersOnly
+	after.PrivateResults = patch.PrivateResults
+	after.AllowGuests = patch.AllowGuests
+	after.RandomizeOptions = patch.RandomizeOptions
+	after.AllowSuggestions = patch.AllowSuggestions
+	after.SuggestionLimit = patch.SuggestionLimit
+	after.MaxAnswerLength = patch.MaxAnswerLength
+	after.TimeBonus = patch.TimeBonus
+	after.CreditBudget = patch.CreditBudget
+	after.Weighted = patch.Weighted
+	after.NoneOfTheAbove = patch.NoneOfTheAbove
+	after.Quorum = patch.Quorum
+	after.QuorumPercent = patch.QuorumPercent
+	after.Threshold = patch.Threshold
+	after.Version = version
+
+	app.savePoll(w, r, userID, before, &after)
+}
+
+// PatchPollOption applies an RFC 7396 merge patch to an option.
+func (app *application) PatchPollOption(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	option := poll.OptionByID(optionID)
+
+	if option == nil {
+		app.writeError(w, errOptionNotFound)
+		return
+	}
+
+	version, err := app.ifMatchVersion(r, option.Version)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	patch := optionPatch{
+		Text:        option.Text,
+		Description: option.Description,
+		LinkURL:     option.LinkURL,
+		IsCorrect:   option.IsCorrect,
+		StartsAt:    option.StartsAt,
+		EndsAt:      option.EndsAt,
+		TimeZone:    option.TimeZone,
+	}
+
+	err = app.readMergePatch(w, r, &patch)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	after := *option
+	after.Text = patch.Text
+	after.Description = patch.Description
+	after.LinkURL = patch.LinkURL
+	after.IsCorrect = patch.IsCorrect
+	after.StartsAt = patch.StartsAt
+	after.EndsAt = patch.EndsAt
+	after.TimeZone = patch.TimeZone
+	after.Version = version
+
+	app.saveOption(w, r, userID, poll, option, after, false)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"testing"
+)
+
+func TestPatchPoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		patch          string
+		expectedStatus int
+		expected       func(poll models.Poll) bool
+	}{
+		{
+			name:           "only the given field changes",
+			patch:          `{"randomize_options": true}`,
+			expectedStatus: http.StatusOK,
+			expected: func(poll models.Poll) bool {
+				return poll.Title == "Private Poll" && poll.PrivateResults && poll.RandomizeOptions
+			},
+		},
+		{
+			name:           "null resets a field",
+			patch:          `{"title": "Renamed", "private_results": null}`,
+			expectedStatus: http.StatusOK,
+			expected: func(poll models.Poll) bool {
+				return poll.Title == "Renamed" && !poll.PrivateResults
+			},
+		},
+		{name: "title cannot be removed", patch: `{"title": null}`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown field", patch: `{"status": "draft"}`, expectedStatus: http.StatusBadRequest},
+		{name: "wrong type", patch: `{"allow_guests": "yes"}`, expectedStatus: http.StatusBadRequest},
+		{name: "who can vote is frozen once published", patch: `{"allow_guests": true}`, expectedStatus: http.StatusConflict},
+		{name: "members only is frozen once published", patch: `{"members_only": true}`, expectedStatus: http.StatusConflict},
+		{name: "schedule is frozen once published", patch: `{"closes_at": "2030-01-01T00:00:00Z"}`, expectedStatus: http.StatusConflict},
+		{name: "credit budget is frozen once published", patch: `{"credit_budget": 50}`, expectedStatus: http.StatusConflict},
+		{name: "weighting is frozen once published", patch: `{"weighted": true}`, expectedStatus: http.StatusConflict},
+		{name: "none of the above is frozen once published", patch: `{"none_of_the_above": true}`, expectedStatus: http.StatusConflict},
+		{name: "decision rules are frozen once published", patch: `{"threshold": "majority"}`, expectedStatus: http.StatusConflict},
+		{name: "malformed", patch: `{"title":`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("PATCH", "/polls/5", bytes.NewBufferString(tt.patch))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.Header.Set("If-Match", `"1"`)
+			req = addURLParamToRequest(req, "pollID", "5")
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.PatchPoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expected == nil {
+				return
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+
+			if len(events) != 1 {
+				t.Fatalf("expected 1 audit event, got %d", len(events))
+			}
+
+			var after models.Poll
+			err = json.Unmarshal(events[0].After, &after)
+			if err != nil {
+				t.Fatalf("Failed to parse audit event: %v", err)
+			}
+
+			if !tt.expected(after) {
+				t.Errorf("unexpected poll after patch: %+v", after)
+			}
+		})
+	}
+}
+
+func TestPatchPollOption(t *testing.T) {
+	tests := []struct {
+		name           string
+		patch          string
+		expectedStatus int
+	}{
+		{name: "new text", patch: `{"text": "Maybe"}`, expectedStatus: http.StatusOK},
+		{name: "empty patch keeps the text", patch: `{}`, expectedStatus: http.StatusOK},
+		{name: "duplicate text", patch: `{"text": "No"}`, expectedStatus: http.StatusBadRequest},
+		{name: "votes cannot be reset", patch: `{"reset_votes": true}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("PATCH", "/polls/1/options/1", bytes.NewBufferString(tt.patch))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.Header.Set("If-Match", `"1"`)
+			req = addURLParamToRequest(req, "pollID", "1")
+			req = addURLParamToRequest(req, "optionID", "1")
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.PatchPollOption))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"time"
+)
+
+var (
+	errAnswerLocked = repository.Conflict("answer_locked", "answers to a quiz cannot be changed")
+	errNotQuiz      = repository.Conflict("not_quiz", "this survey is not a quiz")
+)
+
+// quiz routes handlers
+
+// GetQuizLeaderboard ranks the users who took a quiz by their score across
+// its questions. Until every question has closed, only the owner of the quiz
+// sees the leaderboard, since the scores give the answer key away.
+func (app *application) GetQuizLeaderboard(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	survey, err := app.surveyFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !survey.IsPublished() && survey.UserID != userID {
+		app.writeError(w, errSurveyNotFound)
+		return
+	}
+
+	if !survey.Quiz {
+		app.writeError(w, errNotQuiz)
+		return
+	}
+
+	if survey.UserID != userID && !survey.IsClosed(time.Now()) {
+		app.writeError(w, repository.Forbidden("quiz_not_closed", "the leaderboard is shown once the quiz closes"))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, survey.Leaderboard())
+}
+
+// hideAnswerKey hides the correct answers of an open quiz poll from everyone
+// but its editors.
+func (app *application) hideAnswerKey(poll *models.Poll, userID int) {
+	if !poll.Quiz || poll.IsClosed(time.Now()) {
+		return
+	}
+
+	if userID != 0 && app.canEditPoll(poll.ID, userID) {
+		return
+	}
+
+	poll.HideAnswerKey()
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"testing"
+)
+
+func TestQuizAnswerKey(t *testing.T) {
+	tests := []struct {
+		name            string
+		pollID          string
+		userID          int
+		expectedCorrect bool
+	}{
+		{name: "open quiz for a player", pollID: "10", userID: 2, expectedCorrect: false},
+		{name: "open quiz for an anonymous visitor", pollID: "10", userID: 0, expectedCorrect: false},
+		{name: "open quiz for the owner", pollID: "10", userID: 1, expectedCorrect: true},
+		{name: "closed quiz for a player", pollID: "11", userID: 2, expectedCorrect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/"+tt.pollID, nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			if tt.userID != 0 {
+				token, err := generateTestJWT(app.auth, tt.userID)
+				if err != nil {
+					t.Fatalf("Failed to generate token: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetPoll))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var poll models.Poll
+			err := json.Unmarshal(rr.Body.Bytes(), &poll)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if correct := len(poll.CorrectOptions()) > 0; correct != tt.expectedCorrect {
+				t.Errorf("expected answer key shown %v, got %v", tt.expectedCorrect, correct)
+			}
+		})
+	}
+}
+
+func TestQuizAnswersLocked(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		userID         int
+		handler        func(app *application) http.HandlerFunc
+		expectedStatus int
+	}{
+		{name: "first answer", method: "PUT", userID: 5, handler: func(app *application) http.HandlerFunc { return app.Vote }, expectedStatus: http.StatusOK},
+		{name: "changed answer", method: "PUT", userID: 2, handler: func(app *application) http.HandlerFunc { return app.Vote }, expectedStatus: http.StatusConflict},
+		{name: "withdrawn answer", method: "DELETE", userID: 2, handler: func(app *application) http.HandlerFunc { return app.Unvote }, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest(tt.method, "/polls/10/options/2/votes", nil)
+			req = addURLParamToRequest(req, "pollID", "10")
+			req = addURLParamToRequest(req, "optionID", "2")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(tt.handler(app))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus == http.StatusConflict {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != "answer_locked" {
+					t.Errorf("expected code answer_locked, got %s", response.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitQuizSurveyLocked(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	jsonPayload, _ := json.Marshal(map[string]any{"answers": []models.SurveyAnswer{{PollID: 10, OptionID: 2}}})
+
+	req := httptest.NewRequest("POST", "/surveys/5/responses", bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	req = addURLParamToRequest(req, "surveyID", "5")
+
+	token, err := generateTestJWT(app.auth, 2)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	authHandler := app.authRequired(http.HandlerFunc(app.SubmitSurvey))
+	authHandler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response problem
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if len(response.Errors) != 1 || response.Errors[0].Field != "answers[0].poll_id" {
+		t.Errorf("expected an error for answers[0].poll_id, got %v", response.Errors)
+	}
+}
+
+func TestGetQuizLeaderboard(t *testing.T) {
+	tests := []struct {
+		name           string
+		surveyID       string
+		userID         int
+		expectedStatus int
+		expected       []models.LeaderboardEntry
+	}{
+		{
+			// user 2 answered the first question after 15 of the 60 bonus
+			// seconds, user 4 after 45
+			name:           "open quiz for the owner",
+			surveyID:       "5",
+			userID:         1,
+			expectedStatus: http.StatusOK,
+			expected: []models.LeaderboardEntry{
+				{Rank: 1, UserID: 4, Score: 225, Correct: 2, Answered: 2},
+				{Rank: 2, UserID: 2, Score: 175, Correct: 1, Answered: 2},
+				{Rank: 3, UserID: 3, Score: 100, Correct: 1, Answered: 2},
+			},
+		},
+		{name: "open quiz for a player", surveyID: "5", userID: 2, expectedStatus: http.StatusForbidden},
+		{
+			name:           "closed quiz for a player",
+			surveyID:       "6",
+			userID:         2,
+			expectedStatus: http.StatusOK,
+			expected: []models.LeaderboardEntry{
+				{Rank: 1, UserID: 3, Score: 100, Correct: 1, Answered: 1},
+				{Rank: 1, UserID: 4, Score: 100, Correct: 1, Answered: 1},
+				{Rank: 3, UserID: 2, Score: 0, Correct: 0, Answered: 1},
+			},
+		},
+		{name: "not a quiz", surveyID: "1", userID: 1, expectedStatus: http.StatusConflict},
+		{name: "unknown survey", surveyID: "99", userID: 1, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/surveys/"+tt.surveyID+"/leaderboard", nil)
+			req = addURLParamToRequest(req, "surveyID", tt.surveyID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.GetQuizLeaderboard))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var leaderboard models.Leaderboard
+			err = json.Unmarshal(rr.Body.Bytes(), &leaderboard)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if len(leaderboard.Entries) != len(tt.expected) {
+				t.Fatalf("expected %d entries, got %d", len(tt.expected), len(leaderboard.Entries))
+			}
+
+			for i, entry := range leaderboard.Entries {
+				if *entry != tt.expected[i] {
+					t.Errorf("entry %d: expected %+v, got %+v", i, tt.expected[i], *entry)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"polling/internal/ical"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+	"strings"
+	"time"
+)
+
+var (
+	errNotSchedulePoll      = repository.Conflict("not_schedule_poll", "this poll is not a scheduling poll")
+	errSchedulePoll         = repository.Conflict("schedule_poll", "this poll is answered per time slot")
+	errPollFinalized        = repository.Conflict("poll_finalized", "this poll has already been finalized on a slot")
+	errPollNotFinalized     = repository.Conflict("poll_not_finalized", "this poll has not been finalized on a slot yet")
+	errNoBestSlot           = repository.Conflict("no_best_slot", "no voter can make any slot yet, pick one with option_id")
+	errInvalidCalendarToken = repository.Forbidden("invalid_calendar_token", "the calendar token is not valid")
+)
+
+// calendarProductID names this service in the calendars it exports.
+const calendarProductID = "-//polling//meetings//EN"
+
+// scheduling routes handlers
+
+// SubmitAvailability stores the answers of the user to the slots of an open
+// scheduling poll. Submitting again replaces the earlier answers.
+func (app *application) SubmitAvailability(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canViewPoll(poll, userID) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	if !poll.IsSchedule() {
+		app.writeError(w, errNotSchedulePoll)
+		return
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	if poll.FinalOption() != nil {
+		app.writeError(w, errPollFinalized)
+		return
+	}
+
+	if poll.MembersOnly && poll.OrganizationID != nil {
+		if app.DB.GetOrganizationRole(*poll.OrganizationID, userID) == "" {
+			app.writeError(w, repository.Forbidden("members_only", "only organization members can vote on this poll"))
+			return
+		}
+	}
+
+	var payload struct {
+		Answers []models.SlotAnswer `json:"answers"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	models.ValidateAvailability(v, "answers", poll, payload.Answers)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	availability, err := app.DB.SaveAvailability(poll.ID, userID, payload.Answers)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditAvailabilitySave,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(availability),
+	})
+
+	app.writeJSON(w, http.StatusOK, availability)
+}
+
+// FinalizePoll settles a published scheduling poll on the slot named by
+// option_id, or on the best slot when the body leaves it out. Finalizing
+// again moves the meeting to another slot.
+func (app *application) FinalizePoll(w http.ResponseWriter, r *http.Request) {
+	err := app.checkPollOwnership(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !poll.IsSchedule() {
+		app.writeError(w, errNotSchedulePoll)
+		return
+	}
+
+	var payload struct {
+		OptionID *int `json:"option_id"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(poll.IsPublished(), "publish", validator.CodeInvalid, "only published polls can be finalized")
+
+	if payload.OptionID != nil {
+		v.Check(poll.OptionByID(*payload.OptionID) != nil, "option_id", validator.CodeInvalid,
+			"option is not part of this poll")
+	}
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	availabilities, err := app.DB.GetAvailabilities(poll.ID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	results := poll.TallySchedule(availabilities)
+	optionID := results.Best
+
+	if payload.OptionID != nil {
+		optionID = payload.OptionID
+	}
+
+	if optionID == nil {
+		app.writeError(w, errNoBestSlot)
+		return
+	}
+
+	err = app.DB.FinalizePoll(poll.ID, *optionID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	event := models.AuditEvent{
+		Action:     models.AuditPollFinalize,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(map[string]int{"final_option_id": *optionID}),
+	}
+
+	if poll.FinalOptionID != nil {
+		event.Before = auditValue(map[string]int{"final_option_id": *poll.FinalOptionID})
+	}
+
+	app.audit(r, event)
+
+	results.FinalOptionID = optionID
+
+	app.writeJSON(w, http.StatusOK, results)
+}
+
+// GetPollEvent exports the slot a scheduling poll was finalized on as an
+// iCalendar file.
+func (app *application) GetPollEvent(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canViewPoll(poll, app.optionalUserID(r)) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	if !poll.IsSchedule() {
+		app.writeError(w, errNotSchedulePoll)
+		return
+	}
+
+	option := poll.FinalOption()
+
+	if option == nil {
+		app.writeError(w, errPollNotFinalized)
+		return
+	}
+
+	meeting := &models.Meeting{
+		PollID:      poll.ID,
+		OptionID:    option.ID,
+		Title:       poll.Title,
+		Description: poll.Description,
+		Slot:        option.Text,
+		StartsAt:    *option.StartsAt,
+		EndsAt:      *option.EndsAt,
+		TimeZone:    option.TimeZone,
+		OrganizerID: poll.UserID,
+	}
+
+	calendar := ical.Calendar{
+		ProductID: calendarProductID,
+		Events:    []ical.Event{app.meetingEvent(meeting, time.Now())},
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="poll-%d.ics"`, poll.ID))
+
+	app.writeCalendar(w, &calendar)
+}
+
+// GetCalendarURL returns the address of the meeting feed of the user, which
+// calendar apps can subscribe to without logging in.
+func (app *application) GetCalendarURL(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	url := fmt.Sprintf("/calendar/%d.ics?token=%s", userID, app.auth.CalendarToken(userID))
+
+	app.writeJSON(w, http.StatusOK, map[string]string{"url": url})
+}
+
+// GetCalendarFeed lists the finalized meetings of a user as an iCalendar
+// feed. The token query parameter stands in for logging in, since calendar
+// apps cannot send one.
+func (app *application) GetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.readIDParam(r, "userID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.auth.VerifyCalendarToken(userID, r.URL.Query().Get("token")) {
+		app.writeError(w, errInvalidCalendarToken)
+		return
+	}
+
+	meetings, err := app.DB.GetFinalizedMeetings(userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	now := time.Now()
+	calendar := ical.Calendar{
+		ProductID: calendarProductID,
+		Name:      "Meetings",
+		Events:    make([]ical.Event, len(meetings)),
+	}
+
+	for i, meeting := range meetings {
+		calendar.Events[i] = app.meetingEvent(meeting, now)
+	}
+
+	app.writeCalendar(w, &calendar)
+}
+
+// meetingEvent turns a meeting into a calendar event. The UID only depends
+// on the poll, so moving the meeting to another slot updates the event.
+func (app *application) meetingEvent(meeting *models.Meeting, now time.Time) ical.Event {
+	description := meeting.Description
+
+	if meeting.Slot != "" {
+		description = strings.TrimSpace(meeting.Slot + "\n\n" + description)
+	}
+
+	return ical.Event{
+		UID:         fmt.Sprintf("poll-%d@%s", meeting.PollID, app.Domain),
+		Summary:     meeting.Title,
+		Description: description,
+		Start:       meeting.StartsAt,
+		End:         meeting.EndsAt,
+		Stamp:       now,
+	}
+}
+
+func (app *application) writeCalendar(w http.ResponseWriter, calendar *ical.Calendar) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+
+	err := calendar.Encode(w)
+
+	if err != nil {
+		log.Println("calendar:", err)
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"strings"
+	"testing"
+)
+
+func TestSubmitAvailability(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		answers        []map[string]any
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "open scheduling poll", pollID: "16", answers: []map[string]any{{"option_id": 1, "answer": "yes"}, {"option_id": 3, "answer": "if_need_be"}}, expectedStatus: http.StatusOK},
+		{name: "finalized scheduling poll", pollID: "17", answers: []map[string]any{{"option_id": 1, "answer": "yes"}}, expectedStatus: http.StatusConflict, expectedCode: "poll_finalized"},
+		{name: "choice poll", pollID: "4", answers: []map[string]any{{"option_id": 1, "answer": "yes"}}, expectedStatus: http.StatusConflict, expectedCode: "not_schedule_poll"},
+		{name: "slot of another poll", pollID: "16", answers: []map[string]any{{"option_id": 9, "answer": "yes"}}, expectedStatus: http.StatusBadRequest},
+		{name: "no answers", pollID: "16", answers: []map[string]any{}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]any{"answers": tt.answers})
+
+			req := httptest.NewRequest("PUT", "/polls/"+tt.pollID+"/availability", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, 2)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SubmitAvailability))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var availability models.Availability
+			err = json.Unmarshal(rr.Body.Bytes(), &availability)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if availability.UserID != 2 || len(availability.Answers) != len(tt.answers) {
+				t.Errorf("expected %d answers of user 2, got %s", len(tt.answers), rr.Body.String())
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditAvailabilitySave {
+				t.Errorf("expected a %s audit event, got %v", models.AuditAvailabilitySave, events)
+			}
+		})
+	}
+}
+
+func TestVoteOnSchedulePoll(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("PUT", "/polls/16/options/1/votes", nil)
+	req = addURLParamToRequest(req, "pollID", "16")
+	req = addURLParamToRequest(req, "optionID", "1")
+
+	token, err := generateTestJWT(app.auth, 2)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	authHandler := app.authRequired(http.HandlerFunc(app.Vote))
+	authHandler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "schedule_poll") {
+		t.Errorf("expected status %d with code schedule_poll, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+}
+
+func TestGetSchedulePollResults(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/16/results", nil)
+	req = addURLParamToRequest(req, "pollID", "16")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var results models.ScheduleResults
+	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if results.Respondents != 3 || len(results.Slots) != 3 {
+		t.Fatalf("expected 3 respondents and 3 slots, got %s", rr.Body.String())
+	}
+
+	// the second slot has the fewest yes answers, but everyone can make it
+	if results.Best == nil || *results.Best != 2 {
+		t.Errorf("expected slot 2 to be the best, got %s", rr.Body.String())
+	}
+
+	expected := []struct{ yes, ifNeedBe, no int }{{2, 0, 1}, {1, 2, 0}, {2, 0, 1}}
+
+	for i, slot := range results.Slots {
+		if slot.Yes != expected[i].yes || slot.IfNeedBe != expected[i].ifNeedBe || slot.No != expected[i].no {
+			t.Errorf("expected slot %d to have %d/%d/%d answers, got %d/%d/%d", slot.OptionID, expected[i].yes,
+				expected[i].ifNeedBe, expected[i].no, slot.Yes, slot.IfNeedBe, slot.No)
+		}
+	}
+
+	// slots are shown in their own time zone
+	if !strings.Contains(rr.Body.String(), `"starts_at":"2026-11-02T10:00:00+01:00"`) ||
+		!strings.Contains(rr.Body.String(), `"starts_at":"2026-11-04T04:00:00-05:00"`) {
+		t.Errorf("expected local slot times, got %s", rr.Body.String())
+	}
+}
+
+func TestFinalizePoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		userID         int
+		payload        map[string]any
+		expectedStatus int
+		expectedCode   string
+		expectedFinal  int
+		expectedBefore bool
+	}{
+		{name: "best slot", pollID: "16", userID: 1, payload: map[string]any{}, expectedStatus: http.StatusOK, expectedFinal: 2},
+		{name: "chosen slot", pollID: "16", userID: 1, payload: map[string]any{"option_id": 3}, expectedStatus: http.StatusOK, expectedFinal: 3},
+		{name: "moving a finalized meeting", pollID: "17", userID: 1, payload: map[string]any{"option_id": 1}, expectedStatus: http.StatusOK, expectedFinal: 1, expectedBefore: true},
+		{name: "slot of another poll", pollID: "16", userID: 1, payload: map[string]any{"option_id": 9}, expectedStatus: http.StatusBadRequest},
+		{name: "choice poll", pollID: "4", userID: 1, payload: map[string]any{}, expectedStatus: http.StatusConflict, expectedCode: "not_schedule_poll"},
+		{name: "not the owner", pollID: "16", userID: 2, payload: map[string]any{}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(tt.payload)
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/finalize", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.FinalizePoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var results models.ScheduleResults
+			err = json.Unmarshal(rr.Body.Bytes(), &results)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if results.FinalOptionID == nil || *results.FinalOptionID != tt.expectedFinal {
+				t.Errorf("expected the poll to be finalized on slot %d, got %s", tt.expectedFinal, rr.Body.String())
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditPollFinalize || (events[0].Before != nil) != tt.expectedBefore {
+				t.Errorf("expected a %s audit event, got %v", models.AuditPollFinalize, events)
+			}
+		})
+	}
+}
+
+func TestGetPollEvent(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		expectedStatus int
+	}{
+		{name: "finalized poll", pollID: "17", expectedStatus: http.StatusOK},
+		{name: "poll still being answered", pollID: "16", expectedStatus: http.StatusConflict},
+		{name: "choice poll", pollID: "4", expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/"+tt.pollID+"/event.ics", nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetPollEvent))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/calendar") {
+				t.Errorf("expected a calendar, got %s", contentType)
+			}
+
+			body := rr.Body.String()
+			for _, line := range []string{"BEGIN:VEVENT\r\n", "SUMMARY:Team Offsite\r\n", "DTSTART:20261103T090000Z\r\n", "DTEND:20261103T110000Z\r\n"} {
+				if !strings.Contains(body, line) {
+					t.Errorf("expected the event to contain %q, got %q", line, body)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCalendarFeed(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		token          func(app *application) string
+		expectedStatus int
+		expectedEvents int
+	}{
+		{name: "organizer", userID: "1", token: func(app *application) string { return app.auth.CalendarToken(1) }, expectedStatus: http.StatusOK, expectedEvents: 1},
+		{name: "voter who can make the final slot", userID: "3", token: func(app *application) string { return app.auth.CalendarToken(3) }, expectedStatus: http.StatusOK, expectedEvents: 1},
+		{name: "user without meetings", userID: "5", token: func(app *application) string { return app.auth.CalendarToken(5) }, expectedStatus: http.StatusOK, expectedEvents: 0},
+		{name: "token of another user", userID: "3", token: func(app *application) string { return app.auth.CalendarToken(1) }, expectedStatus: http.StatusForbidden},
+		{name: "missing token", userID: "3", token: func(app *application) string { return "" }, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/calendar/"+tt.userID+".ics?token="+tt.token(app), nil)
+			req = addURLParamToRequest(req, "userID", tt.userID)
+
+			rr := httptest.NewRecorder()
+
+			http.HandlerFunc(app.GetCalendarFeed).ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if events := strings.Count(rr.Body.String(), "BEGIN:VEVENT"); events != tt.expectedEvents {
+				t.Errorf("expected %d events, got %d: %q", tt.expectedEvents, events, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetCalendarURL(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/calendar", nil)
+
+	token, err := generateTestJWT(app.auth, 3)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	authHandler := app.authRequired(http.HandlerFunc(app.GetCalendarURL))
+	authHandler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var response map[string]string
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	expected := "/calendar/3.ics?token=" + app.auth.CalendarToken(3)
+	if response["url"] != expected {
+		t.Errorf("expected %s, got %s", expected, response["url"])
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/fuzzy"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+	"time"
+)
+
+var (
+	errSuggestionsDisabled = repository.Forbidden("suggestions_disabled", "this poll does not accept suggestions")
+	errDuplicateSuggestion = repository.Conflict("duplicate_suggestion", "a similar option has already been suggested")
+)
+
+// suggestion routes handlers
+
+// SuggestOption lets a voter propose a new option for an open poll. The
+// suggestion waits in the review queue of the poll until someone who manages
+// the poll approves it.
+func (app *application) SuggestOption(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !app.canViewPoll(poll, userID) {
+		app.writeError(w, errPollNotFound)
+		return
+	}
+
+	if !poll.AllowSuggestions {
+		app.writeError(w, errSuggestionsDisabled)
+		return
+	}
+
+	if !poll.IsOpen(time.Now()) {
+		app.writeError(w, errPollNotOpen)
+		return
+	}
+
+	if poll.MembersOnly && poll.OrganizationID != nil {
+		if app.DB.GetOrganizationRole(*poll.OrganizationID, userID) == "" {
+			app.writeError(w, repository.Forbidden("members_only", "only organization members can suggest options for this poll"))
+			return
+		}
+	}
+
+	var payload models.PollOption
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	// only the people managing a quiz mark its answers
+	payload.IsCorrect = false
+
+	v := validator.New()
+	models.ValidateOption(v, "", &payload)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	// rejected suggestions count too, so the same idea is not sent in again
+	suggestions, err := app.DB.GetOptionSuggestions(poll.ID, "")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	for _, existing := range append(poll.Options, suggestions...) {
+		if fuzzy.Similar(existing.Text, payload.Text) {
+			app.writeError(w, errDuplicateSuggestion)
+			return
+		}
+	}
+
+	suggestion, err := app.DB.SuggestOption(poll.ID, payload, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionSuggest,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &suggestion.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(suggestion),
+	})
+
+	app.writeJSON(w, http.StatusOK, suggestion)
+}
+
+// GetSuggestions lists the suggestions made for a poll, optionally filtered
+// by their status.
+func (app *application) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	err := app.checkPollOwnership(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+
+	if status != "" {
+		v := validator.New()
+		v.In("status", status, models.OptionStatusPending, models.OptionStatusApproved, models.OptionStatusRejected)
+
+		if !v.Valid() {
+			app.writeValidationErrors(w, v.Errors)
+			return
+		}
+	}
+
+	suggestions, err := app.DB.GetOptionSuggestions(pollID, status)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, suggestions)
+}
+
+// ReviewSuggestion approves or rejects a suggestion. Approved suggestions
+// become regular options of the poll.
+func (app *application) ReviewSuggestion(w http.ResponseWriter, r *http.Request) {
+	err := app.checkPollOwnership(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Status string `json:"status"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.In("status", payload.Status, models.OptionStatusApproved, models.OptionStatusRejected)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	err = app.DB.ReviewSuggestion(pollID, optionID, payload.Status)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionReview,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &pollID,
+		After:      auditValue(map[string]string{"status": payload.Status}),
+	})
+
+	app.writeMessage(w, "Suggestion "+payload.Status)
+}
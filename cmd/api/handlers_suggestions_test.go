@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"testing"
+)
+
+func TestSuggestOption(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		text           string
+		userID         int
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "new idea", pollID: "8", text: "Karaoke night", userID: 2, expectedStatus: http.StatusOK},
+		{name: "similar to pending suggestion", pollID: "8", text: "pizza-partys", userID: 2, expectedStatus: http.StatusConflict, expectedCode: "duplicate_suggestion"},
+		{name: "similar to rejected suggestion", pollID: "8", text: "Bowlng", userID: 2, expectedStatus: http.StatusConflict, expectedCode: "duplicate_suggestion"},
+		{name: "similar to option", pollID: "8", text: "YES!", userID: 2, expectedStatus: http.StatusConflict, expectedCode: "duplicate_suggestion"},
+		{name: "limit reached", pollID: "8", text: "Karaoke night", userID: 7, expectedStatus: http.StatusForbidden, expectedCode: "suggestion_limit_reached"},
+		{name: "empty text", pollID: "8", text: "", userID: 2, expectedStatus: http.StatusBadRequest},
+		{name: "suggestions disabled", pollID: "4", text: "Karaoke night", userID: 2, expectedStatus: http.StatusForbidden, expectedCode: "suggestions_disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]string{"text": tt.text})
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/suggestions", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SuggestOption))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var suggestion models.PollOption
+			err = json.Unmarshal(rr.Body.Bytes(), &suggestion)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if suggestion.Status != models.OptionStatusPending {
+				t.Errorf("expected status pending, got %s", suggestion.Status)
+			}
+
+			if suggestion.SuggestedBy == nil || *suggestion.SuggestedBy != tt.userID {
+				t.Errorf("expected suggested_by %d, got %v", tt.userID, suggestion.SuggestedBy)
+			}
+		})
+	}
+}
+
+func TestGetSuggestions(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		userID         int
+		expectedStatus int
+		expectedCount  int
+	}{
+		{name: "all suggestions", query: "", userID: 1, expectedStatus: http.StatusOK, expectedCount: 2},
+		{name: "pending only", query: "?status=pending", userID: 1, expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "unknown status", query: "?status=maybe", userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "not the owner", query: "", userID: 2, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/8/suggestions"+tt.query, nil)
+			req = addURLParamToRequest(req, "pollID", "8")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.GetSuggestions))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var suggestions []models.PollOption
+			err = json.Unmarshal(rr.Body.Bytes(), &suggestions)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if len(suggestions) != tt.expectedCount {
+				t.Errorf("expected %d suggestions, got %d", tt.expectedCount, len(suggestions))
+			}
+		})
+	}
+}
+
+func TestReviewSuggestion(t *testing.T) {
+	tests := []struct {
+		name           string
+		optionID       string
+		status         string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "approve", optionID: "10", status: "approved", userID: 1, expectedStatus: http.StatusOK},
+		{name: "reject", optionID: "10", status: "rejected", userID: 1, expectedStatus: http.StatusOK},
+		{name: "back to pending", optionID: "10", status: "pending", userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "not a suggestion", optionID: "1", status: "approved", userID: 1, expectedStatus: http.StatusNotFound},
+		{name: "not the owner", optionID: "10", status: "approved", userID: 4, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]string{"status": tt.status})
+
+			req := httptest.NewRequest("PUT", "/polls/8/suggestions/"+tt.optionID, bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", "8")
+			req = addURLParamToRequest(req, "optionID", tt.optionID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.ReviewSuggestion))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK && len(app.DB.(*mocks.MockDBRepo).AuditEvents) != 1 {
+				t.Errorf("expected the review to be audited")
+			}
+		})
+	}
+}
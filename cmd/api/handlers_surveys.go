@@ -0,0 +1,452 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/branching"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+	"strconv"
+	"time"
+)
+
+var (
+	errSurveyNotFound = repository.NotFound("survey_not_found", "survey not found")
+	errNotSurveyOwner = repository.Forbidden("not_survey_owner", "you are not the owner of this survey")
+	errSurveyNotOpen  = repository.Forbidden("survey_not_open", "this survey is not open for responses")
+)
+
+// survey routes handlers
+
+// CreateSurvey creates a survey and a poll for each of its questions.
+// Questions are required unless they say otherwise. Rating questions give the
+// size of their scale instead of options, text questions the longest answer
+// they take. The questions of a quiz share its time bonus.
+func (app *application) CreateSurvey(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	var payload struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Publish     bool   `json:"publish"`
+		Quiz        bool   `json:"quiz"`
+		TimeBonus   int    `json:"time_bonus"`
+		Questions   []struct {
+			Title            string                `json:"title"`
+			Description      string                `json:"description"`
+			Kind             string                `json:"kind"`
+			Scale            int                   `json:"scale"`
+			MaxLength        *int                  `json:"max_length"`
+			Required         *bool                 `json:"required"`
+			ShowIf           []branching.Condition `json:"show_if"`
+			Skip             []branching.SkipRule  `json:"skip"`
+			RandomizeOptions bool                  `json:"randomize_options"`
+			OpensAt          *time.Time            `json:"opens_at"`
+			ClosesAt         *time.Time            `json:"closes_at"`
+			Options          []struct {
+				Text        string `json:"text"`
+				Description string `json:"description"`
+				LinkURL     string `json:"link_url"`
+				IsCorrect   bool   `json:"is_correct"`
+			} `json:"options"`
+		} `json:"questions"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	survey := models.Survey{
+		Title:       payload.Title,
+		Description: payload.Description,
+		UserID:      userID,
+		Status:      models.SurveyStatusDraft,
+		Quiz:        payload.Quiz,
+	}
+
+	v := validator.New()
+
+	for i, q := range payload.Questions {
+		poll := &models.Poll{
+			Title:            q.Title,
+			Description:      q.Description,
+			UserID:           userID,
+			RandomizeOptions: q.RandomizeOptions,
+			Quiz:             payload.Quiz,
+			TimeBonus:        payload.TimeBonus,
+			Status:           models.PollStatusDraft,
+			OpensAt:          q.OpensAt,
+			ClosesAt:         q.ClosesAt,
+		}
+
+		for _, option := range q.Options {
+			poll.Options = append(poll.Options, &models.PollOption{
+				Text:        option.Text,
+				Description: option.Description,
+				LinkURL:     option.LinkURL,
+				IsCorrect:   option.IsCorrect,
+			})
+		}
+
+		if q.Kind == "" {
+			q.Kind = models.QuestionKindChoice
+		}
+
+		if q.Kind == models.QuestionKindRating {
+			v.Check(len(q.Options) == 0, validator.Index("questions", i, "options"), validator.CodeInvalid,
+				"rating questions take a scale instead of options")
+
+			// one point past the limit is enough for Validate to report the scale
+			for point := 1; point <= q.Scale && point <= models.MaxRatingScale+1; point++ {
+				poll.Options = append(poll.Options, &models.PollOption{Text: strconv.Itoa(point)})
+			}
+		}
+
+		if q.Kind == models.QuestionKindText {
+			poll.Kind = models.PollKindText
+			poll.MaxAnswerLength = models.DefaultTextAnswerLength
+
+			if q.MaxLength != nil {
+				poll.MaxAnswerLength = *q.MaxLength
+			}
+		}
+
+		survey.Questions = append(survey.Questions, &models.SurveyQuestion{
+			Position: i,
+			Kind:     q.Kind,
+			Required: q.Required == nil || *q.Required,
+			ShowIf:   q.ShowIf,
+			Skip:     q.Skip,
+			Poll:     poll,
+		})
+	}
+
+	survey.Validate(v)
+
+	if payload.Publish && v.Valid() {
+		err = survey.CheckPublishable(time.Now())
+
+		if err != nil {
+			v.AddError("publish", validator.CodeInvalid, err.Error())
+		}
+	}
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	if payload.Publish {
+		survey.Status = models.SurveyStatusPublished
+	}
+
+	created, err := app.DB.CreateSurvey(survey)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditSurveyCreate,
+		TargetType: models.AuditTargetSurvey,
+		TargetID:   &created.ID,
+		After:      auditValue(created),
+	})
+
+	app.writeJSON(w, http.StatusOK, created)
+}
+
+// GetSurvey returns the survey with its questions. The answers of other
+// users are only shown in the results.
+func (app *application) GetSurvey(w http.ResponseWriter, r *http.Request) {
+	survey, err := app.surveyFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	userID := app.optionalUserID(r)
+
+	if !survey.IsPublished() && survey.UserID != userID {
+		app.writeError(w, errSurveyNotFound)
+		return
+	}
+
+	for _, question := range survey.Questions {
+		question.Poll.HideVotes()
+		app.hideAnswerKey(question.Poll, userID)
+
+		if question.Poll.RandomizeOptions && survey.UserID != userID {
+			question.Poll.ShuffleOptions(app.viewerSeed(r, question.Poll.ID, userID))
+		}
+	}
+
+	app.writeJSON(w, http.StatusOK, survey)
+}
+
+func (app *application) PublishSurvey(w http.ResponseWriter, r *http.Request) {
+	survey, err := app.ownSurveyFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = survey.CheckPublishable(time.Now())
+
+	if err != nil {
+		app.writeError(w, repository.Validation("not_publishable", err.Error()))
+		return
+	}
+
+	err = app.DB.PublishSurvey(survey.ID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditSurveyPublish,
+		TargetType: models.AuditTargetSurvey,
+		TargetID:   &survey.ID,
+		Before:     auditValue(map[string]string{"status": survey.Status}),
+		After:      auditValue(map[string]string{"status": models.SurveyStatusPublished}),
+	})
+
+	app.writeMessage(w, "Survey published")
+}
+
+// SubmitSurvey stores the answers of the user to any number of questions of
+// the survey. Either all answers are stored or none of them. Submitting again
+// replaces earlier answers, so a partial response can be completed later.
+// Answers to questions hidden by the branching rules are rejected.
+func (app *application) SubmitSurvey(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	survey, err := app.surveyFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !survey.IsPublished() {
+		app.writeError(w, errSurveyNotOpen)
+		return
+	}
+
+	var payload struct {
+		Answers []models.SurveyAnswer `json:"answers"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	survey.CheckAnswers(v, payload.Answers)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	previous, err := app.DB.GetSurveyAnswers(survey.ID, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	// the answers to a quiz are final
+	if survey.Quiz {
+		for i, answer := range payload.Answers {
+			for _, given := range previous {
+				if given.PollID == answer.PollID {
+					v.AddError(validator.Index("answers", i, "poll_id"), validator.CodeInvalid, "the question has already been answered")
+				}
+			}
+		}
+
+		if !v.Valid() {
+			app.writeValidationErrors(w, v.Errors)
+			return
+		}
+	}
+
+	progress := survey.Progress(mergeAnswers(previous, payload.Answers))
+
+	// answers the branching rules hide are rejected, earlier ones are dropped
+	hidden := map[int]bool{}
+
+	for _, pollID := range progress.Hidden {
+		hidden[pollID] = true
+	}
+
+	for i, answer := range payload.Answers {
+		if hidden[answer.PollID] {
+			v.AddError(validator.Index("answers", i, "poll_id"), validator.CodeInvalid, "the question is hidden by the answers to earlier questions")
+		}
+	}
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	now := time.Now()
+
+	for _, answer := range payload.Answers {
+		if !survey.Question(answer.PollID).Poll.IsOpen(now) {
+			app.writeError(w, errPollNotOpen)
+			return
+		}
+	}
+
+	response, err := app.DB.SubmitSurveyResponse(survey.ID, userID, payload.Answers, progress.Hidden, progress.Completed)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditSurveySubmit,
+		TargetType: models.AuditTargetSurvey,
+		TargetID:   &survey.ID,
+		After:      auditValue(payload.Answers),
+	})
+
+	app.writeJSON(w, http.StatusOK, response)
+}
+
+// GetNextSurveyQuestion returns the next question the user should answer,
+// following the branching rules of the survey. Next is null once the response
+// is complete and every question shown has been answered.
+func (app *application) GetNextSurveyQuestion(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	survey, err := app.surveyFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if !survey.IsPublished() {
+		app.writeError(w, errSurveyNotOpen)
+		return
+	}
+
+	answers, err := app.DB.GetSurveyAnswers(survey.ID, userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	progress := survey.Progress(answers)
+
+	if progress.Next != nil {
+		progress.Next.Poll.HideVotes()
+		app.hideAnswerKey(progress.Next.Poll, userID)
+	}
+
+	app.writeJSON(w, http.StatusOK, progress)
+}
+
+// mergeAnswers applies the answers of a submission on top of the answers
+// given before.
+func mergeAnswers(previous []models.SurveyAnswer, submitted []models.SurveyAnswer) []models.SurveyAnswer {
+	merged := []models.SurveyAnswer{}
+	replaced := map[int]bool{}
+
+	for _, answer := range submitted {
+		replaced[answer.PollID] = true
+	}
+
+	for _, answer := range previous {
+		if !replaced[answer.PollID] {
+			merged = append(merged, answer)
+		}
+	}
+
+	return append(merged, submitted...)
+}
+
+// GetSurveyResults returns the results of every question together with the
+// number of complete and partial responses.
+func (app *application) GetSurveyResults(w http.ResponseWriter, r *http.Request) {
+	survey, err := app.ownSurveyFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	stats, err := app.DB.GetSurveyResponseStats(survey.ID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, survey.Results(*stats))
+}
+
+// surveyFromRequest loads the survey named by the surveyID URL parameter.
+func (app *application) surveyFromRequest(r *http.Request) (*models.Survey, error) {
+	surveyID, err := app.readIDParam(r, "surveyID")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.DB.GetSurveyByID(surveyID)
+}
+
+// ownSurveyFromRequest is like surveyFromRequest, but only lets the owner of
+// the survey through.
+func (app *application) ownSurveyFromRequest(r *http.Request) (*models.Survey, error) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	survey, err := app.surveyFromRequest(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if survey.UserID != userID {
+		return nil, errNotSurveyOwner
+	}
+
+	return survey, nil
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"testing"
+)
+
+func TestCreateSurvey(t *testing.T) {
+	question := func(title string, options ...string) map[string]any {
+		list := []map[string]string{}
+		for _, text := range options {
+			list = append(list, map[string]string{"text": text})
+		}
+		return map[string]any{"title": title, "options": list}
+	}
+
+	tests := []struct {
+		name           string
+		payload        map[string]any
+		expectedStatus int
+		expectedField  string
+	}{
+		{
+			name:           "draft survey",
+			payload:        map[string]any{"title": "Team Survey", "questions": []any{question("Lunch?", "Yes", "No"), question("Remote?")}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "published survey",
+			payload:        map[string]any{"title": "Team Survey", "publish": true, "questions": []any{question("Lunch?", "Yes", "No")}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing title",
+			payload:        map[string]any{"questions": []any{question("Lunch?", "Yes", "No")}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "title",
+		},
+		{
+			name:           "no questions",
+			payload:        map[string]any{"title": "Team Survey", "questions": []any{}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "questions",
+		},
+		{
+			name:           "question without title",
+			payload:        map[string]any{"title": "Team Survey", "questions": []any{question("Lunch?", "Yes", "No"), question("", "Yes", "No")}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "questions[1].title",
+		},
+		{
+			name:           "duplicate option",
+			payload:        map[string]any{"title": "Team Survey", "questions": []any{question("Lunch?", "Yes", "yes")}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "questions[0].options[1].text",
+		},
+		{
+			name: "rating question with a skip rule",
+			payload: map[string]any{"title": "Team Survey", "questions": []any{
+				map[string]any{"title": "Rate lunch", "kind": "rating", "scale": 5,
+					"skip": []any{map[string]any{"when": map[string]any{"question": 0, "operator": "gt", "value": 3}, "to": 2}}},
+				question("Why?", "Food", "Price"),
+				question("Again?", "Yes", "No"),
+			}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "rating question with options",
+			payload: map[string]any{"title": "Team Survey", "questions": []any{
+				map[string]any{"title": "Rate lunch", "kind": "rating", "scale": 5, "options": []any{map[string]string{"text": "Good"}}},
+			}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "questions[0].options",
+		},
+		{
+			name: "rating scale too large",
+			payload: map[string]any{"title": "Team Survey", "questions": []any{
+				map[string]any{"title": "Rate lunch", "kind": "rating", "scale": 50},
+			}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "questions[0].scale",
+		},
+		{
+			name: "unknown kind",
+			payload: map[string]any{"title": "Team Survey", "questions": []any{
+				map[string]any{"title": "Lunch?", "kind": "essay", "options": []any{map[string]string{"text": "Yes"}, map[string]string{"text": "No"}}},
+			}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "questions[0].kind",
+		},
+		{
+			name: "threshold on a choice question",
+			payload: map[string]any{"title": "Team Survey", "questions": []any{
+				question("Lunch?", "Yes", "No"),
+				map[string]any{"title": "Where?", "options": []any{map[string]string{"text": "In"}, map[string]string{"text": "Out"}},
+					"show_if": []any{map[string]any{"question": 0, "operator": "gt", "value": 1}}},
+			}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "questions[1].show_if[0].operator",
+		},
+		{
+			name:           "publish a question with one option",
+			payload:        map[string]any{"title": "Team Survey", "publish": true, "questions": []any{question("Lunch?", "Yes")}},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "publish",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(tt.payload)
+
+			req := httptest.NewRequest("POST", "/surveys", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.CreateSurvey))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedField != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if len(response.Errors) == 0 || response.Errors[0].Field != tt.expectedField {
+					t.Errorf("expected an error at %s, got %v", tt.expectedField, response.Errors)
+				}
+				return
+			}
+
+			var survey models.Survey
+			err = json.Unmarshal(rr.Body.Bytes(), &survey)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			for _, question := range survey.Questions {
+				if question.Kind == models.QuestionKindRating && len(question.Poll.Options) != 5 {
+					t.Errorf("expected a rating question with 5 points, got %d", len(question.Poll.Options))
+				}
+				if !question.Required {
+					t.Errorf("expected questions to be required by default")
+				}
+				if question.Poll.Status != survey.Status {
+					t.Errorf("expected question status %s, got %s", survey.Status, question.Poll.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmitSurvey(t *testing.T) {
+	tests := []struct {
+		name              string
+		surveyID          string
+		answers           []models.SurveyAnswer
+		expectedStatus    int
+		expectedCompleted bool
+	}{
+		{
+			name:              "every question",
+			surveyID:          "1",
+			answers:           []models.SurveyAnswer{{PollID: 4, OptionID: 1}, {PollID: 5, OptionID: 2}},
+			expectedStatus:    http.StatusOK,
+			expectedCompleted: true,
+		},
+		{
+			name:              "required question only",
+			surveyID:          "1",
+			answers:           []models.SurveyAnswer{{PollID: 4, OptionID: 2}},
+			expectedStatus:    http.StatusOK,
+			expectedCompleted: true,
+		},
+		{
+			name:           "optional question only",
+			surveyID:       "1",
+			answers:        []models.SurveyAnswer{{PollID: 5, OptionID: 1}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no answers",
+			surveyID:       "1",
+			answers:        []models.SurveyAnswer{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "question of another survey",
+			surveyID:       "1",
+			answers:        []models.SurveyAnswer{{PollID: 4, OptionID: 1}, {PollID: 3, OptionID: 1}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown option",
+			surveyID:       "1",
+			answers:        []models.SurveyAnswer{{PollID: 4, OptionID: 9}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "question answered twice",
+			surveyID:       "1",
+			answers:        []models.SurveyAnswer{{PollID: 4, OptionID: 1}, {PollID: 4, OptionID: 2}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:              "text question",
+			surveyID:          "4",
+			answers:           []models.SurveyAnswer{{PollID: 4, OptionID: 1}, {PollID: 9, Text: "More coffee"}},
+			expectedStatus:    http.StatusOK,
+			expectedCompleted: true,
+		},
+		{
+			name:           "empty text answer",
+			surveyID:       "4",
+			answers:        []models.SurveyAnswer{{PollID: 9, OptionID: 1}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "text answer too long",
+			surveyID:       "4",
+			answers:        []models.SurveyAnswer{{PollID: 9, Text: "Coffee machine on every floor"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "draft survey",
+			surveyID:       "2",
+			answers:        []models.SurveyAnswer{{PollID: 1, OptionID: 1}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown survey",
+			surveyID:       "99",
+			answers:        []models.SurveyAnswer{{PollID: 1, OptionID: 1}},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]any{"answers": tt.answers})
+
+			req := httptest.NewRequest("POST", "/surveys/"+tt.surveyID+"/responses", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "surveyID", tt.surveyID)
+
+			token, err := generateTestJWT(app.auth, 2)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SubmitSurvey))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response models.SurveyResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if response.Completed != tt.expectedCompleted {
+				t.Errorf("expected completed %v, got %v", tt.expectedCompleted, response.Completed)
+			}
+		})
+	}
+}
+
+func TestGetSurveyResults(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "owner", userID: 1, expectedStatus: http.StatusOK},
+		{name: "respondent", userID: 2, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/surveys/1/results", nil)
+			req = addURLParamToRequest(req, "surveyID", "1")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.GetSurveyResults))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var results models.SurveyResults
+			err = json.Unmarshal(rr.Body.Bytes(), &results)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if results.CompletionRate != 0.75 || results.Partial != 1 {
+				t.Errorf("expected completion rate 0.75 with 1 partial response, got %v with %d", results.CompletionRate, results.Partial)
+			}
+
+			if len(results.Questions) != 2 || results.Questions[0].Answers != 1 {
+				t.Errorf("expected 2 questions with 1 answer to the first, got %+v", results.Questions)
+			}
+		})
+	}
+}
+
+func TestGetSurveyHidesAnswers(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/surveys/1", nil)
+	req = addURLParamToRequest(req, "surveyID", "1")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetSurvey))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var survey models.Survey
+	err := json.Unmarshal(rr.Body.Bytes(), &survey)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	for _, question := range survey.Questions {
+		for _, option := range question.Poll.Options {
+			if len(option.Votes) != 0 {
+				t.Errorf("expected the votes of option %d to be hidden", option.ID)
+			}
+		}
+	}
+}
+
+func TestSubmitBranchingSurvey(t *testing.T) {
+	tests := []struct {
+		name              string
+		userID            int
+		answers           []models.SurveyAnswer
+		expectedStatus    int
+		expectedCompleted bool
+	}{
+		{
+			name:           "answer to a skipped question",
+			userID:         2,
+			answers:        []models.SurveyAnswer{{PollID: 4, OptionID: 2}, {PollID: 5, OptionID: 1}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:              "skipped question is not required",
+			userID:            2,
+			answers:           []models.SurveyAnswer{{PollID: 4, OptionID: 2}, {PollID: 8, OptionID: 1}},
+			expectedStatus:    http.StatusOK,
+			expectedCompleted: true,
+		},
+		{
+			name:           "question shown when not skipped",
+			userID:         2,
+			answers:        []models.SurveyAnswer{{PollID: 4, OptionID: 1}, {PollID: 8, OptionID: 1}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:              "completes an earlier partial response",
+			userID:            3,
+			answers:           []models.SurveyAnswer{{PollID: 8, OptionID: 1}},
+			expectedStatus:    http.StatusOK,
+			expectedCompleted: true,
+		},
+		{
+			name:              "changed answer hides an earlier one",
+			userID:            3,
+			answers:           []models.SurveyAnswer{{PollID: 4, OptionID: 2}, {PollID: 8, OptionID: 2}},
+			expectedStatus:    http.StatusOK,
+			expectedCompleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, _ := json.Marshal(map[string]any{"answers": tt.answers})
+
+			req := httptest.NewRequest("POST", "/surveys/3/responses", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "surveyID", "3")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SubmitSurvey))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response models.SurveyResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if response.Completed != tt.expectedCompleted {
+				t.Errorf("expected completed %v, got %v", tt.expectedCompleted, response.Completed)
+			}
+		})
+	}
+}
+
+func TestGetNextSurveyQuestion(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       int
+		expectedPoll int
+	}{
+		{name: "new response", userID: 2, expectedPoll: 4},
+		{name: "partial response", userID: 3, expectedPoll: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/surveys/3/next", nil)
+			req = addURLParamToRequest(req, "surveyID", "3")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.GetNextSurveyQuestion))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+
+			var progress models.SurveyProgress
+			err = json.Unmarshal(rr.Body.Bytes(), &progress)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if progress.Completed || progress.Next == nil || progress.Next.Poll.ID != tt.expectedPoll {
+				t.Errorf("expected an incomplete response with poll %d next, got %+v", tt.expectedPoll, progress)
+			}
+		})
+	}
+}
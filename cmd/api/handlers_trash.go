@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"polling/internal/models"
+)
+
+// trash routes handlers
+
+func (app *application) GetTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	trash, err := app.DB.GetTrash(userID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, trash)
+}
+
+func (app *application) RestorePoll(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.DB.GetDeletedPollByID(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	// checkPollOwnership only sees live polls
+	if poll.UserID != userID && !app.isOrganizationManager(poll, userID) {
+		app.writeError(w, errNotPollEditor)
+		return
+	}
+
+	err = app.DB.RestorePoll(pollID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditPollRestore,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &pollID,
+		PollID:     &pollID,
+	})
+
+	app.writeMessage(w, "Poll restored")
+}
+
+func (app *application) RestorePollOption(w http.ResponseWriter, r *http.Request) {
+	optionID, err := app.readIDParam(r, "optionID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.checkPollEditor(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if poll.IsPublished() {
+		app.writeError(w, errPollFrozen)
+		return
+	}
+
+	err = app.DB.RestoreOption(poll.ID, optionID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditOptionRestore,
+		TargetType: models.AuditTargetOption,
+		TargetID:   &optionID,
+		PollID:     &poll.ID,
+	})
+
+	app.writeMessage(w, "Option restored")
+}
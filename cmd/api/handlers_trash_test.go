@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"testing"
+)
+
+func TestGetTrash(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/trash", nil)
+
+	token, err := generateTestJWT(app.auth, 1)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	authHandler := app.authRequired(http.HandlerFunc(app.GetTrash))
+	authHandler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var trash models.Trash
+	err = json.Unmarshal(rr.Body.Bytes(), &trash)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if len(trash.Polls) != 1 || trash.Polls[0].DeletedAt == nil {
+		t.Errorf("Expected one deleted poll, got %+v", trash.Polls)
+	}
+	if len(trash.Options) != 1 {
+		t.Errorf("Expected one deleted option, got %+v", trash.Options)
+	}
+}
+
+func TestGetTrashOfOrganization(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        int
+		expectedPolls int
+	}{
+		{name: "organization admin", userID: 2, expectedPolls: 1},
+		{name: "plain member", userID: 3, expectedPolls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/trash", nil)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.GetTrash))
+			authHandler.ServeHTTP(rr, req)
+
+			var trash models.Trash
+			err = json.Unmarshal(rr.Body.Bytes(), &trash)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if len(trash.Polls) != tt.expectedPolls {
+				t.Errorf("expected %d deleted polls, got %+v", tt.expectedPolls, trash.Polls)
+			}
+		})
+	}
+}
+
+func TestGetTrashRoute(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/trash", nil)
+
+	token, err := generateTestJWT(app.auth, 1)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+
+	app.routes().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var trash models.Trash
+	err = json.Unmarshal(rr.Body.Bytes(), &trash)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if len(trash.Polls) != 1 {
+		t.Errorf("Expected one deleted poll, got %+v", trash.Polls)
+	}
+}
+
+func TestRestorePoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "owner restores", pollID: "6", userID: 1, expectedStatus: http.StatusOK},
+		{name: "someone else", pollID: "6", userID: 4, expectedStatus: http.StatusForbidden},
+		{name: "poll not in trash", pollID: "1", userID: 1, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/restore", nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.RestorePoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRestorePollOption(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		optionID       string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "editor restores", pollID: "1", optionID: "3", userID: 5, expectedStatus: http.StatusOK},
+		{name: "option not in trash", pollID: "1", optionID: "1", userID: 1, expectedStatus: http.StatusNotFound},
+		{name: "published poll", pollID: "4", optionID: "3", userID: 1, expectedStatus: http.StatusConflict},
+		{name: "outsider", pollID: "1", optionID: "3", userID: 4, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/options/"+tt.optionID+"/restore", nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+			req = addURLParamToRequest(req, "optionID", tt.optionID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.RestorePollOption))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
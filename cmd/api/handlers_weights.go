@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"polling/internal/validator"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errNotWeighted    = repository.Conflict("not_weighted", "this poll is not weighted")
+	errWeightsFrozen  = repository.Conflict("weights_frozen", "voter weights cannot change once the poll has opened")
+	errNotOnAllowlist = repository.Forbidden("not_on_allowlist", "only voters on the allowlist can vote on this poll")
+	errInvalidCSV     = repository.Validation("invalid_csv", "body must be CSV with a username and a weight on every line")
+)
+
+// voter weight routes handlers
+
+// GetVoterWeights lists the allowlist of a weighted poll.
+func (app *application) GetVoterWeights(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.weightedPollFromRequest(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	weights, err := app.DB.GetVoterWeights(poll.ID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, weights)
+}
+
+// SetVoterWeight adds a user to the allowlist of a weighted poll, or changes
+// their weight, until the poll opens.
+func (app *application) SetVoterWeight(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.weightedPollFromRequest(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if poll.WeightsFrozen(time.Now()) {
+		app.writeError(w, errWeightsFrozen)
+		return
+	}
+
+	var payload struct {
+		Username string `json:"username"`
+		Weight   int    `json:"weight"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Required("username", payload.Username)
+	models.ValidateVoterWeight(v, "weight", payload.Weight)
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	user, err := app.DB.GetUserByUsername(payload.Username)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	previous, err := app.DB.GetVoterWeight(poll.ID, user.ID)
+
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.DB.SetVoterWeight(poll.ID, user.ID, payload.Weight)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	event := models.AuditEvent{
+		Action:     models.AuditWeightSet,
+		TargetType: models.AuditTargetUser,
+		TargetID:   &user.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(map[string]int{"weight": payload.Weight}),
+	}
+
+	if previous != 0 {
+		event.Before = auditValue(map[string]int{"weight": previous})
+	}
+
+	app.audit(r, event)
+
+	app.writeMessage(w, "Voter weight set")
+}
+
+// RemoveVoterWeight takes a user off the allowlist of a weighted poll until
+// the poll opens.
+func (app *application) RemoveVoterWeight(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.weightedPollFromRequest(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if poll.WeightsFrozen(time.Now()) {
+		app.writeError(w, errWeightsFrozen)
+		return
+	}
+
+	voterID, err := app.readIDParam(r, "userID")
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	previous, err := app.DB.GetVoterWeight(poll.ID, voterID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	err = app.DB.RemoveVoterWeight(poll.ID, voterID)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditWeightRemove,
+		TargetType: models.AuditTargetUser,
+		TargetID:   &voterID,
+		PollID:     &poll.ID,
+		Before:     auditValue(map[string]int{"weight": previous}),
+	})
+
+	app.writeMessage(w, "Voter weight removed")
+}
+
+// ImportVoterWeights replaces the allowlist of a weighted poll with a CSV
+// body holding a username and a weight on every line, after an optional
+// username,weight header. Problems are reported at rows[i], counting from
+// the first line after the header.
+func (app *application) ImportVoterWeights(w http.ResponseWriter, r *http.Request) {
+	poll, err := app.weightedPollFromRequest(w, r)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	if poll.WeightsFrozen(time.Now()) {
+		app.writeError(w, errWeightsFrozen)
+		return
+	}
+
+	maxBytes := 1024 * 1024 // 1MB
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+
+	records, err := readWeightRecords(r.Body)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(records) > 0, "rows", validator.CodeRequired, "rows is required")
+	v.Check(len(records) <= models.MaxVoterWeights, "rows", validator.CodeInvalid, "the allowlist has too many rows")
+
+	usernames := make([]string, len(records))
+	weights := make([]models.VoterWeight, len(records))
+
+	for i, record := range records {
+		username := strings.TrimSpace(record[0])
+		v.Required(validator.Index("rows", i, "username"), username)
+		usernames[i] = username
+
+		weight, err := strconv.Atoi(strings.TrimSpace(record[1]))
+
+		if err != nil {
+			v.AddError(validator.Index("rows", i, "weight"), validator.CodeInvalidFormat, "weight must be a whole number")
+		} else {
+			models.ValidateVoterWeight(v, validator.Index("rows", i, "weight"), weight)
+		}
+
+		weights[i] = models.VoterWeight{Username: username, Weight: weight}
+	}
+
+	v.NoDuplicates(usernames, nil, func(i int) string {
+		return validator.Index("rows", i, "username")
+	})
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	ids, err := app.DB.GetUserIDsByUsernames(usernames)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	for i := range weights {
+		id, ok := ids[weights[i].Username]
+		v.Check(ok, validator.Index("rows", i, "username"), validator.CodeInvalid, "user not found")
+		weights[i].UserID = id
+	}
+
+	if !v.Valid() {
+		app.writeValidationErrors(w, v.Errors)
+		return
+	}
+
+	err = app.DB.ReplaceVoterWeights(poll.ID, weights)
+
+	if err != nil {
+		app.writeError(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditEvent{
+		Action:     models.AuditWeightImport,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(weights),
+	})
+
+	app.writeJSON(w, http.StatusOK, weights)
+}
+
+// readWeightRecords reads the username and weight pairs of an allowlist
+// import, skipping the header line if there is one.
+func readWeightRecords(body io.Reader) ([][]string, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+
+		if errors.As(err, &tooLarge) {
+			return nil, repository.Validation("body_too_large", "body must not be larger than 1MB")
+		}
+
+		return nil, errInvalidCSV.Wrap(err)
+	}
+
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "username") {
+		records = records[1:]
+	}
+
+	return records, nil
+}
+
+// weightedPollFromRequest loads the weighted poll named by the pollID URL
+// parameter for a user who manages it.
+func (app *application) weightedPollFromRequest(w http.ResponseWriter, r *http.Request) (*models.Poll, error) {
+	err := app.checkPollOwnership(w, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	poll, err := app.pollFromRequest(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !poll.Weighted {
+		return nil, errNotWeighted
+	}
+
+	return poll, nil
+}
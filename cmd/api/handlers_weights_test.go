@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"strings"
+	"testing"
+)
+
+func TestSetVoterWeight(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		userID         int
+		weight         int
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "before the poll opens", pollID: "15", userID: 1, weight: 25, expectedStatus: http.StatusOK},
+		{name: "once the poll is open", pollID: "14", userID: 1, weight: 25, expectedStatus: http.StatusConflict, expectedCode: "weights_frozen"},
+		{name: "poll without weights", pollID: "1", userID: 1, weight: 25, expectedStatus: http.StatusConflict, expectedCode: "not_weighted"},
+		{name: "zero weight", pollID: "15", userID: 1, weight: 0, expectedStatus: http.StatusBadRequest},
+		{name: "not the owner", pollID: "15", userID: 2, weight: 25, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{MockUser: &models.User{ID: 6, Username: "erin"}})
+
+			jsonPayload, _ := json.Marshal(map[string]any{"username": "erin", "weight": tt.weight})
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/weights", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.SetVoterWeight))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditWeightSet || events[0].Before != nil {
+				t.Errorf("expected a %s audit event for a new voter, got %v", models.AuditWeightSet, events)
+			}
+		})
+	}
+}
+
+func TestRemoveVoterWeight(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		voterID        string
+		expectedStatus int
+	}{
+		{name: "voter on the allowlist", pollID: "15", voterID: "3", expectedStatus: http.StatusOK},
+		{name: "voter not on the allowlist", pollID: "15", voterID: "6", expectedStatus: http.StatusNotFound},
+		{name: "once the poll is open", pollID: "14", voterID: "3", expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("DELETE", "/polls/"+tt.pollID+"/weights/"+tt.voterID, nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+			req = addURLParamToRequest(req, "userID", tt.voterID)
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.RemoveVoterWeight))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestImportVoterWeights(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedField  string
+		expectedRows   int
+	}{
+		{name: "with header", pollID: "15", body: "username,weight\nalice,100\nbob, 50\n", expectedStatus: http.StatusOK, expectedRows: 2},
+		{name: "without header", pollID: "15", body: "carol,300\n", expectedStatus: http.StatusOK, expectedRows: 1},
+		{name: "unknown user", pollID: "15", body: "alice,100\nmallory,5\n", expectedStatus: http.StatusBadRequest, expectedField: "rows[1].username"},
+		{name: "weight is not a number", pollID: "15", body: "alice,lots\n", expectedStatus: http.StatusBadRequest, expectedField: "rows[0].weight"},
+		{name: "negative weight", pollID: "15", body: "alice,-1\n", expectedStatus: http.StatusBadRequest, expectedField: "rows[0].weight"},
+		{name: "same user twice", pollID: "15", body: "alice,100\nAlice,20\n", expectedStatus: http.StatusBadRequest, expectedField: "rows[1].username"},
+		{name: "header only", pollID: "15", body: "username,weight\n", expectedStatus: http.StatusBadRequest, expectedField: "rows"},
+		{name: "extra column", pollID: "15", body: "alice,100,admin\n", expectedStatus: http.StatusBadRequest, expectedCode: "invalid_csv"},
+		{name: "once the poll is open", pollID: "14", body: "alice,100\n", expectedStatus: http.StatusConflict, expectedCode: "weights_frozen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("POST", "/polls/"+tt.pollID+"/weights/import", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/csv")
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.ImportVoterWeights))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusOK {
+				var response problem
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+
+				if tt.expectedCode != "" && response.Code != tt.expectedCode {
+					t.Errorf("expected code %s, got %s", tt.expectedCode, response.Code)
+				}
+
+				if tt.expectedField != "" && (len(response.Errors) == 0 || response.Errors[0].Field != tt.expectedField) {
+					t.Errorf("expected an error at %s, got %v", tt.expectedField, response.Errors)
+				}
+				return
+			}
+
+			var weights []models.VoterWeight
+			err = json.Unmarshal(rr.Body.Bytes(), &weights)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if len(weights) != tt.expectedRows {
+				t.Fatalf("expected %d voters, got %d", tt.expectedRows, len(weights))
+			}
+
+			if weights[0].UserID == 0 {
+				t.Errorf("expected usernames to be resolved, got %v", weights)
+			}
+
+			events := app.DB.(*mocks.MockDBRepo).AuditEvents
+			if len(events) != 1 || events[0].Action != models.AuditWeightImport {
+				t.Errorf("expected a %s audit event, got %v", models.AuditWeightImport, events)
+			}
+		})
+	}
+}
+
+func TestVoteOnWeightedPoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "voter on the allowlist", userID: 5, expectedStatus: http.StatusOK},
+		{name: "voter not on the allowlist", userID: 6, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("PUT", "/polls/14/options/1/votes", nil)
+			req = addURLParamToRequest(req, "pollID", "14")
+			req = addURLParamToRequest(req, "optionID", "1")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.Vote))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetWeightedPollResults(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/14/results", nil)
+	req = addURLParamToRequest(req, "pollID", "14")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var results models.PollResults
+	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if !results.Weighted || results.TotalVotes != 3 || results.WeightedTotal == nil || *results.WeightedTotal != 450 {
+		t.Fatalf("expected 3 votes weighing 450, got %s", rr.Body.String())
+	}
+
+	// alice and bob outvote carol, but carol holds more shares
+	expected := []struct{ votes, weighted int }{{2, 150}, {1, 300}}
+
+	for i, option := range results.Options {
+		if option.Votes != expected[i].votes || option.WeightedVotes == nil || *option.WeightedVotes != expected[i].weighted {
+			t.Errorf("expected option %d to have %d votes weighing %d, got %s", option.OptionID, expected[i].votes,
+				expected[i].weighted, rr.Body.String())
+		}
+	}
+}
+
+func TestUnweightedResultsHaveNoWeights(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/4/results", nil)
+	req = addURLParamToRequest(req, "pollID", "4")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetPollResults))
+	handler.ServeHTTP(rr, req)
+
+	if strings.Contains(rr.Body.String(), "weighted") {
+		t.Errorf("expected no weighted counts, got %s", rr.Body.String())
+	}
+}
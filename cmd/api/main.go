@@ -7,20 +7,28 @@ import (
 	"net/http"
 	"polling/internal/repository"
 	"polling/internal/repository/dbrepo"
+	"polling/internal/storage"
 	"time"
 )
 
 const port = 8080
 
 type application struct {
-	Domain       string
-	DB           repository.Repository
-	DSN          string
-	auth         Auth
-	JWTSecret    string
-	JWTIssuer    string
-	JWTAudience  string
-	CookieDomain string
+	Domain            string
+	DB                repository.Repository
+	DSN               string
+	auth              Auth
+	JWTSecret         string
+	JWTIssuer         string
+	JWTAudience       string
+	CookieDomain      string
+	GuestIPLimit      int
+	GuestTokenLimit   int
+	guestIPLimiter    *rateLimiter
+	guestTokenLimiter *rateLimiter
+	TrashRetention    time.Duration
+	MediaDir          string
+	Media             storage.BlobStore
 }
 
 func main() {
@@ -32,6 +40,10 @@ func main() {
 	flag.StringVar(&app.JWTAudience, "jwt-audience", "example.com", "signing audience")
 	flag.StringVar(&app.CookieDomain, "cookie-domain", "localhost", "cookie domain")
 	flag.StringVar(&app.Domain, "domain", "localhost", "domain")
+	flag.IntVar(&app.GuestIPLimit, "guest-ip-limit", 30, "guest requests allowed per IP per minute")
+	flag.IntVar(&app.GuestTokenLimit, "guest-token-limit", 10, "guest requests allowed per guest token per minute")
+	flag.StringVar(&app.MediaDir, "media-dir", "./media", "directory uploaded images are stored in")
+	flag.DurationVar(&app.TrashRetention, "trash-retention", 30*24*time.Hour, "how long deleted polls and options are kept before they are purged")
 
 	flag.Parse()
 
@@ -43,6 +55,11 @@ func main() {
 	app.DB = &dbrepo.DBRepo{DB: conn}
 	defer app.DB.Connection().Close()
 
+	app.Media, err = storage.NewLocalStore(app.MediaDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	app.auth = Auth{
 		Issuer:        app.JWTIssuer,
 		Audience:      app.JWTAudience,
@@ -54,6 +71,12 @@ func main() {
 		CookieDomain:  app.CookieDomain,
 	}
 
+	app.guestIPLimiter = newRateLimiter(app.GuestIPLimit, time.Minute)
+	app.guestTokenLimiter = newRateLimiter(app.GuestTokenLimit, time.Minute)
+
+	go app.purgeTrash(time.Hour)
+	go app.decideOutcomes(time.Minute)
+
 	log.Println("Server starting on port: ", port)
 	err = http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", port), app.routes())
 
@@ -3,16 +3,18 @@ package main
 import (
 	"context"
 	"net/http"
+	"polling/internal/repository"
 )
 
 func (app *application) enableCORS(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "http://*")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if r.Method == "OPTIONS" {
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT,PATCH, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, X-CSRF-Token, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, X-CSRF-Token, Authorization, If-Match, If-None-Match")
 			return
 		} else {
 			h.ServeHTTP(w, r)
@@ -25,7 +27,7 @@ func (app *application) authRequired(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, claims, err := app.auth.GetTokenFromHeaderAndVerify(w, r)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
+			app.writeError(w, repository.Unauthenticated("invalid_token", "missing or invalid token").Wrap(err))
 			return
 		}
 
@@ -36,3 +38,31 @@ func (app *application) authRequired(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// authOptional sets the user ID when the request carries a valid token, but
+// lets anonymous requests through as well.
+func (app *application) authOptional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, claims, err := app.auth.GetTokenFromHeaderAndVerify(w, r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "userID", claims.Subject)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// guestRateLimit limits unauthenticated requests per client IP.
+func (app *application) guestRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.guestIPLimiter.Allow(clientIP(r)) {
+			app.writeError(w, errTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
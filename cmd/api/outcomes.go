@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"time"
+)
+
+// decideOutcomes stores the outcome of the polls with decision rules once
+// they close. It checks once every interval and never returns, so run it in
+// its own goroutine.
+func (app *application) decideOutcomes(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		decided, err := app.decideClosedPolls(time.Now().UTC())
+
+		if err != nil {
+			log.Println("decide outcomes:", err)
+		} else if decided > 0 {
+			log.Printf("decide outcomes: decided %d polls", decided)
+		}
+
+		<-ticker.C
+	}
+}
+
+// decideClosedPolls decides the outcome of every poll awaiting one at now and
+// returns how many were decided. Polls that fail are logged and tried again on
+// the next run.
+func (app *application) decideClosedPolls(now time.Time) (int, error) {
+	polls, err := app.DB.GetUndecidedPolls(now)
+
+	if err != nil {
+		return 0, err
+	}
+
+	decided := 0
+
+	for _, poll := range polls {
+		err = app.decidePoll(poll)
+
+		if err != nil {
+			log.Println("decide outcomes: poll", poll.ID, err)
+			continue
+		}
+
+		decided++
+	}
+
+	return decided, nil
+}
+
+// settleOutcome decides the outcome of a poll read after it closed but before
+// decideOutcomes got to it, so a closed poll is never shown without one. When
+// the job wins the race the poll is read again.
+func (app *application) settleOutcome(poll *models.Poll) (*models.Poll, error) {
+	if !poll.AwaitsOutcome(time.Now().UTC()) {
+		return poll, nil
+	}
+
+	err := app.decidePoll(poll)
+
+	if errors.Is(err, repository.ErrConflict) {
+		return app.DB.GetPollByID(poll.ID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+// decidePoll works out and stores the outcome of a closed poll, and sets it
+// on poll. No request is behind it, so the audit event has no actor.
+func (app *application) decidePoll(poll *models.Poll) error {
+	results, err := app.choiceResults(poll)
+
+	if err != nil {
+		return err
+	}
+
+	eligible, err := app.eligibleVoters(poll)
+
+	if err != nil {
+		return err
+	}
+
+	outcome := poll.Decide(results, eligible)
+
+	err = app.DB.SetPollOutcome(poll.ID, *outcome)
+
+	if err != nil {
+		return err
+	}
+
+	decidedAt := time.Now().UTC()
+	poll.Outcome = outcome.Result
+	poll.OutcomeOptionID = outcome.OptionID
+	poll.DecidedAt = &decidedAt
+	poll.Version++
+
+	err = app.DB.RecordAuditEvent(models.AuditEvent{
+		Action:     models.AuditPollDecide,
+		TargetType: models.AuditTargetPoll,
+		TargetID:   &poll.ID,
+		PollID:     &poll.ID,
+		After:      auditValue(outcome),
+	})
+
+	if err != nil {
+		log.Println("audit:", models.AuditPollDecide, err)
+	}
+
+	return nil
+}
+
+// eligibleVoters counts the voters allowed to vote on the poll, the
+// allowlist of a weighted poll or the members of the organization of a
+// members_only poll. It is zero for polls open to everyone.
+func (app *application) eligibleVoters(poll *models.Poll) (int, error) {
+	if poll.Weighted {
+		weights, err := app.DB.GetVoterWeights(poll.ID)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return len(weights), nil
+	}
+
+	if poll.MembersOnly && poll.OrganizationID != nil {
+		members, err := app.DB.GetOrganizationMembers(*poll.OrganizationID)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return len(members), nil
+	}
+
+	return 0, nil
+}
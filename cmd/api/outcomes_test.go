@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"polling/internal/models"
+	"polling/internal/repository/mocks"
+	"testing"
+	"time"
+)
+
+func TestDecideClosedPolls(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+	db := app.DB.(*mocks.MockDBRepo)
+
+	decided, err := app.decideClosedPolls(time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if decided != 3 {
+		t.Fatalf("expected 3 polls decided, got %d", decided)
+	}
+
+	tests := []struct {
+		name             string
+		pollID           int
+		expectedResult   string
+		expectedOptionID int
+		expectedEligible int
+	}{
+		// two of three members voted yes, exactly two thirds
+		{name: "supermajority of members", pollID: 20, expectedResult: models.OutcomePassed, expectedOptionID: 1, expectedEligible: 3},
+		{name: "fewer voters than the quorum", pollID: 21, expectedResult: models.OutcomeNoQuorum},
+		// the weights carry no but not unanimously
+		{name: "weighted poll short of unanimity", pollID: 22, expectedResult: models.OutcomeFailed, expectedEligible: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, ok := db.Outcomes[tt.pollID]
+			if !ok {
+				t.Fatalf("expected poll %d to have an outcome", tt.pollID)
+			}
+
+			if outcome.Result != tt.expectedResult {
+				t.Errorf("expected result %s, got %s", tt.expectedResult, outcome.Result)
+			}
+
+			if tt.expectedOptionID == 0 && outcome.OptionID != nil {
+				t.Errorf("expected no option to pass, got %d", *outcome.OptionID)
+			}
+
+			if tt.expectedOptionID != 0 && (outcome.OptionID == nil || *outcome.OptionID != tt.expectedOptionID) {
+				t.Errorf("expected option %d to pass, got %v", tt.expectedOptionID, outcome.OptionID)
+			}
+
+			if outcome.Eligible != tt.expectedEligible {
+				t.Errorf("expected %d eligible voters, got %d", tt.expectedEligible, outcome.Eligible)
+			}
+		})
+	}
+
+	if len(db.AuditEvents) != 3 || db.AuditEvents[0].Action != models.AuditPollDecide || db.AuditEvents[0].ActorID != nil {
+		t.Errorf("expected a %s audit event without an actor per poll, got %v", models.AuditPollDecide, db.AuditEvents)
+	}
+
+	// the outcome of a poll is only decided once
+	decided, err = app.decideClosedPolls(time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if decided != 0 {
+		t.Errorf("expected no polls left to decide, got %d", decided)
+	}
+}
+
+func TestDecideClosedPollsBeforeClosing(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	decided, err := app.decideClosedPolls(time.Now().Add(-2 * time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if decided != 0 {
+		t.Errorf("expected open polls to be left undecided, got %d", decided)
+	}
+}
+
+func TestGetPollSettlesOutcome(t *testing.T) {
+	app := setuptestApp(TestAppConfig{})
+
+	req := httptest.NewRequest("GET", "/polls/20", nil)
+	req = addURLParamToRequest(req, "pollID", "20")
+
+	rr := httptest.NewRecorder()
+
+	handler := app.authOptional(http.HandlerFunc(app.GetPoll))
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var poll models.Poll
+	err := json.Unmarshal(rr.Body.Bytes(), &poll)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if poll.Outcome != models.OutcomePassed || poll.OutcomeOptionID == nil || *poll.OutcomeOptionID != 1 || poll.DecidedAt == nil {
+		t.Errorf("expected the closed poll to be read with its outcome, got %s", rr.Body.String())
+	}
+
+	if _, decided := app.DB.(*mocks.MockDBRepo).Outcomes[20]; !decided {
+		t.Errorf("expected the outcome to be stored")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// purgeTrash permanently removes the polls and options that have been in the
+// trash for longer than the retention period. It checks once every interval
+// and never returns, so run it in its own goroutine.
+func (app *application) purgeTrash(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := app.DB.PurgeDeleted(app.TrashRetention)
+
+		if err != nil {
+			log.Println("purge trash:", err)
+		} else if purged > 0 {
+			log.Printf("purge trash: removed %d items", purged)
+		}
+
+		<-ticker.C
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a fixed window limiter allowing a number of events per key
+// within each window. A nil limiter allows everything.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[string]*rateWindow),
+	}
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+func (l *rateLimiter) Allow(key string) bool {
+	if l == nil || l.limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	hit, ok := l.hits[key]
+
+	if !ok || now.Sub(hit.start) >= l.window {
+		// drop expired windows now and then so the map does not grow forever
+		if len(l.hits) > 10000 {
+			l.sweep(now)
+		}
+
+		l.hits[key] = &rateWindow{start: now, count: 1}
+		return true
+	}
+
+	if hit.count >= l.limit {
+		return false
+	}
+
+	hit.count++
+	return true
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, hit := range l.hits {
+		if now.Sub(hit.start) >= l.window {
+			delete(l.hits, key)
+		}
+	}
+}
@@ -14,29 +14,100 @@ import (
 func (app *application) routes() http.Handler {
 	mux := chi.NewRouter()
 
+	mux.Use(middleware.RequestID)
 	mux.Use(middleware.Recoverer)
 	mux.Use(app.enableCORS)
 
 	mux.Post("/signup", app.Signup)
 	mux.Post("/login", app.Login)
+	mux.Get("/media/*", app.GetMedia)
+	mux.Get("/calendar/{userID}.ics", app.GetCalendarFeed)
 
-	mux.Get("/polls", app.GetAllPolls)
-	mux.Get("/polls/{pollID}", app.GetPoll)
+	mux.Group(func(r chi.Router) {
+		r.Use(app.authOptional)
+		r.Get("/polls", app.GetAllPolls)
+		r.Get("/polls/{pollID}", app.GetPoll)
+		r.Get("/polls/{pollID}/results", app.GetPollResults)
+		r.Get("/polls/{pollID}/history", app.GetPollHistory)
+		r.Get("/polls/{pollID}/event.ics", app.GetPollEvent)
 
-	mux.Get("/polls/{pollID}/options/{optionID}/votes", app.GetOptionVotes)
+		r.Get("/polls/{pollID}/options/{optionID}/votes", app.GetOptionVotes)
+
+		r.Get("/surveys/{surveyID}", app.GetSurvey)
+	})
+
+	mux.Group(func(r chi.Router) {
+		r.Use(app.guestRateLimit)
+		r.Put("/polls/{pollID}/options/{optionID}/guest-votes", app.GuestVote)
+		r.Delete("/polls/{pollID}/guest-votes", app.GuestUnvote)
+	})
 
 	mux.Route("/", func(r chi.Router) {
 		r.Use(app.authRequired)
 		r.Post("/polls/create", app.CreatePoll)
+		r.Get("/trash", app.GetTrash)
 		r.Put("/polls/{pollID}", app.UpdatePoll)
+		r.Patch("/polls/{pollID}", app.PatchPoll)
+		r.Post("/polls/{pollID}/publish", app.PublishPoll)
+		r.Post("/polls/{pollID}/reopen", app.ReopenPoll)
 		r.Delete("/polls/{pollID}", app.RemovePoll)
+		r.Post("/polls/{pollID}/restore", app.RestorePoll)
 
 		r.Post("/polls/{pollID}/options", app.AddPollOptions)
+		r.Put("/polls/{pollID}/options", app.ReplacePollOptions)
+		r.Put("/polls/{pollID}/options/order", app.ReorderPollOptions)
 		r.Put("/polls/{pollID}/options/{optionID}", app.UpdatePollOption)
+		r.Patch("/polls/{pollID}/options/{optionID}", app.PatchPollOption)
 		r.Delete("/polls/{pollID}/options/{optionID}", app.RemovePollOption)
+		r.Post("/polls/{pollID}/options/{optionID}/restore", app.RestorePollOption)
+		r.Post("/polls/{pollID}/options/{optionID}/image", app.UploadOptionImage)
+		r.Delete("/polls/{pollID}/options/{optionID}/image", app.RemoveOptionImage)
+
+		r.Post("/polls/{pollID}/suggestions", app.SuggestOption)
+		r.Get("/polls/{pollID}/suggestions", app.GetSuggestions)
+		r.Put("/polls/{pollID}/suggestions/{optionID}", app.ReviewSuggestion)
+
+		r.Put("/polls/{pollID}/answers", app.SubmitTextAnswer)
+		r.Get("/polls/{pollID}/answers", app.GetTextAnswers)
+		r.Get("/polls/{pollID}/answers/summary", app.GetTextAnswerSummary)
+		r.Put("/polls/{pollID}/answers/{answerID}/moderation", app.ModerateTextAnswer)
+
+		r.Put("/polls/{pollID}/ballot", app.SubmitBallot)
+
+		r.Put("/polls/{pollID}/availability", app.SubmitAvailability)
+		r.Post("/polls/{pollID}/finalize", app.FinalizePoll)
+		r.Get("/calendar", app.GetCalendarURL)
+
+		r.Get("/polls/{pollID}/weights", app.GetVoterWeights)
+		r.Post("/polls/{pollID}/weights", app.SetVoterWeight)
+		r.Post("/polls/{pollID}/weights/import", app.ImportVoterWeights)
+		r.Delete("/polls/{pollID}/weights/{userID}", app.RemoveVoterWeight)
 
 		r.Put("/polls/{pollID}/options/{optionID}/votes", app.Vote)
 		r.Delete("/polls/{pollID}/options/{optionID}/votes", app.Unvote)
+		r.Put("/polls/{pollID}/blank-vote", app.CastBlankVote)
+		r.Delete("/polls/{pollID}/blank-vote", app.WithdrawBlankVote)
+
+		r.Get("/polls/{pollID}/collaborators", app.GetPollCollaborators)
+		r.Post("/polls/{pollID}/collaborators", app.AddPollCollaborator)
+		r.Delete("/polls/{pollID}/collaborators/{userID}", app.RemovePollCollaborator)
+
+		r.Post("/surveys", app.CreateSurvey)
+		r.Post("/surveys/{surveyID}/publish", app.PublishSurvey)
+		r.Post("/surveys/{surveyID}/responses", app.SubmitSurvey)
+		r.Get("/surveys/{surveyID}/next", app.GetNextSurveyQuestion)
+		r.Get("/surveys/{surveyID}/results", app.GetSurveyResults)
+		r.Get("/surveys/{surveyID}/leaderboard", app.GetQuizLeaderboard)
+
+		r.Post("/organizations", app.CreateOrganization)
+		r.Get("/organizations", app.GetMyOrganizations)
+		r.Get("/organizations/{orgID}", app.GetOrganization)
+		r.Get("/organizations/{orgID}/polls", app.GetOrganizationPolls)
+		r.Post("/organizations/{orgID}/members", app.AddOrganizationMember)
+		r.Put("/organizations/{orgID}/members/{userID}", app.UpdateOrganizationMember)
+		r.Delete("/organizations/{orgID}/members/{userID}", app.RemoveOrganizationMember)
+
+		r.Get("/audit", app.GetAuditEvents)
 	})
 
 	return mux
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"polling/internal/models"
+	"polling/internal/repository"
 	"polling/internal/repository/mocks"
+	"polling/internal/storage"
+	"polling/internal/validator"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,8 +44,9 @@ func setuptestApp(cfg TestAppConfig) *application {
 	}
 
 	return &application{
-		DB:   mockRepo,
-		auth: mockAuth,
+		DB:    mockRepo,
+		auth:  mockAuth,
+		Media: storage.NewMemoryStore(),
 	}
 }
 
@@ -59,7 +64,10 @@ func generateTestJWT(auth Auth, userID int) (string, error) {
 }
 
 func addURLParamToRequest(req *http.Request, key, value string) *http.Request {
-	rctx := chi.NewRouteContext()
+	rctx, ok := req.Context().Value(chi.RouteCtxKey).(*chi.Context)
+	if !ok {
+		rctx = chi.NewRouteContext()
+	}
 	rctx.URLParams.Add(key, value)
 	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 }
@@ -97,7 +105,7 @@ func TestSignup(t *testing.T) {
 			dbShouldFail:    false,
 			expectedStatus:  http.StatusBadRequest,
 			expectError:     true,
-			expectedMessage: "missing one or more required field ['username','password','first_name', 'last_name']",
+			expectedMessage: "validation failed",
 		},
 		{
 			name: "missing password",
@@ -109,7 +117,7 @@ func TestSignup(t *testing.T) {
 			dbShouldFail:    false,
 			expectedStatus:  http.StatusBadRequest,
 			expectError:     true,
-			expectedMessage: "missing one or more required field ['username','password','first_name', 'last_name']",
+			expectedMessage: "validation failed",
 		},
 		{
 			name: "database error",
@@ -120,9 +128,9 @@ func TestSignup(t *testing.T) {
 				"last_name":  "Doe",
 			},
 			dbShouldFail:    true,
-			expectedStatus:  http.StatusBadRequest,
+			expectedStatus:  http.StatusInternalServerError,
 			expectError:     true,
-			expectedMessage: "database error",
+			expectedMessage: "internal server error",
 		},
 	}
 
@@ -160,12 +168,12 @@ func TestSignup(t *testing.T) {
 				if err != nil {
 					t.Errorf("Failed to parse response: %v", err)
 				}
-				if _, exists := response["error"]; !exists {
-					t.Error("Expected error in response but got none")
+				if _, exists := response["code"]; !exists {
+					t.Error("Expected error code in response but got none")
 				}
 
 				// Check specific error message
-				if errorMsg, exists := response["error"].(string); exists {
+				if errorMsg, exists := response["detail"].(string); exists {
 					if errorMsg != tt.expectedMessage {
 						t.Errorf("Expected error message '%s', got '%s'", tt.expectedMessage, errorMsg)
 					}
@@ -186,6 +194,12 @@ func TestSignup(t *testing.T) {
 						t.Errorf("Expected success message '%s', got '%s'", tt.expectedMessage, successMsg)
 					}
 				}
+
+				events := app.DB.(*mocks.MockDBRepo).AuditEvents
+				if len(events) != 1 || events[0].ActorID == nil || *events[0].ActorID != 100 ||
+					events[0].TargetID == nil || *events[0].TargetID != 100 {
+					t.Errorf("expected a signup audit event by and about user 100, got %v", events)
+				}
 			}
 		})
 	}
@@ -249,8 +263,8 @@ func TestLogin(t *testing.T) {
 				"password": "password123",
 			},
 			mockUser:       nil,
-			mockError:      errors.New("user not found"),
-			expectedStatus: http.StatusBadRequest,
+			mockError:      repository.NotFound("user_not_found", "user not found"),
+			expectedStatus: http.StatusUnauthorized,
 			expectError:    true,
 			checkTokens:    false,
 		},
@@ -307,8 +321,8 @@ func TestLogin(t *testing.T) {
 				if err != nil {
 					t.Errorf("Failed to parse response: %v", err)
 				}
-				if _, exists := response["error"]; !exists {
-					t.Error("Expected error in response but got none")
+				if _, exists := response["code"]; !exists {
+					t.Error("Expected error code in response but got none")
 				}
 			} else if tt.checkTokens {
 				var response map[string]any
@@ -427,12 +441,8 @@ func TestCreatePoll(t *testing.T) {
 
 			// Check response based on expectations
 			if tt.expectError {
-				if tt.expectedStatus == http.StatusUnauthorized {
-					// Auth middleware returns empty body for unauthorized requests
-					if rr.Body.Len() == 0 {
-						// This is expected for auth middleware rejection
-						return
-					}
+				if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+					t.Errorf("Expected problem+json content type, got '%s'", ct)
 				}
 
 				var response map[string]any
@@ -440,8 +450,8 @@ func TestCreatePoll(t *testing.T) {
 				if err != nil {
 					t.Errorf("Failed to parse response: %v", err)
 				}
-				if _, exists := response["error"]; !exists {
-					t.Error("Expected error in response but got none")
+				if _, exists := response["code"]; !exists {
+					t.Error("Expected error code in response but got none")
 				}
 			} else {
 				var response map[string]any
@@ -492,16 +502,16 @@ func TestRemovePoll(t *testing.T) {
 			pollID:          2,
 			userID:          1,
 			withAuth:        true,
-			expectedStatus:  http.StatusBadRequest,
+			expectedStatus:  http.StatusInternalServerError,
 			expectError:     true,
-			expectedMessage: "database error",
+			expectedMessage: "internal server error",
 			dbShouldFail:    false,
 		}, {
 			name:            "unathuroized",
 			pollID:          1,
 			userID:          2,
 			withAuth:        true,
-			expectedStatus:  http.StatusUnauthorized,
+			expectedStatus:  http.StatusForbidden,
 			expectError:     true,
 			expectedMessage: "you are not authorized to update this poll",
 			dbShouldFail:    false,
@@ -515,6 +525,7 @@ func TestRemovePoll(t *testing.T) {
 
 			// Create HTTP request
 			req := httptest.NewRequest("DELETE", fmt.Sprintf("/polls/%d", tt.pollID), nil)
+			req.Header.Set("If-Match", `"1"`)
 			req.Header.Set("Content-Type", "application/json")
 
 			// Add poll ID to chi URL params context
@@ -543,12 +554,8 @@ func TestRemovePoll(t *testing.T) {
 
 			// Check response based on expectations
 			if tt.expectError {
-				if tt.expectedStatus == http.StatusUnauthorized {
-					// Auth middleware returns empty body for unauthorized requests
-					if rr.Body.Len() == 0 {
-						// This is expected for auth middleware rejection
-						return
-					}
+				if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+					t.Errorf("Expected problem+json content type, got '%s'", ct)
 				}
 
 				var response map[string]any
@@ -556,13 +563,13 @@ func TestRemovePoll(t *testing.T) {
 				if err != nil {
 					t.Errorf("Failed to parse response: %v", err)
 				}
-				if _, exists := response["error"]; !exists {
-					t.Error("Expected error in response but got none")
+				if _, exists := response["code"]; !exists {
+					t.Error("Expected error code in response but got none")
 				}
-				if _, exists := response["message"]; !exists {
-					t.Error("Expected message in response but got none")
-				} else if response["message"] != tt.expectedMessage {
-					t.Error("Got wrong message in response")
+				if _, exists := response["detail"]; !exists {
+					t.Error("Expected detail in response but got none")
+				} else if response["detail"] != tt.expectedMessage {
+					t.Error("Got wrong detail in response")
 				}
 
 			} else {
@@ -622,9 +629,9 @@ func TestAddPollOptions(t *testing.T) {
 				},
 			},
 			withAuth:        true,
-			expectedStatus:  http.StatusBadRequest,
+			expectedStatus:  http.StatusInternalServerError,
 			expectError:     true,
-			expectedMessage: "database error",
+			expectedMessage: "internal server error",
 			dbShouldFail:    false,
 		}, {
 			name:   "invalid owner",
@@ -637,7 +644,7 @@ func TestAddPollOptions(t *testing.T) {
 				},
 			},
 			withAuth:        true,
-			expectedStatus:  http.StatusUnauthorized,
+			expectedStatus:  http.StatusForbidden,
 			expectError:     true,
 			expectedMessage: "you are not authorized to update this poll",
 			dbShouldFail:    false,
@@ -654,7 +661,7 @@ func TestAddPollOptions(t *testing.T) {
 			withAuth:        false,
 			expectedStatus:  http.StatusUnauthorized,
 			expectError:     true,
-			expectedMessage: "",
+			expectedMessage: "missing or invalid token",
 			dbShouldFail:    false,
 		},
 	}
@@ -701,12 +708,8 @@ func TestAddPollOptions(t *testing.T) {
 
 			// Check response based on expectations
 			if tt.expectError {
-				if tt.expectedStatus == http.StatusUnauthorized {
-					// Auth middleware returns empty body for unauthorized requests
-					if rr.Body.Len() == 0 {
-						// This is expected for auth middleware rejection
-						return
-					}
+				if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+					t.Errorf("Expected problem+json content type, got '%s'", ct)
 				}
 
 				var response map[string]any
@@ -714,13 +717,13 @@ func TestAddPollOptions(t *testing.T) {
 				if err != nil {
 					t.Errorf("Failed to parse response: %v", err)
 				}
-				if _, exists := response["error"]; !exists {
-					t.Error("Expected error in response but got none")
+				if _, exists := response["code"]; !exists {
+					t.Error("Expected error code in response but got none")
 				}
-				if _, exists := response["message"]; !exists {
-					t.Error("Expected message in response but got none")
-				} else if response["message"] != tt.expectedMessage {
-					t.Error("Got wrong message in response")
+				if _, exists := response["detail"]; !exists {
+					t.Error("Expected detail in response but got none")
+				} else if response["detail"] != tt.expectedMessage {
+					t.Error("Got wrong detail in response")
 				}
 
 			} else {
@@ -742,3 +745,973 @@ func TestAddPollOptions(t *testing.T) {
 	}
 
 }
+
+func TestPublishPoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         int
+		userID         int
+		expectedStatus int
+	}{
+		{name: "complete draft", pollID: 1, userID: 1, expectedStatus: http.StatusOK},
+		{name: "draft without options", pollID: 2, userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "already published", pollID: 4, userID: 1, expectedStatus: http.StatusBadRequest},
+		{name: "not the owner", pollID: 1, userID: 4, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("POST", fmt.Sprintf("/polls/%d/publish", tt.pollID), nil)
+			req = addURLParamToRequest(req, "pollID", fmt.Sprintf("%d", tt.pollID))
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.PublishPoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestGetDraftPoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		withAuth       bool
+		expectedStatus int
+	}{
+		{name: "anonymous", withAuth: false, expectedStatus: http.StatusNotFound},
+		{name: "other user", userID: 4, withAuth: true, expectedStatus: http.StatusNotFound},
+		{name: "owner", userID: 1, withAuth: true, expectedStatus: http.StatusOK},
+		{name: "editor", userID: 5, withAuth: true, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/1", nil)
+			req = addURLParamToRequest(req, "pollID", "1")
+
+			if tt.withAuth {
+				token, err := generateTestJWT(app.auth, tt.userID)
+				if err != nil {
+					t.Fatalf("Failed to generate token: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetPoll))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestGetAllPollsListsEditableDrafts(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        int
+		withAuth      bool
+		expectedPolls []int
+	}{
+		{name: "anonymous", withAuth: false, expectedPolls: []int{4}},
+		{name: "other user", userID: 4, withAuth: true, expectedPolls: []int{4}},
+		{name: "owner", userID: 1, withAuth: true, expectedPolls: []int{1, 2, 4}},
+		{name: "editor", userID: 5, withAuth: true, expectedPolls: []int{1, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls", nil)
+
+			if tt.withAuth {
+				token, err := generateTestJWT(app.auth, tt.userID)
+				if err != nil {
+					t.Fatalf("Failed to generate token: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetAllPolls))
+			handler.ServeHTTP(rr, req)
+
+			var polls []models.Poll
+			err := json.Unmarshal(rr.Body.Bytes(), &polls)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			ids := []int{}
+			for _, poll := range polls {
+				ids = append(ids, poll.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tt.expectedPolls) {
+				t.Errorf("expected polls %v, got %v", tt.expectedPolls, ids)
+			}
+		})
+	}
+}
+
+func TestGetOptionVotes(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		optionID       string
+		expectedStatus int
+	}{
+		{name: "option of the poll", pollID: "4", optionID: "2", expectedStatus: http.StatusOK},
+		{name: "option of another poll", pollID: "4", optionID: "6", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/"+tt.pollID+"/options/"+tt.optionID+"/votes", nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+			req = addURLParamToRequest(req, "optionID", tt.optionID)
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetOptionVotes))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnvote(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		optionID       string
+		expectedStatus int
+	}{
+		{name: "option of the poll", pollID: "4", optionID: "2", expectedStatus: http.StatusOK},
+		{name: "option of another poll", pollID: "4", optionID: "6", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("DELETE", "/polls/"+tt.pollID+"/options/"+tt.optionID+"/votes", nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+			req = addURLParamToRequest(req, "optionID", tt.optionID)
+
+			token, err := generateTestJWT(app.auth, 2)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.Unvote))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestPublishedPollStructureIsFrozen(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		optionID       string
+		handler        func(app *application) http.HandlerFunc
+		payload        any
+		expectedStatus int
+	}{
+		{
+			name:           "add options",
+			method:         "POST",
+			handler:        func(app *application) http.HandlerFunc { return app.AddPollOptions },
+			payload:        map[string]any{"options": []map[string]string{{"text": "option3"}}},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "remove option",
+			method:         "DELETE",
+			optionID:       "1",
+			handler:        func(app *application) http.HandlerFunc { return app.RemovePollOption },
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "edit option with votes",
+			method:         "PUT",
+			optionID:       "2",
+			handler:        func(app *application) http.HandlerFunc { return app.UpdatePollOption },
+			payload:        map[string]string{"text": "changed"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "edit option without votes",
+			method:         "PUT",
+			optionID:       "1",
+			handler:        func(app *application) http.HandlerFunc { return app.UpdatePollOption },
+			payload:        map[string]string{"text": "typo fixed"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+
+			req := httptest.NewRequest(tt.method, "/polls/4/options", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("If-Match", `"1"`)
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", "4")
+			if tt.optionID != "" {
+				req = addURLParamToRequest(req, "optionID", tt.optionID)
+			}
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(tt.handler(app))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCreatePollWithOptions(t *testing.T) {
+	tests := []struct {
+		name               string
+		payload            map[string]any
+		expectedStatus     int
+		expectedFields     []string
+		expectedPollStatus string
+	}{
+		{
+			name: "publish with options",
+			payload: map[string]any{
+				"title":   "Lunch",
+				"publish": true,
+				"options": []map[string]string{{"text": "Pizza"}, {"text": "Sushi"}},
+			},
+			expectedStatus:     http.StatusOK,
+			expectedPollStatus: models.PollStatusPublished,
+		},
+		{
+			name: "draft with options",
+			payload: map[string]any{
+				"title":   "Lunch",
+				"options": []map[string]string{{"text": "Pizza"}},
+			},
+			expectedStatus:     http.StatusOK,
+			expectedPollStatus: models.PollStatusDraft,
+		},
+		{
+			name: "publish with a single option",
+			payload: map[string]any{
+				"title":   "Lunch",
+				"publish": true,
+				"options": []map[string]string{{"text": "Pizza"}},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"publish"},
+		},
+		{
+			name: "field errors",
+			payload: map[string]any{
+				"options": []map[string]string{{"text": "Pizza"}, {"text": ""}},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"title", "options[1].text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+
+			req := httptest.NewRequest("POST", "/polls/create", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("Content-Type", "application/json")
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.CreatePoll))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var poll models.Poll
+				err := json.Unmarshal(rr.Body.Bytes(), &poll)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if len(poll.Options) != len(tt.payload["options"].([]map[string]string)) {
+					t.Errorf("Expected %d options in response, got %d", len(tt.payload["options"].([]map[string]string)), len(poll.Options))
+				}
+				if poll.Status != tt.expectedPollStatus {
+					t.Errorf("Expected status '%s', got '%s'", tt.expectedPollStatus, poll.Status)
+				}
+				return
+			}
+
+			var response problem
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(response.Errors) != len(tt.expectedFields) {
+				t.Fatalf("Expected %d field errors, got %v", len(tt.expectedFields), response.Errors)
+			}
+			for i, field := range tt.expectedFields {
+				if response.Errors[i].Field != field {
+					t.Errorf("Expected field error for '%s', got '%s'", field, response.Errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestPayloadValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		params        map[string]string
+		handler       func(app *application) http.HandlerFunc
+		payload       any
+		expectedField string
+		expectedCode  string
+	}{
+		{
+			name:          "signup with invalid username",
+			method:        "POST",
+			path:          "/signup",
+			handler:       func(app *application) http.HandlerFunc { return app.Signup },
+			payload:       map[string]string{"username": "john doe", "password": "password123", "first_name": "John", "last_name": "Doe"},
+			expectedField: "username",
+			expectedCode:  validator.CodeInvalidFormat,
+		},
+		{
+			name:          "update poll with empty title",
+			method:        "PUT",
+			path:          "/polls/1",
+			params:        map[string]string{"pollID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.UpdatePoll },
+			payload:       map[string]string{"title": "", "description": "desc"},
+			expectedField: "title",
+			expectedCode:  validator.CodeRequired,
+		},
+		{
+			name:          "update poll with long title",
+			method:        "PUT",
+			path:          "/polls/1",
+			params:        map[string]string{"pollID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.UpdatePoll },
+			payload:       map[string]string{"title": strings.Repeat("a", models.MaxTitleLength+1)},
+			expectedField: "title",
+			expectedCode:  validator.CodeTooLong,
+		},
+		{
+			name:          "update option with empty text",
+			method:        "PUT",
+			path:          "/polls/1/options/1",
+			params:        map[string]string{"pollID": "1", "optionID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.UpdatePollOption },
+			payload:       map[string]string{"text": " "},
+			expectedField: "text",
+			expectedCode:  validator.CodeRequired,
+		},
+		{
+			name:          "update option to duplicate text",
+			method:        "PUT",
+			path:          "/polls/1/options/1",
+			params:        map[string]string{"pollID": "1", "optionID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.UpdatePollOption },
+			payload:       map[string]string{"text": "no"},
+			expectedField: "text",
+			expectedCode:  validator.CodeDuplicate,
+		},
+		{
+			name:          "update option with invalid link",
+			method:        "PUT",
+			path:          "/polls/1/options/1",
+			params:        map[string]string{"pollID": "1", "optionID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.UpdatePollOption },
+			payload:       map[string]string{"text": "Yes", "link_url": "ftp://example.com/mockup"},
+			expectedField: "link_url",
+			expectedCode:  validator.CodeInvalidFormat,
+		},
+		{
+			name:          "add option with long description",
+			method:        "POST",
+			path:          "/polls/1/options",
+			params:        map[string]string{"pollID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.AddPollOptions },
+			payload:       map[string]any{"options": []map[string]string{{"text": "Maybe", "description": strings.Repeat("a", models.MaxOptionDescriptionLength+1)}}},
+			expectedField: "options[0].description",
+			expectedCode:  validator.CodeTooLong,
+		},
+		{
+			name:          "add duplicate option",
+			method:        "POST",
+			path:          "/polls/1/options",
+			params:        map[string]string{"pollID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.AddPollOptions },
+			payload:       map[string]any{"options": []map[string]string{{"text": "YES"}}},
+			expectedField: "options[0].text",
+			expectedCode:  validator.CodeDuplicate,
+		},
+		{
+			name:          "create text poll with options",
+			method:        "POST",
+			path:          "/polls/create",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Feedback", "kind": "text", "options": []map[string]string{{"text": "Yes"}}},
+			expectedField: "options",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create text poll with long answer limit",
+			method:        "POST",
+			path:          "/polls/create",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Feedback", "kind": "text", "max_answer_length": models.MaxTextAnswerLength + 1},
+			expectedField: "max_answer_length",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create poll of unknown kind",
+			method:        "POST",
+			path:          "/polls/create",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Feedback", "kind": "essay"},
+			expectedField: "kind",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create poll with correct option outside a quiz",
+			method:        "POST",
+			path:          "/polls/create",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Capitals", "options": []map[string]any{{"text": "Paris", "is_correct": true}, {"text": "London"}}},
+			expectedField: "options[0].is_correct",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create quiz with long time bonus",
+			method:        "POST",
+			path:          "/polls/create",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Capitals", "quiz": true, "time_bonus": models.MaxTimeBonus + 1},
+			expectedField: "time_bonus",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "publish quiz without correct option",
+			method:        "POST",
+			path:          "/polls/create",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Capitals", "quiz": true, "publish": true, "options": []map[string]string{{"text": "Paris"}, {"text": "London"}}},
+			expectedField: "publish",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "mark correct option outside a quiz",
+			method:        "PATCH",
+			path:          "/polls/1/options/1",
+			params:        map[string]string{"pollID": "1", "optionID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.PatchPollOption },
+			payload:       map[string]bool{"is_correct": true},
+			expectedField: "is_correct",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create ranked poll open to guests",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Board", "kind": "ranked", "allow_guests": true},
+			expectedField: "allow_guests",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create ranked quiz",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Board", "kind": "ranked", "quiz": true},
+			expectedField: "quiz",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "empty ballot",
+			method:        "PUT",
+			path:          "/polls/12/ballot",
+			params:        map[string]string{"pollID": "12"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitBallot },
+			payload:       map[string][]int{"ranking": {}},
+			expectedField: "ranking",
+			expectedCode:  validator.CodeRequired,
+		},
+		{
+			name:          "ballot ranking an unknown option",
+			method:        "PUT",
+			path:          "/polls/12/ballot",
+			params:        map[string]string{"pollID": "12"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitBallot },
+			payload:       map[string][]int{"ranking": {2, 9}},
+			expectedField: "ranking[1]",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "ballot ranking an option twice",
+			method:        "PUT",
+			path:          "/polls/12/ballot",
+			params:        map[string]string{"pollID": "12"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitBallot },
+			payload:       map[string][]int{"ranking": {2, 1, 2}},
+			expectedField: "ranking[2]",
+			expectedCode:  validator.CodeDuplicate,
+		},
+		{
+			name:          "create quadratic poll with large budget",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Roadmap", "kind": "quadratic", "credit_budget": models.MaxCreditBudget + 1},
+			expectedField: "credit_budget",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "quadratic ballot over budget",
+			method:        "PUT",
+			path:          "/polls/13/ballot",
+			params:        map[string]string{"pollID": "13"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitBallot },
+			payload:       map[string]any{"votes": []map[string]int{{"option_id": 1, "votes": 5}, {"option_id": 2, "votes": 1}}},
+			expectedField: "votes",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "quadratic ballot with negative votes",
+			method:        "PUT",
+			path:          "/polls/13/ballot",
+			params:        map[string]string{"pollID": "13"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitBallot },
+			payload:       map[string]any{"votes": []map[string]int{{"option_id": 1, "votes": -2}}},
+			expectedField: "votes[0].votes",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "quadratic ballot voting on an option twice",
+			method:        "PUT",
+			path:          "/polls/13/ballot",
+			params:        map[string]string{"pollID": "13"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitBallot },
+			payload:       map[string]any{"votes": []map[string]int{{"option_id": 1, "votes": 1}, {"option_id": 1, "votes": 2}}},
+			expectedField: "votes[1].option_id",
+			expectedCode:  validator.CodeDuplicate,
+		},
+		{
+			name:          "create weighted text poll",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Feedback", "kind": "text", "weighted": true},
+			expectedField: "weighted",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create weighted poll open to guests",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Shareholders", "weighted": true, "allow_guests": true},
+			expectedField: "allow_guests",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create scheduling poll with a slot missing its start",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Offsite", "kind": "schedule", "options": []map[string]any{{"text": "Monday", "ends_at": "2026-11-02T11:00:00Z", "time_zone": "Europe/Berlin"}}},
+			expectedField: "options[0].starts_at",
+			expectedCode:  validator.CodeRequired,
+		},
+		{
+			name:          "create scheduling poll with a slot ending before it starts",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Offsite", "kind": "schedule", "options": []map[string]any{{"text": "Monday", "starts_at": "2026-11-02T11:00:00Z", "ends_at": "2026-11-02T09:00:00Z", "time_zone": "Europe/Berlin"}}},
+			expectedField: "options[0].ends_at",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create scheduling poll with an unknown time zone",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Offsite", "kind": "schedule", "options": []map[string]any{{"text": "Monday", "starts_at": "2026-11-02T09:00:00Z", "ends_at": "2026-11-02T11:00:00Z", "time_zone": "Mars/Olympus_Mons"}}},
+			expectedField: "options[0].time_zone",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create choice poll with a time slot",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Lunch", "options": []map[string]any{{"text": "Pizza", "starts_at": "2026-11-02T12:00:00Z"}}},
+			expectedField: "options[0].starts_at",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "add slot without a time zone to a scheduling poll",
+			method:        "PUT",
+			path:          "/polls/16/options/1",
+			params:        map[string]string{"pollID": "16", "optionID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.UpdatePollOption },
+			payload:       map[string]any{"text": "Monday", "starts_at": "2026-11-02T09:00:00Z", "ends_at": "2026-11-02T11:00:00Z"},
+			expectedField: "time_zone",
+			expectedCode:  validator.CodeRequired,
+		},
+		{
+			name:          "availability with an unknown answer",
+			method:        "PUT",
+			path:          "/polls/16/availability",
+			params:        map[string]string{"pollID": "16"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitAvailability },
+			payload:       map[string]any{"answers": []map[string]any{{"option_id": 1, "answer": "maybe"}}},
+			expectedField: "answers[0].answer",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "availability answering a slot twice",
+			method:        "PUT",
+			path:          "/polls/16/availability",
+			params:        map[string]string{"pollID": "16"},
+			handler:       func(app *application) http.HandlerFunc { return app.SubmitAvailability },
+			payload:       map[string]any{"answers": []map[string]any{{"option_id": 1, "answer": "yes"}, {"option_id": 1, "answer": "no"}}},
+			expectedField: "answers[1].option_id",
+			expectedCode:  validator.CodeDuplicate,
+		},
+		{
+			name:          "create text poll offering none of the above",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Feedback", "kind": "text", "none_of_the_above": true},
+			expectedField: "none_of_the_above",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create weighted poll offering none of the above",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Shareholders", "weighted": true, "none_of_the_above": true},
+			expectedField: "none_of_the_above",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "blank vote with an unknown choice",
+			method:        "PUT",
+			path:          "/polls/19/blank-vote",
+			params:        map[string]string{"pollID": "19"},
+			handler:       func(app *application) http.HandlerFunc { return app.CastBlankVote },
+			payload:       map[string]any{"choice": "spoil"},
+			expectedField: "choice",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create poll with an unknown threshold",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Budget", "threshold": "plurality"},
+			expectedField: "threshold",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create poll with a quorum percentage over 100",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Budget", "weighted": true, "quorum_percent": 150},
+			expectedField: "quorum_percent",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create poll with a quorum percentage but no eligible voters",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Budget", "quorum_percent": 50},
+			expectedField: "quorum_percent",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create poll with both quorum kinds",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Budget", "weighted": true, "quorum": 10, "quorum_percent": 50},
+			expectedField: "quorum_percent",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "create ranked poll with a threshold",
+			method:        "POST",
+			path:          "/polls",
+			handler:       func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload:       map[string]any{"title": "Election", "kind": "ranked", "threshold": "majority"},
+			expectedField: "threshold",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:    "publish poll with a threshold but no closing time",
+			method:  "POST",
+			path:    "/polls",
+			handler: func(app *application) http.HandlerFunc { return app.CreatePoll },
+			payload: map[string]any{"title": "Budget", "threshold": "majority", "publish": true,
+				"options": []map[string]string{{"text": "Yes"}, {"text": "No"}}},
+			expectedField: "publish",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "patch draft with a negative credit budget",
+			method:        "PATCH",
+			path:          "/polls/1",
+			params:        map[string]string{"pollID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.PatchPoll },
+			payload:       map[string]any{"credit_budget": -5},
+			expectedField: "credit_budget",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "patch draft into a weighted poll open to guests",
+			method:        "PATCH",
+			path:          "/polls/1",
+			params:        map[string]string{"pollID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.PatchPoll },
+			payload:       map[string]any{"weighted": true, "allow_guests": true},
+			expectedField: "allow_guests",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "patch weighted draft to offer none of the above",
+			method:        "PATCH",
+			path:          "/polls/15",
+			params:        map[string]string{"pollID": "15"},
+			handler:       func(app *application) http.HandlerFunc { return app.PatchPoll },
+			payload:       map[string]any{"none_of_the_above": true},
+			expectedField: "none_of_the_above",
+			expectedCode:  validator.CodeInvalid,
+		},
+		{
+			name:          "patch draft with an unknown threshold",
+			method:        "PATCH",
+			path:          "/polls/1",
+			params:        map[string]string{"pollID": "1"},
+			handler:       func(app *application) http.HandlerFunc { return app.PatchPoll },
+			payload:       map[string]any{"threshold": "plurality"},
+			expectedField: "threshold",
+			expectedCode:  validator.CodeInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBuffer(jsonPayload))
+			req.Header.Set("If-Match", `"1"`)
+			req.Header.Set("Content-Type", "application/json")
+			for key, value := range tt.params {
+				req = addURLParamToRequest(req, key, value)
+			}
+
+			token, err := generateTestJWT(app.auth, 1)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(tt.handler(app))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+
+			var response problem
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(response.Errors) == 0 {
+				t.Fatal("Expected field errors in response")
+			}
+			if response.Errors[0].Field != tt.expectedField || response.Errors[0].Code != tt.expectedCode {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.expectedField, tt.expectedCode, response.Errors[0].Field, response.Errors[0].Code)
+			}
+		})
+	}
+}
+
+func TestResetVotesOnOptionEdit(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         int
+		payload        map[string]any
+		expectedStatus int
+	}{
+		{name: "editor keeps votes", userID: 5, payload: map[string]any{"text": "changed"}, expectedStatus: http.StatusOK},
+		{name: "editor cannot reset votes", userID: 5, payload: map[string]any{"text": "changed", "reset_votes": true}, expectedStatus: http.StatusForbidden},
+		{name: "owner resets votes", userID: 1, payload: map[string]any{"text": "changed", "reset_votes": true}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			jsonPayload, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+
+			req := httptest.NewRequest("PUT", "/polls/5/options/2", bytes.NewBuffer(jsonPayload))
+			req.Header.Set("If-Match", `"1"`)
+			req.Header.Set("Content-Type", "application/json")
+			req = addURLParamToRequest(req, "pollID", "5")
+			req = addURLParamToRequest(req, "optionID", "2")
+
+			token, err := generateTestJWT(app.auth, tt.userID)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+
+			authHandler := app.authRequired(http.HandlerFunc(app.UpdatePollOption))
+			authHandler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestGetPollHistory(t *testing.T) {
+	tests := []struct {
+		name           string
+		pollID         string
+		userID         int
+		expectedStatus int
+	}{
+		{name: "published poll", pollID: "4", userID: 0, expectedStatus: http.StatusOK},
+		{name: "draft for its owner", pollID: "1", userID: 1, expectedStatus: http.StatusOK},
+		{name: "draft for anonymous visitor", pollID: "1", userID: 0, expectedStatus: http.StatusNotFound},
+		{name: "unknown poll", pollID: "99", userID: 0, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := setuptestApp(TestAppConfig{})
+
+			req := httptest.NewRequest("GET", "/polls/"+tt.pollID+"/history", nil)
+			req = addURLParamToRequest(req, "pollID", tt.pollID)
+
+			if tt.userID != 0 {
+				token, err := generateTestJWT(app.auth, tt.userID)
+				if err != nil {
+					t.Fatalf("Failed to generate token: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			rr := httptest.NewRecorder()
+
+			handler := app.authOptional(http.HandlerFunc(app.GetPollHistory))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if rr.Code == http.StatusOK {
+				var history models.PollHistory
+				err := json.Unmarshal(rr.Body.Bytes(), &history)
+				if err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if len(history.Revisions) == 0 || len(history.Options) == 0 {
+					t.Errorf("Expected poll and option revisions, got %+v", history)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"polling/internal/mergepatch"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"reflect"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
@@ -27,19 +33,60 @@ func (app *application) readJSON(w http.ResponseWriter, r *http.Request, data an
 	err := dec.Decode(data)
 
 	if err != nil {
-		return err
+		return repository.Validation("invalid_json", "body must be a valid JSON object").Wrap(err)
 	}
 
 	err = dec.Decode(&struct{}{})
 
 	if err != io.EOF {
-		return errors.New("body must contain only a single JSON value")
+		return repository.Validation("invalid_json", "body must contain only a single JSON value")
 	}
 
 	return nil
 
 }
 
+// readMergePatch applies the JSON merge patch in the request body to data,
+// which has to hold the current state of the resource.
+func (app *application) readMergePatch(w http.ResponseWriter, r *http.Request, data any) error {
+	maxBytes := 1024 * 1024 // 1MB
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+
+	patch, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		return repository.Validation("invalid_json", "body could not be read").Wrap(err)
+	}
+
+	current, err := json.Marshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	patched, err := mergepatch.Apply(current, patch)
+
+	if err != nil {
+		return repository.Validation("invalid_json", "body must be a valid JSON merge patch").Wrap(err)
+	}
+
+	// members removed by the patch have to end up as zero values
+	target := reflect.ValueOf(data).Elem()
+	target.Set(reflect.Zero(target.Type()))
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(data)
+
+	if err != nil {
+		return repository.Validation("invalid_patch", "patch must only set known fields to values of their type").Wrap(err)
+	}
+
+	return nil
+}
+
 func (app *application) writeJSON(w http.ResponseWriter, status int, data any, headers ...http.Header) error {
 
 	out, err := json.Marshal(data)
@@ -48,14 +95,14 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, data any, h
 		return err
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+
 	if len(headers) > 0 {
 		for key, value := range headers[0] {
 			w.Header()[key] = value
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
 	w.WriteHeader(status)
 
 	_, err = w.Write(out)
@@ -68,20 +115,6 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, data any, h
 
 }
 
-func (app *application) writeError(w http.ResponseWriter, err error, status ...int) error {
-	statusCode := http.StatusBadRequest
-
-	if len(status) > 0 {
-		statusCode = status[0]
-	}
-
-	var payload JSONResponse
-	payload.Error = true
-	payload.Message = err.Error()
-
-	return app.writeJSON(w, statusCode, payload)
-}
-
 func (app *application) writeMessage(w http.ResponseWriter, message string, status ...int) error {
 	statusCode := http.StatusOK
 
@@ -96,30 +129,245 @@ func (app *application) writeMessage(w http.ResponseWriter, message string, stat
 	return app.writeJSON(w, statusCode, payload)
 }
 
-func (app *application) checkPollOwnership(w http.ResponseWriter, r *http.Request) error {
+// authenticatedUserID returns the ID of the user set by authRequired.
+func (app *application) authenticatedUserID(r *http.Request) (int, error) {
 	userIDstr, ok := r.Context().Value("userID").(string)
 
 	if !ok {
-		return errors.New("missing user")
+		return 0, errMissingUser
 	}
 
 	userID, err := strconv.Atoi(userIDstr)
 
+	if err != nil {
+		return 0, errMissingUser.Wrap(err)
+	}
+
+	return userID, nil
+}
+
+// optionalUserID returns the ID of the user set by authOptional, or 0 for
+// anonymous requests.
+func (app *application) optionalUserID(r *http.Request) int {
+	userID, err := app.authenticatedUserID(r)
+
+	if err != nil {
+		return 0
+	}
+
+	return userID
+}
+
+// guestVoter returns the guest voter ID from the signed guest cookie, issuing
+// a new cookie when the request has none or carries a tampered one. A guest
+// who drops the cookie gets a fresh token and so another vote; guestRateLimit
+// caps how fast a single address can do that.
+func (app *application) guestVoter(w http.ResponseWriter, r *http.Request) (string, error) {
+	cookie, err := r.Cookie(guestCookieName)
+
+	if err == nil {
+		if guestID, ok := app.auth.VerifyGuestToken(cookie.Value); ok {
+			return guestID, nil
+		}
+	}
+
+	guestID, value, err := app.auth.NewGuestToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, app.auth.GetGuestCookie(value))
+
+	return guestID, nil
+}
+
+// clientIP returns the IP address of the connection the request came from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// readIDParam parses the named URL parameter as an integer ID.
+func (app *application) readIDParam(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, name))
+
+	if err != nil {
+		return 0, repository.Validation("invalid_parameter", fmt.Sprintf("invalid %s parameter", name))
+	}
+
+	return id, nil
+}
+
+// readQueryID parses the named query parameter as an integer ID. It returns
+// nil when the parameter is absent.
+func (app *application) readQueryID(r *http.Request, name string) (*int, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(value)
+
+	if err != nil {
+		return nil, repository.Validation("invalid_parameter", fmt.Sprintf("invalid %s parameter", name))
+	}
+
+	return &id, nil
+}
+
+// readQueryInt parses the named query parameter as a positive integer. It
+// returns fallback when the parameter is absent.
+func (app *application) readQueryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+
+	if err != nil || n < 1 {
+		return 0, repository.Validation("invalid_parameter", fmt.Sprintf("invalid %s parameter", name))
+	}
+
+	return n, nil
+}
+
+// readQueryBool parses the named query parameter as a boolean. It returns nil
+// when the parameter is absent.
+func (app *application) readQueryBool(r *http.Request, name string) (*bool, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+
+	if err != nil {
+		return nil, repository.Validation("invalid_parameter", fmt.Sprintf("invalid %s parameter", name))
+	}
+
+	return &b, nil
+}
+
+// pollFromRequest loads the poll named by the pollID URL parameter.
+func (app *application) pollFromRequest(r *http.Request) (*models.Poll, error) {
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.DB.GetPollByID(pollID)
+}
+
+func (app *application) checkPollOwnership(w http.ResponseWriter, r *http.Request) error {
+	userID, err := app.authenticatedUserID(r)
+
 	if err != nil {
 		return err
 	}
 
-	pollIDStr := chi.URLParam(r, "pollID")
+	pollID, err := app.readIDParam(r, "pollID")
 
-	pollID, err := strconv.Atoi(pollIDStr)
+	if err != nil {
+		return err
+	}
+
+	if !app.canManagePoll(pollID, userID) {
+		return errNotPollEditor
+	}
+
+	return nil
+}
+
+// checkPollEditor is like checkPollOwnership, but also lets collaborators
+// with the editor role through.
+func (app *application) checkPollEditor(w http.ResponseWriter, r *http.Request) error {
+	userID, err := app.authenticatedUserID(r)
 
 	if err != nil {
-		return errors.New("invalid poll ID")
+		return err
 	}
 
-	if !app.DB.IsPollOwner(pollID, userID) {
-		return errors.New("you are not authorized to update this poll")
+	pollID, err := app.readIDParam(r, "pollID")
+
+	if err != nil {
+		return err
+	}
+
+	if !app.canEditPoll(pollID, userID) {
+		return errNotPollEditor
 	}
 
 	return nil
 }
+
+// canManagePoll reports whether the user owns the poll, or owns or administers
+// the organization the poll belongs to.
+func (app *application) canManagePoll(pollID int, userID int) bool {
+	if app.DB.IsPollOwner(pollID, userID) {
+		return true
+	}
+
+	poll, err := app.DB.GetPollByID(pollID)
+
+	return err == nil && app.isOrganizationManager(poll, userID)
+}
+
+// isOrganizationManager reports whether the user owns or administers the
+// organization the poll belongs to. They manage all of its polls.
+func (app *application) isOrganizationManager(poll *models.Poll, userID int) bool {
+	if poll.OrganizationID == nil {
+		return false
+	}
+
+	return models.CanManagePolls(app.DB.GetOrganizationRole(*poll.OrganizationID, userID))
+}
+
+// canEditPoll reports whether the user manages the poll or collaborates on it
+// as an editor.
+func (app *application) canEditPoll(pollID int, userID int) bool {
+	if app.canManagePoll(pollID, userID) {
+		return true
+	}
+
+	return app.DB.GetPollCollaboratorRole(pollID, userID) == models.CollaboratorRoleEditor
+}
+
+// canViewPoll reports whether the user may see the poll at all. Drafts are
+// only visible to the people who can edit them.
+func (app *application) canViewPoll(poll *models.Poll, userID int) bool {
+	if poll.IsPublished() {
+		return true
+	}
+
+	return userID != 0 && app.canEditPoll(poll.ID, userID)
+}
+
+// canViewResults reports whether the user may see the votes of the poll. A
+// userID of 0 stands for an anonymous visitor.
+func (app *application) canViewResults(poll *models.Poll, userID int) bool {
+	if !poll.PrivateResults {
+		return true
+	}
+
+	if userID == 0 {
+		return false
+	}
+
+	if app.canManagePoll(poll.ID, userID) {
+		return true
+	}
+
+	// both editors and result viewers may see private results
+	return app.DB.GetPollCollaboratorRole(poll.ID, userID) != ""
+}
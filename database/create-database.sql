@@ -4,30 +4,281 @@ CREATE TABLE USERS (
     password VARCHAR(255) NOT NULL,
     first_name VARCHAR(50) NOT NULL,
     last_name VARCHAR(50) NOT NULL,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE ORGANIZATIONS (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE ORGANIZATION_MEMBERS (
+    organization_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'member')),
+    FOREIGN KEY (organization_id) REFERENCES ORGANIZATIONS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    PRIMARY KEY (organization_id, user_id)
+);
+
 CREATE TABLE POLLS (
     id SERIAL PRIMARY KEY,
     title VARCHAR(255) NOT NULL,
     description TEXT,
     user_id INT NOT NULL,
-    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE
+    organization_id INT,
+    members_only BOOLEAN NOT NULL DEFAULT FALSE,
+    private_results BOOLEAN NOT NULL DEFAULT FALSE,
+    allow_guests BOOLEAN NOT NULL DEFAULT FALSE,
+    randomize_options BOOLEAN NOT NULL DEFAULT FALSE,
+    allow_suggestions BOOLEAN NOT NULL DEFAULT FALSE,
+    suggestion_limit INT NOT NULL DEFAULT 3 CHECK (suggestion_limit >= 0),
+    kind VARCHAR(20) NOT NULL DEFAULT 'choice' CHECK (kind IN ('choice', 'text', 'ranked', 'quadratic', 'schedule')),
+    max_answer_length INT NOT NULL DEFAULT 1000 CHECK (max_answer_length > 0),
+    quiz BOOLEAN NOT NULL DEFAULT FALSE,
+    time_bonus INT NOT NULL DEFAULT 0 CHECK (time_bonus >= 0),
+    credit_budget INT NOT NULL DEFAULT 100 CHECK (credit_budget > 0),
+    weighted BOOLEAN NOT NULL DEFAULT FALSE,
+    none_of_the_above BOOLEAN NOT NULL DEFAULT FALSE,
+    quorum INT NOT NULL DEFAULT 0 CHECK (quorum >= 0),
+    quorum_percent INT NOT NULL DEFAULT 0 CHECK (quorum_percent BETWEEN 0 AND 100),
+    threshold VARCHAR(20) NOT NULL DEFAULT '' CHECK (threshold IN ('', 'majority', 'supermajority', 'unanimity')),
+    outcome VARCHAR(20) NOT NULL DEFAULT '' CHECK (outcome IN ('', 'passed', 'failed', 'no_quorum')),
+    outcome_option_id INT,
+    decided_at TIMESTAMP,
+    final_option_id INT,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published')),
+    opens_at TIMESTAMPTZ,
+    closes_at TIMESTAMPTZ,
+    published_at TIMESTAMP,
+    revision INT NOT NULL DEFAULT 1,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES ORGANIZATIONS(id) ON DELETE SET NULL
 );
 
 CREATE TABLE POLL_OPTIONS (
     id SERIAL PRIMARY KEY,
     poll_id INT NOT NULL,
     option_text VARCHAR(255) NOT NULL,
-    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE
+    description TEXT NOT NULL DEFAULT '',
+    link_url VARCHAR(2048) NOT NULL DEFAULT '',
+    image_key VARCHAR(255),
+    thumbnail_key VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'approved' CHECK (status IN ('pending', 'approved', 'rejected')),
+    suggested_by INT,
+    is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    time_zone VARCHAR(64) NOT NULL DEFAULT '',
+    position INT NOT NULL DEFAULT 0,
+    revision INT NOT NULL DEFAULT 1,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (suggested_by) REFERENCES USERS(id) ON DELETE SET NULL
+);
+
+CREATE INDEX poll_options_suggested_by_idx ON POLL_OPTIONS (poll_id, suggested_by);
+
+-- the slot a scheduling poll settled on, added once both tables exist
+ALTER TABLE POLLS ADD FOREIGN KEY (final_option_id) REFERENCES POLL_OPTIONS(id) ON DELETE SET NULL;
+
+-- the option a poll with decision rules passed, once its outcome is decided
+ALTER TABLE POLLS ADD FOREIGN KEY (outcome_option_id) REFERENCES POLL_OPTIONS(id) ON DELETE SET NULL;
+
+CREATE TABLE POLL_REVISIONS (
+    poll_id INT NOT NULL,
+    revision INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    edited_by INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (edited_by) REFERENCES USERS(id) ON DELETE SET NULL,
+    PRIMARY KEY (poll_id, revision)
+);
+
+CREATE TABLE OPTION_REVISIONS (
+    option_id INT NOT NULL,
+    revision INT NOT NULL,
+    option_text VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    link_url VARCHAR(2048) NOT NULL DEFAULT '',
+    edited_by INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (option_id) REFERENCES POLL_OPTIONS(id) ON DELETE CASCADE,
+    FOREIGN KEY (edited_by) REFERENCES USERS(id) ON DELETE SET NULL,
+    PRIMARY KEY (option_id, revision)
 );
 
 CREATE TABLE VOTES (
     id SERIAL PRIMARY KEY,
     option_id INT NOT NULL,
+    option_revision INT NOT NULL DEFAULT 1,
     user_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (option_id) REFERENCES POLL_OPTIONS(id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
     UNIQUE(option_id, user_id)
 );
+
+CREATE TABLE GUEST_VOTES (
+    id SERIAL PRIMARY KEY,
+    poll_id INT NOT NULL,
+    option_id INT NOT NULL,
+    option_revision INT NOT NULL DEFAULT 1,
+    guest_token VARCHAR(64) NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (option_id) REFERENCES POLL_OPTIONS(id) ON DELETE CASCADE,
+    UNIQUE(poll_id, guest_token)
+);
+
+-- answers to text polls, kept apart from the votes on options
+CREATE TABLE TEXT_ANSWERS (
+    id SERIAL PRIMARY KEY,
+    poll_id INT NOT NULL,
+    user_id INT NOT NULL,
+    body TEXT NOT NULL,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    flagged BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    UNIQUE(poll_id, user_id)
+);
+
+-- ballots of ranked polls, listing option IDs from first to last choice
+CREATE TABLE BALLOTS (
+    id SERIAL PRIMARY KEY,
+    poll_id INT NOT NULL,
+    user_id INT NOT NULL,
+    ranking JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    UNIQUE(poll_id, user_id)
+);
+
+-- votes of quadratic polls, one row per option a voter spent credits on
+CREATE TABLE QUADRATIC_VOTES (
+    poll_id INT NOT NULL,
+    user_id INT NOT NULL,
+    option_id INT NOT NULL,
+    votes INT NOT NULL CHECK (votes > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    FOREIGN KEY (option_id) REFERENCES POLL_OPTIONS(id) ON DELETE CASCADE,
+    PRIMARY KEY (poll_id, user_id, option_id)
+);
+
+-- the allowlist of a weighted poll, which only changes until the poll opens
+CREATE TABLE VOTER_WEIGHTS (
+    poll_id INT NOT NULL,
+    user_id INT NOT NULL,
+    weight INT NOT NULL CHECK (weight > 0),
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+-- votes on choice polls that go to no option: abstentions, which only count
+-- towards turnout, and votes for none of the above
+CREATE TABLE BLANK_VOTES (
+    poll_id INT NOT NULL,
+    user_id INT NOT NULL,
+    choice VARCHAR(20) NOT NULL CHECK (choice IN ('abstain', 'none_of_the_above')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+-- answers to scheduling polls, one row per slot a voter answered
+CREATE TABLE SCHEDULE_ANSWERS (
+    poll_id INT NOT NULL,
+    user_id INT NOT NULL,
+    option_id INT NOT NULL,
+    answer VARCHAR(20) NOT NULL CHECK (answer IN ('yes', 'if_need_be', 'no')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    FOREIGN KEY (option_id) REFERENCES POLL_OPTIONS(id) ON DELETE CASCADE,
+    PRIMARY KEY (poll_id, user_id, option_id)
+);
+
+CREATE TABLE POLL_COLLABORATORS (
+    poll_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('editor', 'viewer')),
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE TABLE SURVEYS (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published')),
+    quiz BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE
+);
+
+-- every question is a poll, and a poll belongs to at most one survey
+CREATE TABLE SURVEY_QUESTIONS (
+    survey_id INT NOT NULL,
+    poll_id INT NOT NULL UNIQUE,
+    position INT NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'choice' CHECK (kind IN ('choice', 'rating', 'text')),
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    show_if JSONB NOT NULL DEFAULT '[]',
+    skip JSONB NOT NULL DEFAULT '[]',
+    FOREIGN KEY (survey_id) REFERENCES SURVEYS(id) ON DELETE CASCADE,
+    FOREIGN KEY (poll_id) REFERENCES POLLS(id) ON DELETE CASCADE,
+    PRIMARY KEY (survey_id, poll_id)
+);
+
+CREATE TABLE SURVEY_RESPONSES (
+    survey_id INT NOT NULL,
+    user_id INT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (survey_id) REFERENCES SURVEYS(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES USERS(id) ON DELETE CASCADE,
+    PRIMARY KEY (survey_id, user_id)
+);
+
+-- audit events outlive the users and polls they mention, so there are no
+-- foreign keys, and the rules below keep the table append-only
+CREATE TABLE AUDIT_EVENTS (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INT,
+    poll_id INT,
+    before JSONB,
+    after JSONB,
+    ip VARCHAR(45) NOT NULL,
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_events_poll_id_idx ON AUDIT_EVENTS (poll_id);
+CREATE INDEX audit_events_actor_id_idx ON AUDIT_EVENTS (actor_id);
+
+CREATE RULE audit_events_no_update AS ON UPDATE TO AUDIT_EVENTS DO INSTEAD NOTHING;
+CREATE RULE audit_events_no_delete AS ON DELETE TO AUDIT_EVENTS DO INSTEAD NOTHING;
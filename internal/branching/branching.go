@@ -0,0 +1,215 @@
+// Package branching decides which questions of a survey are shown, based on
+// the answers given to earlier questions. Questions are addressed by their
+// position in the survey and answers by the index of the chosen option, so
+// rules can be written before any option has been stored.
+package branching
+
+import "fmt"
+
+// Operators a condition can use.
+const (
+	OpEquals      = "equals"
+	OpIn          = "in"
+	OpGreaterThan = "gt"
+)
+
+// Answers maps the position of an answered question to the index of the
+// chosen option.
+type Answers map[int]int
+
+// Condition looks at the answer to the question at Question. Equals holds
+// when Option was chosen, in when one of Options was, and gt when the rating
+// given, the option index plus one, is above Value. A condition on a question
+// without an answer never holds.
+type Condition struct {
+	Question int    `json:"question"`
+	Operator string `json:"operator"`
+	Option   int    `json:"option,omitempty"`
+	Options  []int  `json:"options,omitempty"`
+	Value    int    `json:"value,omitempty"`
+}
+
+// SkipRule jumps ahead to the question at To once When holds. The questions
+// in between are hidden.
+type SkipRule struct {
+	When Condition `json:"when"`
+	To   int       `json:"to"`
+}
+
+// Question is a question of the flow. It is shown only when every condition
+// of ShowIf holds. Its skip rules are checked in order once it is answered,
+// and the first one that holds is followed.
+type Question struct {
+	Position int
+	Rating   bool
+	Choices  int
+	ShowIf   []Condition
+	Skip     []SkipRule
+}
+
+// Flow is the list of questions of a survey, ordered by position.
+type Flow []Question
+
+// Problem describes a broken rule of the question at Index. Field is the path
+// of the rule within the question, such as "skip[0].to".
+type Problem struct {
+	Index   int
+	Field   string
+	Message string
+}
+
+// Holds reports whether the condition is met by answers.
+func (c Condition) Holds(answers Answers) bool {
+	chosen, ok := answers[c.Question]
+
+	if !ok {
+		return false
+	}
+
+	switch c.Operator {
+	case OpEquals:
+		return chosen == c.Option
+	case OpIn:
+		for _, option := range c.Options {
+			if chosen == option {
+				return true
+			}
+		}
+	case OpGreaterThan:
+		return chosen+1 > c.Value
+	}
+
+	return false
+}
+
+// Visible returns the positions of the questions shown for answers, in
+// order.
+func (f Flow) Visible(answers Answers) []int {
+	visible := []int{}
+
+	for i := 0; i < len(f); {
+		question := f[i]
+		next := i + 1
+
+		if question.shown(answers) {
+			visible = append(visible, question.Position)
+
+			// rules that jump backwards would never end, they are ignored
+			if target, ok := question.skipTarget(answers); ok && f.index(target) > i {
+				next = f.index(target)
+			}
+		}
+
+		i = next
+	}
+
+	return visible
+}
+
+// Next returns the position of the first shown question without an answer.
+// It reports false once every shown question has been answered.
+func (f Flow) Next(answers Answers) (int, bool) {
+	for _, position := range f.Visible(answers) {
+		if _, ok := answers[position]; !ok {
+			return position, true
+		}
+	}
+
+	return 0, false
+}
+
+// Validate reports every rule of the flow that cannot work: conditions have to
+// look at an earlier question and pick options it has, and skip rules have to
+// jump ahead to a question that exists.
+func (f Flow) Validate() []Problem {
+	problems := []Problem{}
+
+	for i, question := range f {
+		for j, condition := range question.ShowIf {
+			path := fmt.Sprintf("show_if[%d]", j)
+			problems = append(problems, f.checkCondition(i, path, condition, false)...)
+		}
+
+		for j, rule := range question.Skip {
+			path := fmt.Sprintf("skip[%d]", j)
+			problems = append(problems, f.checkCondition(i, path+".when", rule.When, true)...)
+
+			if rule.To <= question.Position || f.index(rule.To) == len(f) {
+				problems = append(problems, Problem{i, path + ".to", "to must be the position of a later question"})
+			}
+		}
+	}
+
+	return problems
+}
+
+// checkCondition checks a condition of the question at i. Conditions of skip
+// rules may also look at the question they belong to.
+func (f Flow) checkCondition(i int, path string, c Condition, self bool) []Problem {
+	earlier := c.Question < f[i].Position || self && c.Question == f[i].Position
+	target := f.index(c.Question)
+
+	if !earlier || target == len(f) {
+		return []Problem{{i, path + ".question", "question must be the position of an earlier question"}}
+	}
+
+	source := f[target]
+	valid := func(option int) bool { return option >= 0 && option < source.Choices }
+
+	switch c.Operator {
+	case OpEquals:
+		if !valid(c.Option) {
+			return []Problem{{i, path + ".option", "option is not an option of the question"}}
+		}
+	case OpIn:
+		if len(c.Options) == 0 {
+			return []Problem{{i, path + ".options", "options is required"}}
+		}
+
+		for _, option := range c.Options {
+			if !valid(option) {
+				return []Problem{{i, path + ".options", "options lists an option the question does not have"}}
+			}
+		}
+	case OpGreaterThan:
+		if !source.Rating {
+			return []Problem{{i, path + ".operator", "gt only applies to rating questions"}}
+		}
+	default:
+		return []Problem{{i, path + ".operator", "operator must be one of ['equals','in','gt']"}}
+	}
+
+	return nil
+}
+
+func (q Question) shown(answers Answers) bool {
+	for _, condition := range q.ShowIf {
+		if !condition.Holds(answers) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q Question) skipTarget(answers Answers) (int, bool) {
+	for _, rule := range q.Skip {
+		if rule.When.Holds(answers) {
+			return rule.To, true
+		}
+	}
+
+	return 0, false
+}
+
+// index returns the index of the question at position, or len(f) when there
+// is none.
+func (f Flow) index(position int) int {
+	for i, question := range f {
+		if question.Position == position {
+			return i
+		}
+	}
+
+	return len(f)
+}
@@ -0,0 +1,179 @@
+package branching
+
+import (
+	"reflect"
+	"testing"
+)
+
+// survey asks
+//
+//	0: Do you use the office? (yes, no)
+//	1: Which floor? (1, 2, 3), skipped when 0 is no
+//	2: Rate the kitchen (1-5)
+//	3: What is wrong with it? (yes, no), shown when 2 is rated 1 or 2
+//	4: Anything else? (yes, no)
+func survey() Flow {
+	return Flow{
+		{Position: 0, Choices: 2, Skip: []SkipRule{{When: Condition{Question: 0, Operator: OpEquals, Option: 1}, To: 4}}},
+		{Position: 1, Choices: 3},
+		{Position: 2, Choices: 5, Rating: true},
+		{Position: 3, Choices: 2, ShowIf: []Condition{{Question: 2, Operator: OpIn, Options: []int{0, 1}}}},
+		{Position: 4, Choices: 2},
+	}
+}
+
+func TestConditionHolds(t *testing.T) {
+	answers := Answers{0: 1, 2: 3}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		holds     bool
+	}{
+		{"equals chosen option", Condition{Question: 0, Operator: OpEquals, Option: 1}, true},
+		{"equals other option", Condition{Question: 0, Operator: OpEquals, Option: 0}, false},
+		{"in set", Condition{Question: 0, Operator: OpIn, Options: []int{0, 1}}, true},
+		{"not in set", Condition{Question: 0, Operator: OpIn, Options: []int{0}}, false},
+		{"rating above threshold", Condition{Question: 2, Operator: OpGreaterThan, Value: 3}, true},
+		{"rating at threshold", Condition{Question: 2, Operator: OpGreaterThan, Value: 4}, false},
+		{"unanswered question", Condition{Question: 1, Operator: OpIn, Options: []int{0, 1, 2}}, false},
+		{"unknown operator", Condition{Question: 0, Operator: "lt", Option: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.condition.Holds(answers); got != tt.holds {
+				t.Errorf("expected %v, got %v", tt.holds, got)
+			}
+		})
+	}
+}
+
+func TestVisible(t *testing.T) {
+	tests := []struct {
+		name     string
+		answers  Answers
+		expected []int
+	}{
+		{"no answers yet", Answers{}, []int{0, 1, 2, 4}},
+		{"uses the office", Answers{0: 0}, []int{0, 1, 2, 4}},
+		{"does not use the office", Answers{0: 1}, []int{0, 4}},
+		{"likes the kitchen", Answers{0: 0, 1: 2, 2: 4}, []int{0, 1, 2, 4}},
+		{"dislikes the kitchen", Answers{0: 0, 1: 2, 2: 0}, []int{0, 1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := survey().Visible(tt.answers); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestVisibleIgnoresBackwardJumps(t *testing.T) {
+	flow := Flow{
+		{Position: 0, Choices: 2},
+		{Position: 1, Choices: 2, Skip: []SkipRule{{When: Condition{Question: 1, Operator: OpEquals, Option: 0}, To: 0}}},
+		{Position: 2, Choices: 2},
+	}
+
+	if got := flow.Visible(Answers{1: 0}); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("expected [0 1 2], got %v", got)
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		answers  Answers
+		expected int
+		ok       bool
+	}{
+		{"first question", Answers{}, 0, true},
+		{"after skipping", Answers{0: 1}, 4, true},
+		{"follow up question", Answers{0: 0, 1: 0, 2: 1}, 3, true},
+		{"earlier question left open", Answers{0: 0, 2: 4}, 1, true},
+		{"done after skipping", Answers{0: 1, 4: 0}, 0, false},
+		{"done", Answers{0: 0, 1: 0, 2: 4, 4: 1}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := survey().Next(tt.answers)
+
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if problems := survey().Validate(); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+
+	tests := []struct {
+		name     string
+		question Question
+		expected Problem
+	}{
+		{
+			name:     "condition on a later question",
+			question: Question{Position: 5, ShowIf: []Condition{{Question: 5, Operator: OpEquals}}},
+			expected: Problem{5, "show_if[0].question", "question must be the position of an earlier question"},
+		},
+		{
+			name:     "condition on a missing question",
+			question: Question{Position: 5, ShowIf: []Condition{{Question: -1, Operator: OpEquals}}},
+			expected: Problem{5, "show_if[0].question", "question must be the position of an earlier question"},
+		},
+		{
+			name:     "unknown option",
+			question: Question{Position: 5, ShowIf: []Condition{{Question: 0, Operator: OpEquals, Option: 2}}},
+			expected: Problem{5, "show_if[0].option", "option is not an option of the question"},
+		},
+		{
+			name:     "empty set",
+			question: Question{Position: 5, ShowIf: []Condition{{Question: 0, Operator: OpIn}}},
+			expected: Problem{5, "show_if[0].options", "options is required"},
+		},
+		{
+			name:     "unknown option in set",
+			question: Question{Position: 5, ShowIf: []Condition{{Question: 1, Operator: OpIn, Options: []int{0, 3}}}},
+			expected: Problem{5, "show_if[0].options", "options lists an option the question does not have"},
+		},
+		{
+			name:     "threshold on a choice question",
+			question: Question{Position: 5, ShowIf: []Condition{{Question: 0, Operator: OpGreaterThan, Value: 1}}},
+			expected: Problem{5, "show_if[0].operator", "gt only applies to rating questions"},
+		},
+		{
+			name:     "unknown operator",
+			question: Question{Position: 5, ShowIf: []Condition{{Question: 0, Operator: "not"}}},
+			expected: Problem{5, "show_if[0].operator", "operator must be one of ['equals','in','gt']"},
+		},
+		{
+			name:     "skip backwards",
+			question: Question{Position: 5, Choices: 2, Skip: []SkipRule{{When: Condition{Question: 5, Operator: OpEquals}, To: 1}}},
+			expected: Problem{5, "skip[0].to", "to must be the position of a later question"},
+		},
+		{
+			name:     "skip past the end",
+			question: Question{Position: 5, Choices: 2, Skip: []SkipRule{{When: Condition{Question: 5, Operator: OpEquals}, To: 9}}},
+			expected: Problem{5, "skip[0].to", "to must be the position of a later question"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := append(survey(), tt.question)
+			problems := flow.Validate()
+
+			if len(problems) != 1 || problems[0] != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, problems)
+			}
+		})
+	}
+}
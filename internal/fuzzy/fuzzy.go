@@ -0,0 +1,79 @@
+// Package fuzzy finds near-identical pieces of text, such as two people
+// suggesting "Pizza party" and "pizza-party!" as poll options.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases s, drops punctuation and symbols and collapses runs of
+// whitespace, so only the words of s are compared.
+func Normalize(s string) string {
+	var b strings.Builder
+
+	space := false
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteRune(' ')
+			}
+
+			b.WriteRune(r)
+			space = false
+		default:
+			space = true
+		}
+	}
+
+	return b.String()
+}
+
+// Levenshtein returns the number of single character insertions, deletions
+// and substitutions needed to turn a into b.
+func Levenshtein(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	// only two rows of the distance matrix are needed at a time
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(br)]
+}
+
+// Similar reports whether a and b are near-identical once normalized: they
+// may differ in about one character in five, so typos match while short,
+// genuinely different words such as "cat" and "car" do not.
+func Similar(a string, b string) bool {
+	a, b = Normalize(a), Normalize(b)
+
+	if a == b {
+		return true
+	}
+
+	longest := max(len([]rune(a)), len([]rune(b)))
+
+	return Levenshtein(a, b) <= longest/5
+}
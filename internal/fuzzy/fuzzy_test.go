@@ -0,0 +1,62 @@
+package fuzzy
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"Pizza party":         "pizza party",
+		"  pizza-PARTY!! ":    "pizza party",
+		"Team   building...":  "team building",
+		"Café au lait":        "café au lait",
+		"!!!":                 "",
+		"Option #2 (revised)": "option 2 revised",
+	}
+
+	for input, expected := range tests {
+		if got := Normalize(input); got != expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"same", "same", 0},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Levenshtein(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		similar bool
+	}{
+		{"Pizza party", "pizza-party!", true},
+		{"Pizza party", "Pizza partys", true},
+		{"Team building", "Team biulding", true},
+		{"cat", "car", false},
+		{"Yes", "No", false},
+		{"Bowling night", "Karaoke night", false},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := Similar(tt.a, tt.b); got != tt.similar {
+			t.Errorf("Similar(%q, %q) = %v, expected %v", tt.a, tt.b, got, tt.similar)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package ical writes calendars in the iCalendar format of RFC 5545, so
+// meetings can be imported into or subscribed to from calendar apps.
+package ical
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineLength is the longest content line, in octets, before it has to be
+// folded.
+const maxLineLength = 75
+
+// dateTimeFormat writes a time in UTC, which every calendar app understands
+// without a VTIMEZONE component.
+const dateTimeFormat = "20060102T150405Z"
+
+// Event is a single meeting. UID must stay the same across exports so
+// calendar apps update the event instead of adding it again.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+}
+
+// Calendar is a list of events. Name, if set, is shown by calendar apps that
+// subscribe to it.
+type Calendar struct {
+	ProductID string
+	Name      string
+	Events    []Event
+}
+
+// Encode writes the calendar to w.
+func (c *Calendar) Encode(w io.Writer) error {
+	var b strings.Builder
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+c.ProductID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+EscapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+event.UID)
+		writeLine(&b, "DTSTAMP:"+formatTime(event.Stamp))
+		writeLine(&b, "DTSTART:"+formatTime(event.Start))
+		writeLine(&b, "DTEND:"+formatTime(event.End))
+		writeLine(&b, "SUMMARY:"+EscapeText(event.Summary))
+
+		if event.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+EscapeText(event.Description))
+		}
+
+		if event.URL != "" {
+			writeLine(&b, "URL:"+event.URL)
+		}
+
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// EscapeText escapes the characters that have a meaning in TEXT values.
+func EscapeText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	)
+
+	return replacer.Replace(text)
+}
+
+// Fold splits a content line into lines of at most 75 octets, each
+// continuation line starting with a space. Lines are only split between
+// characters, never inside one.
+func Fold(line string) string {
+	if len(line) <= maxLineLength {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxLineLength
+
+	for len(line) > limit {
+		cut := limit
+
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space counts towards the length of the next line
+		limit = maxLineLength - 1
+	}
+
+	b.WriteString(line)
+
+	return b.String()
+}
+
+func writeLine(b *strings.Builder, line string) {
+	b.WriteString(Fold(line))
+	b.WriteString("\r\n")
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
@@ -0,0 +1,119 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncode(t *testing.T) {
+	berlin := time.FixedZone("CET", 60*60)
+
+	calendar := Calendar{
+		ProductID: "-//polling//meetings//EN",
+		Name:      "Meetings",
+		Events: []Event{
+			{
+				UID:         "poll-16@example.com",
+				Summary:     "Team offsite; planning, review",
+				Description: "Bring laptops\nand snacks",
+				Start:       time.Date(2026, 11, 2, 10, 0, 0, 0, berlin),
+				End:         time.Date(2026, 11, 2, 12, 30, 0, 0, berlin),
+				Stamp:       time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	var b strings.Builder
+
+	err := calendar.Encode(&b)
+	if err != nil {
+		t.Fatalf("Failed to encode calendar: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//polling//meetings//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Meetings",
+		"BEGIN:VEVENT",
+		"UID:poll-16@example.com",
+		"DTSTAMP:20261018T080000Z",
+		"DTSTART:20261102T090000Z",
+		"DTEND:20261102T113000Z",
+		`SUMMARY:Team offsite\; planning\, review`,
+		`DESCRIPTION:Bring laptops\nand snacks`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	if b.String() != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, b.String())
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "plain", expected: "plain"},
+		{text: `a\b`, expected: `a\\b`},
+		{text: "one, two; three", expected: `one\, two\; three`},
+		{text: "line\r\nbreak\nagain\r", expected: `line\nbreak\nagain`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := EscapeText(tt.text); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short", line: "SUMMARY:Standup"},
+		{name: "exactly 75 octets", line: "SUMMARY:" + strings.Repeat("a", 67)},
+		{name: "long", line: "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{name: "multi-byte characters", line: "SUMMARY:" + strings.Repeat("äöü€", 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := Fold(tt.line)
+			lines := strings.Split(folded, "\r\n")
+
+			for i, line := range lines {
+				if len(line) > maxLineLength {
+					t.Errorf("line %d is %d octets long", i, len(line))
+				}
+
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a character: %q", i, line)
+				}
+
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+			}
+
+			unfolded := strings.ReplaceAll(folded, "\r\n ", "")
+			if unfolded != tt.line {
+				t.Errorf("expected unfolding to give back %q, got %q", tt.line, unfolded)
+			}
+
+			if len(tt.line) <= maxLineLength && len(lines) != 1 {
+				t.Errorf("expected a short line to stay whole, got %d lines", len(lines))
+			}
+		})
+	}
+}
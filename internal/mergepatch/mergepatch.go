@@ -0,0 +1,54 @@
+// Package mergepatch applies JSON Merge Patch documents as described in
+// RFC 7396.
+package mergepatch
+
+import "encoding/json"
+
+// Apply returns target with patch merged into it. Members of patch replace
+// the members of target with the same name, null members remove them, and
+// objects are merged recursively. A patch that is not an object replaces the
+// target as a whole.
+func Apply(target []byte, patch []byte) ([]byte, error) {
+	var targetValue, patchValue any
+
+	if len(target) > 0 {
+		err := json.Unmarshal(target, &targetValue)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := json.Unmarshal(patch, &patchValue)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merge(targetValue, patchValue))
+}
+
+func merge(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+
+		targetObject[name] = merge(targetObject[name], value)
+	}
+
+	return targetObject
+}
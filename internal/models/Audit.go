@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions, named <target>.<verb>.
+const (
+	AuditUserSignup = "user.signup"
+	AuditUserLogin  = "user.login"
+
+	AuditPollCreate   = "poll.create"
+	AuditPollUpdate   = "poll.update"
+	AuditPollPublish  = "poll.publish"
+	AuditPollDelete   = "poll.delete"
+	AuditPollRestore  = "poll.restore"
+	AuditPollFinalize = "poll.finalize"
+	AuditPollReopen   = "poll.reopen"
+	AuditPollDecide   = "poll.decide"
+
+	AuditOptionCreate  = "option.create"
+	AuditOptionUpdate  = "option.update"
+	AuditOptionDelete  = "option.delete"
+	AuditOptionRestore = "option.restore"
+	AuditOptionReorder = "option.reorder"
+	AuditOptionReplace = "option.replace"
+	AuditOptionSuggest = "option.suggest"
+	AuditOptionReview  = "option.review"
+
+	AuditVote        = "vote.create"
+	AuditUnvote      = "vote.delete"
+	AuditGuestVote   = "guest_vote.create"
+	AuditGuestUnvote = "guest_vote.delete"
+	AuditBlankVote   = "blank_vote.create"
+	AuditBlankUnvote = "blank_vote.delete"
+
+	AuditTextAnswerSave     = "text_answer.save"
+	AuditTextAnswerModerate = "text_answer.moderate"
+
+	AuditBallotSave = "ballot.save"
+
+	AuditAvailabilitySave = "availability.save"
+
+	AuditWeightSet    = "weight.set"
+	AuditWeightRemove = "weight.remove"
+	AuditWeightImport = "weight.import"
+
+	AuditSurveyCreate  = "survey.create"
+	AuditSurveyPublish = "survey.publish"
+	AuditSurveySubmit  = "survey.submit"
+
+	AuditCollaboratorAdd    = "collaborator.add"
+	AuditCollaboratorRemove = "collaborator.remove"
+
+	AuditOrganizationCreate = "organization.create"
+	AuditMemberAdd          = "member.add"
+	AuditMemberUpdate       = "member.update"
+	AuditMemberRemove       = "member.remove"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser         = "user"
+	AuditTargetPoll         = "poll"
+	AuditTargetOption       = "option"
+	AuditTargetOrganization = "organization"
+	AuditTargetSurvey       = "survey"
+	AuditTargetTextAnswer   = "text_answer"
+	AuditTargetBallot       = "ballot"
+)
+
+// AuditEvent is an append-only record of a mutation. ActorID is nil for
+// anonymous actors such as guests or users signing up, Before and After hold
+// the JSON encoded state of the target around the change.
+type AuditEvent struct {
+	ID         int             `json:"id"`
+	ActorID    *int            `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   *int            `json:"target_id"`
+	PollID     *int            `json:"poll_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows down audit events. Nil fields match everything.
+type AuditFilter struct {
+	PollID  *int
+	ActorID *int
+	Limit   int
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"polling/internal/tabulation"
+	"polling/internal/validator"
+	"strconv"
+	"time"
+)
+
+// Counting methods of ranked polls.
+const (
+	TabulationIRV     = "irv"
+	TabulationSchulze = "schulze"
+)
+
+// Ballot is the vote of a user on a ranked poll. Ranking lists option IDs
+// from the first choice to the last, options left out rank below all others.
+type Ballot struct {
+	ID        int       `json:"id"`
+	PollID    int       `json:"poll_id"`
+	UserID    int       `json:"user_id"`
+	Ranking   []int     `json:"ranking"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RankedResults holds the count of the ballots of a ranked poll by one of
+// the counting methods.
+type RankedResults struct {
+	PollID        int                       `json:"poll_id"`
+	Method        string                    `json:"method"`
+	Ballots       int                       `json:"ballots"`
+	InstantRunoff *tabulation.RunoffResult  `json:"instant_runoff,omitempty"`
+	Schulze       *tabulation.SchulzeResult `json:"schulze,omitempty"`
+}
+
+// ValidateBallot checks that the ranking found at field lists options of the
+// poll, each at most once.
+func ValidateBallot(v *validator.Validator, field string, poll *Poll, ranking []int) {
+	v.Check(len(ranking) > 0, field, validator.CodeRequired, field+" is required")
+
+	ids := make([]string, len(ranking))
+
+	for i, id := range ranking {
+		v.Check(poll.OptionByID(id) != nil, validator.Index(field, i, ""), validator.CodeInvalid,
+			"option is not part of this poll")
+		ids[i] = strconv.Itoa(id)
+	}
+
+	v.NoDuplicates(ids, nil, func(i int) string {
+		return validator.Index(field, i, "")
+	})
+}
+
+// TallyBallots counts the ballots of the ranked poll by method. Options that
+// were removed since a ballot was cast are skipped.
+func (p *Poll) TallyBallots(ballots []*Ballot, method string) *RankedResults {
+	results := &RankedResults{
+		PollID:  p.ID,
+		Method:  method,
+		Ballots: len(ballots),
+	}
+
+	rankings := make([]tabulation.Ballot, len(ballots))
+
+	for i, ballot := range ballots {
+		rankings[i] = ballot.Ranking
+	}
+
+	switch method {
+	case TabulationSchulze:
+		results.Schulze = tabulation.Schulze(p.OptionIDs(), rankings)
+	default:
+		results.InstantRunoff = tabulation.InstantRunoff(p.OptionIDs(), rankings)
+	}
+
+	return results
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"polling/internal/validator"
+	"time"
+)
+
+// Choices of a blank vote. Abstaining counts towards turnout for quorum but
+// not towards any option, voting for none of the above rejects every option.
+const (
+	BlankAbstain        = "abstain"
+	BlankNoneOfTheAbove = "none_of_the_above"
+)
+
+// BlankVote is the vote of a user on a choice poll that goes to no option.
+// A user either votes for options or casts a blank vote, never both.
+type BlankVote struct {
+	PollID    int       `json:"poll_id"`
+	UserID    int       `json:"user_id"`
+	Choice    string    `json:"choice"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TakesBlankVotes reports whether the poll can be abstained on. Only choice
+// polls that are not quizzes can.
+func (p *Poll) TakesBlankVotes() bool {
+	return (p.Kind == "" || p.Kind == PollKindChoice) && !p.Quiz
+}
+
+// validateNoneOfTheAbove checks the settings of a poll that offers none of
+// the above.
+func (p *Poll) validateNoneOfTheAbove(v *validator.Validator) {
+	if !p.NoneOfTheAbove {
+		return
+	}
+
+	v.Check(p.TakesBlankVotes(), "none_of_the_above", validator.CodeInvalid,
+		"only choice polls that are not quizzes can offer none of the above")
+	v.Check(!p.Weighted, "none_of_the_above", validator.CodeInvalid, "weighted polls cannot offer none of the above")
+}
+
+// ValidateBlankVote checks the choice of a blank vote on the poll found at
+// field.
+func ValidateBlankVote(v *validator.Validator, field string, poll *Poll, choice string) {
+	v.In(field, choice, BlankAbstain, BlankNoneOfTheAbove)
+	v.Check(choice != BlankNoneOfTheAbove || poll.NoneOfTheAbove, field, validator.CodeInvalid,
+		"this poll does not offer none of the above")
+}
+
+// CountBlankVotes adds the blank votes of the poll to its results. None of
+// the above wins when it has more votes than every option.
+func (p *Poll) CountBlankVotes(results *PollResults, votes []*BlankVote) {
+	nota := 0
+
+	for _, vote := range votes {
+		switch vote.Choice {
+		case BlankAbstain:
+			results.Abstentions++
+		case BlankNoneOfTheAbove:
+			nota++
+		}
+	}
+
+	if !p.NoneOfTheAbove {
+		return
+	}
+
+	results.NoneOfTheAbove = &nota
+	results.Turnout += nota
+	results.NoneOfTheAboveWins = nota > 0
+
+	for _, option := range results.Options {
+		if option.Votes >= nota {
+			results.NoneOfTheAboveWins = false
+		}
+	}
+}
+
+// CanReopen reports whether the poll can go back to a draft at now, to be
+// run again with other options: it has closed and none of the above won.
+func (p *Poll) CanReopen(results *PollResults, now time.Time) bool {
+	return p.NoneOfTheAbove && p.IsPublished() && p.ClosesAt != nil && !now.Before(*p.ClosesAt) &&
+		results.NoneOfTheAboveWins
+}
@@ -0,0 +1,15 @@
+package models
+
+const (
+	CollaboratorRoleEditor = "editor"
+	CollaboratorRoleViewer = "viewer"
+)
+
+type PollCollaborator struct {
+	PollID    int    `json:"poll_id"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Role      string `json:"role"`
+}
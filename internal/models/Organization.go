@@ -0,0 +1,41 @@
+package models
+
+import (
+	"polling/internal/validator"
+	"time"
+)
+
+const MaxOrganizationNameLength = 255
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+type Organization struct {
+	ID        int                   `json:"id"`
+	Name      string                `json:"name"`
+	CreatedAt time.Time             `json:"created_at"`
+	Members   []*OrganizationMember `json:"members,omitempty"`
+}
+
+type OrganizationMember struct {
+	OrganizationID int    `json:"organization_id"`
+	UserID         int    `json:"user_id"`
+	Username       string `json:"username"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Role           string `json:"role"`
+}
+
+// CanManagePolls reports whether role allows managing every poll of the
+// organization, not only the ones the member created.
+func CanManagePolls(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin
+}
+
+func (o *Organization) Validate(v *validator.Validator) {
+	v.Required("name", o.Name)
+	v.MaxLength("name", o.Name, MaxOrganizationNameLength)
+}
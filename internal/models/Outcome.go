@@ -0,0 +1,143 @@
+package models
+
+import (
+	"polling/internal/validator"
+	"time"
+)
+
+// Win thresholds of a choice poll with decision rules. A majority needs more
+// than half of the turnout, a supermajority at least two thirds and unanimity
+// all of it. Without a threshold the option with the most votes wins, unless
+// it ties for first.
+const (
+	ThresholdMajority      = "majority"
+	ThresholdSupermajority = "supermajority"
+	ThresholdUnanimity     = "unanimity"
+)
+
+// Outcomes of a choice poll with decision rules, set once it closes.
+const (
+	OutcomePassed   = "passed"
+	OutcomeFailed   = "failed"
+	OutcomeNoQuorum = "no_quorum"
+)
+
+// Outcome is the formal result of a poll with decision rules. OptionID is the
+// option that passed, Participants counts the turnout and the abstentions and
+// Eligible the voters a quorum percentage is taken of.
+type Outcome struct {
+	Result       string `json:"result"`
+	OptionID     *int   `json:"option_id,omitempty"`
+	Participants int    `json:"participants"`
+	Eligible     int    `json:"eligible,omitempty"`
+}
+
+// HasDecisionRules reports whether the poll sets a quorum or a win threshold,
+// and so gets an outcome when it closes.
+func (p *Poll) HasDecisionRules() bool {
+	return p.Quorum > 0 || p.QuorumPercent > 0 || p.Threshold != ""
+}
+
+// validateDecisionRules checks the quorum and win threshold of a poll.
+func (p *Poll) validateDecisionRules(v *validator.Validator) {
+	if !p.HasDecisionRules() {
+		return
+	}
+
+	v.Check(p.Quorum >= 0, "quorum", validator.CodeInvalid, "quorum must not be negative")
+	v.Check(p.QuorumPercent >= 0 && p.QuorumPercent <= 100, "quorum_percent", validator.CodeInvalid,
+		"quorum_percent must be between 0 and 100")
+	v.Check(p.Quorum == 0 || p.QuorumPercent == 0, "quorum_percent", validator.CodeInvalid,
+		"set either quorum or quorum_percent")
+	v.Check(p.QuorumPercent == 0 || p.MembersOnly || p.Weighted, "quorum_percent", validator.CodeInvalid,
+		"quorum_percent needs a members_only or weighted poll to count the eligible voters")
+
+	if p.Threshold != "" {
+		v.In("threshold", p.Threshold, ThresholdMajority, ThresholdSupermajority, ThresholdUnanimity)
+	}
+
+	v.Check(p.TakesBlankVotes(), "threshold", validator.CodeInvalid,
+		"only choice polls that are not quizzes can have a quorum or a threshold")
+}
+
+// AwaitsOutcome reports whether the published poll has closed at now without
+// its outcome having been decided yet.
+func (p *Poll) AwaitsOutcome(now time.Time) bool {
+	return p.HasDecisionRules() && p.Outcome == "" && p.IsPublished() && p.IsClosed(now)
+}
+
+// Decide works out the outcome of the poll from its results. eligible is the
+// number of voters allowed to vote, the members of the organization of a
+// members_only poll or the allowlist of a weighted poll. Quorum counts voters,
+// while on weighted polls the threshold is met by the weights of the votes. A
+// poll nobody took part in never has a quorum.
+func (p *Poll) Decide(results *PollResults, eligible int) *Outcome {
+	outcome := &Outcome{
+		Participants: results.Turnout + results.Abstentions,
+		Eligible:     eligible,
+	}
+
+	if outcome.Participants == 0 || outcome.Participants < p.Quorum || outcome.Participants*100 < p.QuorumPercent*eligible {
+		outcome.Result = OutcomeNoQuorum
+		return outcome
+	}
+
+	outcome.Result = OutcomeFailed
+
+	leader, votes, total := results.leader()
+
+	if leader == nil || results.NoneOfTheAboveWins {
+		return outcome
+	}
+
+	passed := true
+
+	switch p.Threshold {
+	case ThresholdMajority:
+		passed = votes*2 > total
+	case ThresholdSupermajority:
+		passed = votes*3 >= total*2
+	case ThresholdUnanimity:
+		passed = votes == total
+	}
+
+	if passed {
+		outcome.Result = OutcomePassed
+		outcome.OptionID = &leader.OptionID
+	}
+
+	return outcome
+}
+
+// leader returns the option with the most votes, by weight on weighted polls,
+// with its votes and the total they are a share of. It returns a nil option
+// when nobody voted for an option or two options tie for first.
+func (r *PollResults) leader() (*OptionResult, int, int) {
+	var leader *OptionResult
+	most, tied := 0, false
+
+	for _, option := range r.Options {
+		votes := option.Votes
+
+		if option.WeightedVotes != nil {
+			votes = *option.WeightedVotes
+		}
+
+		switch {
+		case votes > most:
+			leader, most, tied = option, votes, false
+		case votes == most:
+			tied = true
+		}
+	}
+
+	if leader == nil || tied {
+		return nil, 0, 0
+	}
+
+	if r.WeightedTotal != nil {
+		return leader, most, *r.WeightedTotal
+	}
+
+	return leader, most, r.Turnout
+}
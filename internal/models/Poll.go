@@ -1,21 +1,377 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"polling/internal/validator"
+	"time"
+)
+
+const (
+	PollStatusDraft     = "draft"
+	PollStatusPublished = "published"
+)
+
+// Poll kinds. Choice polls are voted on by picking an option, text polls are
+// answered in free text and have no options, ranked polls take ballots that
+// order the options by preference, quadratic polls take ballots that spread
+// a budget of credits across the options and scheduling polls ask voters
+// whether they can make each of the time slots the options stand for.
+const (
+	PollKindChoice    = "choice"
+	PollKindText      = "text"
+	PollKindRanked    = "ranked"
+	PollKindQuadratic = "quadratic"
+	PollKindSchedule  = "schedule"
+)
+
+// Limits on the length of the answers to text polls, in characters.
+const (
+	DefaultTextAnswerLength = 1000
+	MaxTextAnswerLength     = 5000
+)
+
+// Option statuses. Options added by the people editing a poll are approved
+// right away, suggestions by voters wait in a queue until they are reviewed.
+const (
+	OptionStatusPending  = "pending"
+	OptionStatusApproved = "approved"
+	OptionStatusRejected = "rejected"
+)
+
+// Limits on the number of options a single user may suggest for a poll.
+const (
+	DefaultSuggestionLimit = 3
+	MaxSuggestionLimit     = 100
+)
+
+// Longest poll title, option text, option description and link URL. All but
+// the description, a TEXT column, match the size of their column in polls and
+// poll_options.
+const (
+	MaxTitleLength             = 255
+	MaxOptionTextLength        = 255
+	MaxOptionDescriptionLength = 2000
+	MaxLinkURLLength           = 2048
+)
+
+// PollOption is the current revision of an option. Votes holds the votes of
+// every revision, GuestVotes and OutdatedGuestVotes count the guest votes cast
+// against the current and earlier revisions. IsCorrect marks the right
+// answers of quiz polls, StartsAt, EndsAt and TimeZone the time slot of an
+// option of a scheduling poll.
 type PollOption struct {
-	ID    int     `json:"id"`
-	Text  string  `json:"text"`
-	Votes []*Vote `json:"votes"`
+	ID                 int        `json:"id"`
+	Text               string     `json:"text"`
+	Description        string     `json:"description"`
+	LinkURL            string     `json:"link_url"`
+	ImageKey           string     `json:"image_key,omitempty"`
+	ThumbnailKey       string     `json:"thumbnail_key,omitempty"`
+	Position           int        `json:"position"`
+	Status             string     `json:"status,omitempty"`
+	SuggestedBy        *int       `json:"suggested_by,omitempty"`
+	IsCorrect          bool       `json:"is_correct,omitempty"`
+	StartsAt           *time.Time `json:"starts_at,omitempty"`
+	EndsAt             *time.Time `json:"ends_at,omitempty"`
+	TimeZone           string     `json:"time_zone,omitempty"`
+	Revision           int        `json:"revision"`
+	Version            int        `json:"version"`
+	Votes              []*Vote    `json:"votes"`
+	GuestVotes         int        `json:"guest_votes"`
+	OutdatedGuestVotes int        `json:"outdated_guest_votes"`
 }
 
 type Poll struct {
-	ID          int           `json:"id"`
-	Title       string        `json:"title"`
-	Description string        `json:"description"`
-	UserID      int           `json:"user_id"`
-	Options     []*PollOption `json:"options"`
+	ID               int           `json:"id"`
+	Title            string        `json:"title"`
+	Description      string        `json:"description"`
+	UserID           int           `json:"user_id"`
+	OrganizationID   *int          `json:"organization_id"`
+	MembersOnly      bool          `json:"members_only"`
+	PrivateResults   bool          `json:"private_results"`
+	AllowGuests      bool          `json:"allow_guests"`
+	RandomizeOptions bool          `json:"randomize_options"`
+	AllowSuggestions bool          `json:"allow_suggestions"`
+	SuggestionLimit  int           `json:"suggestion_limit"`
+	Kind             string        `json:"kind"`
+	MaxAnswerLength  int           `json:"max_answer_length"`
+	TextAnswers      int           `json:"text_answers,omitempty"`
+	Quiz             bool          `json:"quiz"`
+	TimeBonus        int           `json:"time_bonus"`
+	CreditBudget     int           `json:"credit_budget"`
+	Weighted         bool          `json:"weighted"`
+	NoneOfTheAbove   bool          `json:"none_of_the_above"`
+	Quorum           int           `json:"quorum"`
+	QuorumPercent    int           `json:"quorum_percent"`
+	Threshold        string        `json:"threshold"`
+	Outcome          string        `json:"outcome,omitempty"`
+	OutcomeOptionID  *int          `json:"outcome_option_id,omitempty"`
+	DecidedAt        *time.Time    `json:"decided_at,omitempty"`
+	FinalOptionID    *int          `json:"final_option_id,omitempty"`
+	Status           string        `json:"status"`
+	OpensAt          *time.Time    `json:"opens_at"`
+	ClosesAt         *time.Time    `json:"closes_at"`
+	PublishedAt      *time.Time    `json:"published_at"`
+	Revision         int           `json:"revision"`
+	Version          int           `json:"version"`
+	DeletedAt        *time.Time    `json:"deleted_at,omitempty"`
+	Options          []*PollOption `json:"options"`
 }
 
+// Vote is a vote cast against a revision of an option.
 type Vote struct {
-	ID       int `json:"id"`
-	OptionID int `json:"option_id"`
-	UserID   int `json:"user_id"`
+	ID             int       `json:"id"`
+	OptionID       int       `json:"option_id"`
+	OptionRevision int       `json:"option_revision"`
+	UserID         int       `json:"user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// HideVotes removes the individual votes from every option of the poll.
+func (p *Poll) HideVotes() {
+	for _, option := range p.Options {
+		option.Votes = []*Vote{}
+		option.GuestVotes = 0
+		option.OutdatedGuestVotes = 0
+	}
+}
+
+// IsText reports whether the poll is answered in free text.
+func (p *Poll) IsText() bool {
+	return p.Kind == PollKindText
+}
+
+// IsRanked reports whether the poll is answered with ranked ballots.
+func (p *Poll) IsRanked() bool {
+	return p.Kind == PollKindRanked
+}
+
+// IsQuadratic reports whether the poll is answered with quadratic ballots.
+func (p *Poll) IsQuadratic() bool {
+	return p.Kind == PollKindQuadratic
+}
+
+// IsSchedule reports whether the poll is a scheduling poll.
+func (p *Poll) IsSchedule() bool {
+	return p.Kind == PollKindSchedule
+}
+
+func (p *Poll) IsPublished() bool {
+	return p.Status == PollStatusPublished
+}
+
+// IsOpen reports whether the poll is published and accepts votes at now.
+func (p *Poll) IsOpen(now time.Time) bool {
+	if !p.IsPublished() {
+		return false
+	}
+
+	if p.OpensAt != nil && now.Before(*p.OpensAt) {
+		return false
+	}
+
+	if p.ClosesAt != nil && !now.Before(*p.ClosesAt) {
+		return false
+	}
+
+	return true
+}
+
+// ValidateSchedule checks that the poll closes after it opens.
+func (p *Poll) ValidateSchedule() error {
+	if p.OpensAt != nil && p.ClosesAt != nil && !p.ClosesAt.After(*p.OpensAt) {
+		return errors.New("closes_at must be after opens_at")
+	}
+
+	return nil
+}
+
+// CheckPublishable reports why the poll is not ready to be published at now,
+// or nil when it is.
+func (p *Poll) CheckPublishable(now time.Time) error {
+	if p.IsPublished() {
+		return errors.New("poll is already published")
+	}
+
+	if len(p.Options) < 2 && !p.IsText() {
+		return errors.New("a poll needs at least two options to be published")
+	}
+
+	if p.Quiz && len(p.CorrectOptions()) == 0 {
+		return errors.New("a quiz needs at least one correct option to be published")
+	}
+
+	if p.HasDecisionRules() && p.ClosesAt == nil {
+		return errors.New("a poll with a quorum or threshold needs closes_at to be published")
+	}
+
+	err := p.ValidateSchedule()
+
+	if err != nil {
+		return err
+	}
+
+	if p.ClosesAt != nil && !p.ClosesAt.After(now) {
+		return errors.New("closes_at must be in the future")
+	}
+
+	return nil
+}
+
+// ChangesFrozenSettings reports whether after changes a setting of the poll
+// that decides who can vote, when and how the votes count. These are frozen
+// once the poll is published.
+func (p *Poll) ChangesFrozenSettings(after *Poll) bool {
+	if !p.IsPublished() {
+		return false
+	}
+
+	return !sameTime(p.OpensAt, after.OpensAt) || !sameTime(p.ClosesAt, after.ClosesAt) ||
+		p.AllowGuests != after.AllowGuests || p.MembersOnly != after.MembersOnly || p.TimeBonus != after.TimeBonus ||
+		p.CreditBudget != after.CreditBudget || p.Weighted != after.Weighted || p.NoneOfTheAbove != after.NoneOfTheAbove ||
+		p.Quorum != after.Quorum || p.QuorumPercent != after.QuorumPercent || p.Threshold != after.Threshold
+}
+
+// ChangesFrozenFields reports whether after changes what a vote for the
+// option means, whether it is a right answer of a quiz or the time slot it
+// stands for. These are frozen once the poll is published, only the wording
+// of an option can still be revised.
+func (o *PollOption) ChangesFrozenFields(after *PollOption) bool {
+	return o.IsCorrect != after.IsCorrect || !sameTime(o.StartsAt, after.StartsAt) || !sameTime(o.EndsAt, after.EndsAt) ||
+		o.TimeZone != after.TimeZone
+}
+
+// sameTime reports whether a and b are both unset or the same instant.
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+// Validate checks the poll fields and its options.
+func (p *Poll) Validate(v *validator.Validator) {
+	v.Required("title", p.Title)
+	v.MaxLength("title", p.Title, MaxTitleLength)
+
+	texts := make([]string, len(p.Options))
+
+	for i, option := range p.Options {
+		ValidateOption(v, validator.Index("options", i, ""), option)
+		texts[i] = option.Text
+	}
+
+	v.NoDuplicates(texts, nil, func(i int) string {
+		return validator.Index("options", i, "text")
+	})
+
+	v.Check(p.OrganizationID != nil || !p.MembersOnly, "members_only", validator.CodeInvalid, "members_only requires an organization_id")
+	v.Check(!p.MembersOnly || !p.AllowGuests, "allow_guests", validator.CodeInvalid, "members_only polls cannot allow guests")
+	v.Check(!p.AllowSuggestions || p.SuggestionLimit >= 1 && p.SuggestionLimit <= MaxSuggestionLimit, "suggestion_limit",
+		validator.CodeInvalid, fmt.Sprintf("suggestion_limit must be between 1 and %d", MaxSuggestionLimit))
+
+	if p.Kind != "" {
+		v.In("kind", p.Kind, PollKindChoice, PollKindText, PollKindRanked, PollKindQuadratic, PollKindSchedule)
+	}
+
+	if p.IsText() {
+		v.Check(len(p.Options) == 0, "options", validator.CodeInvalid, "text polls have no options")
+		v.Check(p.MaxAnswerLength >= 1 && p.MaxAnswerLength <= MaxTextAnswerLength, "max_answer_length",
+			validator.CodeInvalid, fmt.Sprintf("max_answer_length must be between 1 and %d", MaxTextAnswerLength))
+		v.Check(!p.AllowGuests, "allow_guests", validator.CodeInvalid, "text polls cannot be answered by guests")
+		v.Check(!p.AllowSuggestions, "allow_suggestions", validator.CodeInvalid, "text polls have no options to suggest")
+	}
+
+	if p.IsRanked() {
+		v.Check(!p.AllowGuests, "allow_guests", validator.CodeInvalid, "ranked polls cannot be answered by guests")
+	}
+
+	p.validateQuadratic(v)
+	p.validateWeighted(v)
+	p.validateSlots(v)
+	p.validateNoneOfTheAbove(v)
+	p.validateDecisionRules(v)
+
+	p.validateQuiz(v)
+
+	err := p.ValidateSchedule()
+
+	if err != nil {
+		v.AddError("closes_at", validator.CodeInvalid, err.Error())
+	}
+}
+
+// ValidateOption checks the content of an option found at parent.
+func ValidateOption(v *validator.Validator, parent string, option *PollOption) {
+	text := validator.Field(parent, "text")
+	v.Required(text, option.Text)
+	v.MaxLength(text, option.Text, MaxOptionTextLength)
+
+	v.MaxLength(validator.Field(parent, "description"), option.Description, MaxOptionDescriptionLength)
+
+	link := validator.Field(parent, "link_url")
+	v.MaxLength(link, option.LinkURL, MaxLinkURLLength)
+	v.URL(link, option.LinkURL)
+}
+
+// OptionTexts returns the texts of the poll options, skipping the option with
+// the ID except, if any.
+func (p *Poll) OptionTexts(except int) []string {
+	texts := []string{}
+
+	for _, option := range p.Options {
+		if option.ID != except {
+			texts = append(texts, option.Text)
+		}
+	}
+
+	return texts
+}
+
+// CurrentVotes returns the votes cast against the current revision of the
+// option.
+func (o *PollOption) CurrentVotes() []*Vote {
+	votes := []*Vote{}
+
+	for _, vote := range o.Votes {
+		if vote.OptionRevision == o.Revision {
+			votes = append(votes, vote)
+		}
+	}
+
+	return votes
+}
+
+// ShuffleOptions puts the options in a random order derived from seed, so
+// the same seed always yields the same order.
+func (p *Poll) ShuffleOptions(seed uint64) {
+	r := rand.New(rand.NewPCG(seed, uint64(p.ID)))
+
+	r.Shuffle(len(p.Options), func(i, j int) {
+		p.Options[i], p.Options[j] = p.Options[j], p.Options[i]
+	})
+}
+
+// OptionIDs returns the IDs of the options in their current order.
+func (p *Poll) OptionIDs() []int {
+	ids := make([]int, len(p.Options))
+
+	for i, option := range p.Options {
+		ids[i] = option.ID
+	}
+
+	return ids
+}
+
+// OptionByID returns the option of the poll with the given ID, or nil.
+func (p *Poll) OptionByID(id int) *PollOption {
+	for _, option := range p.Options {
+		if option.ID == id {
+			return option
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"polling/internal/validator"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Limits on the credits every voter of a quadratic poll may spend.
+const (
+	DefaultCreditBudget = 100
+	MaxCreditBudget     = 10000
+)
+
+// QuadraticVote is the number of votes a voter gives one option of a
+// quadratic poll.
+type QuadraticVote struct {
+	OptionID int `json:"option_id"`
+	Votes    int `json:"votes"`
+}
+
+// QuadraticBallot is the ballot of a user on a quadratic poll. Casting n votes
+// on one option costs n² credits, Credits is the total cost of the ballot.
+type QuadraticBallot struct {
+	PollID    int             `json:"poll_id"`
+	UserID    int             `json:"user_id"`
+	Votes     []QuadraticVote `json:"votes"`
+	Credits   int             `json:"credits"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// QuadraticOptionResult sums the votes given to an option and the credits
+// spent on them.
+type QuadraticOptionResult struct {
+	OptionID int    `json:"option_id"`
+	Text     string `json:"text"`
+	Votes    int    `json:"votes"`
+	Credits  int    `json:"credits"`
+	Voters   int    `json:"voters"`
+}
+
+// VoterCredits reports how much of the budget a voter used.
+type VoterCredits struct {
+	UserID    int `json:"user_id"`
+	Votes     int `json:"votes"`
+	Credits   int `json:"credits"`
+	Remaining int `json:"remaining"`
+}
+
+type QuadraticResults struct {
+	PollID       int                      `json:"poll_id"`
+	CreditBudget int                      `json:"credit_budget"`
+	Ballots      int                      `json:"ballots"`
+	TotalVotes   int                      `json:"total_votes"`
+	Options      []*QuadraticOptionResult `json:"options"`
+	Voters       []*VoterCredits          `json:"voters"`
+}
+
+// Credits returns the cost of casting the votes.
+func Credits(votes []QuadraticVote) int {
+	credits := 0
+
+	for _, vote := range votes {
+		credits += vote.Votes * vote.Votes
+	}
+
+	return credits
+}
+
+// validateQuadratic checks the credit budget of a poll and the settings of a
+// quadratic poll.
+func (p *Poll) validateQuadratic(v *validator.Validator) {
+	// polls that are not quadratic may leave it at zero for the default
+	v.Check(p.CreditBudget >= 1 && p.CreditBudget <= MaxCreditBudget || !p.IsQuadratic() && p.CreditBudget == 0,
+		"credit_budget", validator.CodeInvalid, fmt.Sprintf("credit_budget must be between 1 and %d", MaxCreditBudget))
+
+	if !p.IsQuadratic() {
+		return
+	}
+
+	v.Check(!p.AllowGuests, "allow_guests", validator.CodeInvalid, "quadratic polls cannot be answered by guests")
+}
+
+// ValidateQuadraticBallot checks that the votes found at field go to options
+// of the poll, each at most once, and stay within the credit budget.
+func ValidateQuadraticBallot(v *validator.Validator, field string, poll *Poll, votes []QuadraticVote) {
+	v.Check(len(votes) > 0, field, validator.CodeRequired, field+" is required")
+
+	ids := make([]string, len(votes))
+
+	for i, vote := range votes {
+		v.Check(poll.OptionByID(vote.OptionID) != nil, validator.Index(field, i, "option_id"), validator.CodeInvalid,
+			"option is not part of this poll")
+		// the upper bound keeps the squares from overflowing
+		v.Check(vote.Votes >= 1 && vote.Votes <= poll.CreditBudget, validator.Index(field, i, "votes"),
+			validator.CodeInvalid, fmt.Sprintf("votes must be between 1 and %d", poll.CreditBudget))
+		ids[i] = strconv.Itoa(vote.OptionID)
+	}
+
+	v.NoDuplicates(ids, nil, func(i int) string {
+		return validator.Index(field, i, "option_id")
+	})
+
+	if !v.Valid() {
+		return
+	}
+
+	credits := Credits(votes)
+	v.Check(credits <= poll.CreditBudget, field, validator.CodeInvalid,
+		fmt.Sprintf("the ballot costs %d credits, over the budget of %d", credits, poll.CreditBudget))
+}
+
+// TallyQuadratic sums the votes of every option of the quadratic poll and the
+// credits used by every voter. Votes on options that were removed since are
+// skipped.
+func (p *Poll) TallyQuadratic(ballots []*QuadraticBallot) *QuadraticResults {
+	results := &QuadraticResults{
+		PollID:       p.ID,
+		CreditBudget: p.CreditBudget,
+		Ballots:      len(ballots),
+		Options:      []*QuadraticOptionResult{},
+		Voters:       []*VoterCredits{},
+	}
+
+	options := map[int]*QuadraticOptionResult{}
+
+	for _, option := range p.Options {
+		result := &QuadraticOptionResult{OptionID: option.ID, Text: option.Text}
+		options[option.ID] = result
+		results.Options = append(results.Options, result)
+	}
+
+	for _, ballot := range ballots {
+		voter := &VoterCredits{UserID: ballot.UserID}
+
+		for _, vote := range ballot.Votes {
+			option, ok := options[vote.OptionID]
+
+			if !ok {
+				continue
+			}
+
+			option.Votes += vote.Votes
+			option.Credits += vote.Votes * vote.Votes
+			option.Voters++
+
+			voter.Votes += vote.Votes
+			voter.Credits += vote.Votes * vote.Votes
+		}
+
+		voter.Remaining = p.CreditBudget - voter.Credits
+		results.TotalVotes += voter.Votes
+		results.Voters = append(results.Voters, voter)
+	}
+
+	sort.Slice(results.Voters, func(i, j int) bool {
+		return results.Voters[i].UserID < results.Voters[j].UserID
+	})
+
+	return results
+}
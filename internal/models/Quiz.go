@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"polling/internal/validator"
+	"sort"
+	"time"
+)
+
+// QuizPoints is the score of a correct answer. Quizzes with a time bonus add
+// up to QuizPoints more for answers given right after the poll opened.
+const QuizPoints = 100
+
+// MaxTimeBonus limits the time bonus window of a quiz poll, in seconds.
+const MaxTimeBonus = 24 * 60 * 60
+
+// LeaderboardEntry holds the score of one user across the questions of a
+// quiz. Users with the same score and number of correct answers share a rank.
+type LeaderboardEntry struct {
+	Rank     int `json:"rank"`
+	UserID   int `json:"user_id"`
+	Score    int `json:"score"`
+	Correct  int `json:"correct"`
+	Answered int `json:"answered"`
+}
+
+type Leaderboard struct {
+	Questions int                 `json:"questions"`
+	Entries   []*LeaderboardEntry `json:"entries"`
+}
+
+// validateQuiz checks the quiz settings of the poll.
+func (p *Poll) validateQuiz(v *validator.Validator) {
+	v.Check(p.TimeBonus >= 0 && p.TimeBonus <= MaxTimeBonus, "time_bonus", validator.CodeInvalid,
+		fmt.Sprintf("time_bonus must be between 0 and %d seconds", MaxTimeBonus))
+
+	for i, option := range p.Options {
+		p.CheckCorrect(v, validator.Index("options", i, "is_correct"), option)
+	}
+
+	if !p.Quiz {
+		v.Check(p.TimeBonus == 0, "time_bonus", validator.CodeInvalid, "time_bonus only applies to quizzes")
+		return
+	}
+
+	v.Check(!p.IsText(), "quiz", validator.CodeInvalid, "text polls cannot be quizzes")
+	v.Check(!p.IsRanked(), "quiz", validator.CodeInvalid, "ranked polls cannot be quizzes")
+	v.Check(!p.IsQuadratic(), "quiz", validator.CodeInvalid, "quadratic polls cannot be quizzes")
+	v.Check(!p.AllowGuests, "allow_guests", validator.CodeInvalid, "quizzes cannot be answered by guests")
+	v.Check(!p.AllowSuggestions, "allow_suggestions", validator.CodeInvalid, "quizzes do not take suggestions")
+}
+
+// CheckCorrect reports an option found at field that is marked as a correct
+// answer of a poll that is not a quiz.
+func (p *Poll) CheckCorrect(v *validator.Validator, field string, option *PollOption) {
+	v.Check(p.Quiz || !option.IsCorrect, field, validator.CodeInvalid, "is_correct only applies to quizzes")
+}
+
+// CorrectOptions returns the options marked as correct answers.
+func (p *Poll) CorrectOptions() []*PollOption {
+	correct := []*PollOption{}
+
+	for _, option := range p.Options {
+		if option.IsCorrect {
+			correct = append(correct, option)
+		}
+	}
+
+	return correct
+}
+
+// IsClosed reports whether the poll has closed at now. Polls without a
+// closing time never close.
+func (p *Poll) IsClosed(now time.Time) bool {
+	return p.ClosesAt != nil && !now.Before(*p.ClosesAt)
+}
+
+// HideAnswerKey removes the correct answer marks from every option, so the
+// answer key of a quiz stays secret until it closes.
+func (p *Poll) HideAnswerKey() {
+	for _, option := range p.Options {
+		option.IsCorrect = false
+	}
+}
+
+// HasVoted reports whether the user has voted on the poll.
+func (p *Poll) HasVoted(userID int) bool {
+	for _, option := range p.Options {
+		for _, vote := range option.Votes {
+			if vote.UserID == userID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Score returns the points earned by choosing option at answeredAt. The time
+// bonus shrinks linearly from QuizPoints to nothing over the TimeBonus seconds
+// after the poll opened.
+func (p *Poll) Score(option *PollOption, answeredAt time.Time) int {
+	if !option.IsCorrect {
+		return 0
+	}
+
+	start := p.OpensAt
+
+	if start == nil {
+		start = p.PublishedAt
+	}
+
+	if p.TimeBonus == 0 || start == nil {
+		return QuizPoints
+	}
+
+	window := time.Duration(p.TimeBonus) * time.Second
+	left := min(max(window-answeredAt.Sub(*start), 0), window)
+
+	return QuizPoints + int(QuizPoints*left/window)
+}
+
+// NewLeaderboard ranks the users who answered any of the quiz polls by their
+// total score, then by the number of correct answers.
+func NewLeaderboard(polls []*Poll) *Leaderboard {
+	scores := map[int]*LeaderboardEntry{}
+
+	for _, poll := range polls {
+		for _, option := range poll.Options {
+			for _, vote := range option.Votes {
+				entry, ok := scores[vote.UserID]
+
+				if !ok {
+					entry = &LeaderboardEntry{UserID: vote.UserID}
+					scores[vote.UserID] = entry
+				}
+
+				entry.Answered++
+				entry.Score += poll.Score(option, vote.CreatedAt)
+
+				if option.IsCorrect {
+					entry.Correct++
+				}
+			}
+		}
+	}
+
+	leaderboard := &Leaderboard{Questions: len(polls), Entries: []*LeaderboardEntry{}}
+
+	for _, entry := range scores {
+		leaderboard.Entries = append(leaderboard.Entries, entry)
+	}
+
+	sort.Slice(leaderboard.Entries, func(i, j int) bool {
+		a, b := leaderboard.Entries[i], leaderboard.Entries[j]
+
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+
+		if a.Correct != b.Correct {
+			return a.Correct > b.Correct
+		}
+
+		return a.UserID < b.UserID
+	})
+
+	for i, entry := range leaderboard.Entries {
+		entry.Rank = i + 1
+
+		if i > 0 {
+			previous := leaderboard.Entries[i-1]
+
+			if previous.Score == entry.Score && previous.Correct == entry.Correct {
+				entry.Rank = previous.Rank
+			}
+		}
+	}
+
+	return leaderboard
+}
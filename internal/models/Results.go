@@ -0,0 +1,61 @@
+package models
+
+// OptionResult holds the vote count of an option. Votes includes guest votes,
+// which are also reported on their own in GuestVotes. Only votes cast against
+// the current wording are counted, OutdatedVotes reports the votes cast
+// against earlier revisions of the option. WeightedVotes sums the weights of
+// the voters of weighted polls.
+type OptionResult struct {
+	OptionID      int    `json:"option_id"`
+	Text          string `json:"text"`
+	Revision      int    `json:"revision"`
+	Votes         int    `json:"votes"`
+	WeightedVotes *int   `json:"weighted_votes,omitempty"`
+	GuestVotes    int    `json:"guest_votes"`
+	OutdatedVotes int    `json:"outdated_votes"`
+}
+
+// PollResults holds the vote counts of a choice poll. Turnout counts the
+// votes that go towards an outcome, those for an option and those for none of
+// the above, while Abstentions are reported on their own. NoneOfTheAbove is
+// only set on polls that offer the choice.
+type PollResults struct {
+	PollID             int             `json:"poll_id"`
+	Weighted           bool            `json:"weighted,omitempty"`
+	TotalVotes         int             `json:"total_votes"`
+	WeightedTotal      *int            `json:"weighted_total,omitempty"`
+	GuestVotes         int             `json:"guest_votes"`
+	Turnout            int             `json:"turnout"`
+	Abstentions        int             `json:"abstentions"`
+	NoneOfTheAbove     *int            `json:"none_of_the_above,omitempty"`
+	NoneOfTheAboveWins bool            `json:"none_of_the_above_wins,omitempty"`
+	Options            []*OptionResult `json:"options"`
+}
+
+// Tally counts the votes of every option of the poll.
+func (p *Poll) Tally() *PollResults {
+	results := &PollResults{
+		PollID:  p.ID,
+		Options: []*OptionResult{},
+	}
+
+	for _, option := range p.Options {
+		current := len(option.CurrentVotes())
+		votes := current + option.GuestVotes
+
+		results.Options = append(results.Options, &OptionResult{
+			OptionID:      option.ID,
+			Text:          option.Text,
+			Revision:      option.Revision,
+			Votes:         votes,
+			GuestVotes:    option.GuestVotes,
+			OutdatedVotes: len(option.Votes) - current + option.OutdatedGuestVotes,
+		})
+		results.TotalVotes += votes
+		results.GuestVotes += option.GuestVotes
+	}
+
+	results.Turnout = results.TotalVotes
+
+	return results
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PollRevision is an immutable snapshot of the title and description of a
+// poll. EditedBy is nil once the editing user has been deleted.
+type PollRevision struct {
+	Revision    int       `json:"revision"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	EditedBy    *int      `json:"edited_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OptionRevision is an immutable snapshot of the wording of an option: its
+// text, description and link.
+type OptionRevision struct {
+	OptionID    int       `json:"option_id"`
+	Revision    int       `json:"revision"`
+	Text        string    `json:"text"`
+	Description string    `json:"description"`
+	LinkURL     string    `json:"link_url"`
+	EditedBy    *int      `json:"edited_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PollHistory lists every revision of a poll and its options, oldest first.
+type PollHistory struct {
+	PollID    int               `json:"poll_id"`
+	Revisions []*PollRevision   `json:"revisions"`
+	Options   []*OptionRevision `json:"options"`
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"polling/internal/validator"
+	"sort"
+	"strconv"
+	"time"
+	_ "time/tzdata" // time zones of slots must load on hosts without a zoneinfo database
+)
+
+// Answers to the slots of a scheduling poll.
+const (
+	AnswerYes      = "yes"
+	AnswerIfNeedBe = "if_need_be"
+	AnswerNo       = "no"
+)
+
+// MaxTimeZoneLength is the length limit of the time_zone column of
+// poll_options.
+const MaxTimeZoneLength = 64
+
+// SlotAnswer is the answer of a voter to one slot of a scheduling poll.
+type SlotAnswer struct {
+	OptionID int    `json:"option_id"`
+	Answer   string `json:"answer"`
+}
+
+// Availability holds the answers of a user to the slots of a scheduling poll.
+// Slots left out were not answered.
+type Availability struct {
+	PollID    int          `json:"poll_id"`
+	UserID    int          `json:"user_id"`
+	Answers   []SlotAnswer `json:"answers"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// SlotResult counts the answers to a slot. Available is the number of voters
+// who can make it, whether they answered yes or if need be.
+type SlotResult struct {
+	OptionID  int       `json:"option_id"`
+	Text      string    `json:"text"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	TimeZone  string    `json:"time_zone"`
+	Yes       int       `json:"yes"`
+	IfNeedBe  int       `json:"if_need_be"`
+	No        int       `json:"no"`
+	Available int       `json:"available"`
+}
+
+// ScheduleResults holds the answers to every slot of a scheduling poll. Best
+// is the slot the most voters can make, FinalOptionID the slot the poll was
+// finalized on.
+type ScheduleResults struct {
+	PollID        int           `json:"poll_id"`
+	Respondents   int           `json:"respondents"`
+	Slots         []*SlotResult `json:"slots"`
+	Best          *int          `json:"best"`
+	FinalOptionID *int          `json:"final_option_id"`
+}
+
+// Meeting is a scheduling poll finalized on one of its slots.
+type Meeting struct {
+	PollID      int       `json:"poll_id"`
+	OptionID    int       `json:"option_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Slot        string    `json:"slot"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	TimeZone    string    `json:"time_zone"`
+	OrganizerID int       `json:"organizer_id"`
+}
+
+// validateSlots checks the settings of a scheduling poll and the time slots
+// of its options.
+func (p *Poll) validateSlots(v *validator.Validator) {
+	for i, option := range p.Options {
+		p.CheckSlot(v, validator.Index("options", i, ""), option)
+	}
+
+	if !p.IsSchedule() {
+		return
+	}
+
+	v.Check(!p.AllowGuests, "allow_guests", validator.CodeInvalid, "scheduling polls cannot be answered by guests")
+	v.Check(!p.AllowSuggestions, "allow_suggestions", validator.CodeInvalid, "scheduling polls do not take suggestions")
+	v.Check(!p.Quiz, "quiz", validator.CodeInvalid, "scheduling polls cannot be quizzes")
+}
+
+// CheckSlot checks the time slot of an option found at parent. Options of
+// scheduling polls need one, options of other polls cannot have one.
+func (p *Poll) CheckSlot(v *validator.Validator, parent string, option *PollOption) {
+	startsAt := validator.Field(parent, "starts_at")
+	endsAt := validator.Field(parent, "ends_at")
+	timeZone := validator.Field(parent, "time_zone")
+
+	if !p.IsSchedule() {
+		v.Check(option.StartsAt == nil, startsAt, validator.CodeInvalid, "starts_at only applies to scheduling polls")
+		v.Check(option.EndsAt == nil, endsAt, validator.CodeInvalid, "ends_at only applies to scheduling polls")
+		v.Check(option.TimeZone == "", timeZone, validator.CodeInvalid, "time_zone only applies to scheduling polls")
+		return
+	}
+
+	v.Check(option.StartsAt != nil, startsAt, validator.CodeRequired, "starts_at is required")
+	v.Check(option.EndsAt != nil, endsAt, validator.CodeRequired, "ends_at is required")
+
+	if option.StartsAt != nil && option.EndsAt != nil {
+		v.Check(option.EndsAt.After(*option.StartsAt), endsAt, validator.CodeInvalid, "ends_at must be after starts_at")
+	}
+
+	v.Required(timeZone, option.TimeZone)
+	v.MaxLength(timeZone, option.TimeZone, MaxTimeZoneLength)
+
+	if option.TimeZone != "" {
+		_, err := time.LoadLocation(option.TimeZone)
+		v.Check(err == nil, timeZone, validator.CodeInvalid, "time_zone must be an IANA time zone such as Europe/Berlin")
+	}
+}
+
+// ValidateAvailability checks that the answers found at field go to slots of
+// the poll, each at most once.
+func ValidateAvailability(v *validator.Validator, field string, poll *Poll, answers []SlotAnswer) {
+	v.Check(len(answers) > 0, field, validator.CodeRequired, field+" is required")
+
+	ids := make([]string, len(answers))
+
+	for i, answer := range answers {
+		v.Check(poll.OptionByID(answer.OptionID) != nil, validator.Index(field, i, "option_id"), validator.CodeInvalid,
+			"option is not part of this poll")
+		v.In(validator.Index(field, i, "answer"), answer.Answer, AnswerYes, AnswerIfNeedBe, AnswerNo)
+		ids[i] = strconv.Itoa(answer.OptionID)
+	}
+
+	v.NoDuplicates(ids, nil, func(i int) string {
+		return validator.Index(field, i, "option_id")
+	})
+}
+
+// LocalSlot returns the start and end of the slot of the option in its own
+// time zone.
+func (o *PollOption) LocalSlot() (time.Time, time.Time) {
+	location, err := time.LoadLocation(o.TimeZone)
+
+	if err != nil {
+		location = time.UTC
+	}
+
+	return o.StartsAt.In(location), o.EndsAt.In(location)
+}
+
+// TallySchedule counts the answers to every slot of the scheduling poll and
+// picks the best slot: the one the most voters can make, then the one with
+// the most yes answers, then the earliest. Answers to slots that were removed
+// since are skipped.
+func (p *Poll) TallySchedule(availabilities []*Availability) *ScheduleResults {
+	results := &ScheduleResults{
+		PollID:        p.ID,
+		Respondents:   len(availabilities),
+		Slots:         []*SlotResult{},
+		FinalOptionID: p.FinalOptionID,
+	}
+
+	slots := map[int]*SlotResult{}
+
+	for _, option := range p.Options {
+		if option.StartsAt == nil || option.EndsAt == nil {
+			continue
+		}
+
+		startsAt, endsAt := option.LocalSlot()
+		slot := &SlotResult{
+			OptionID: option.ID,
+			Text:     option.Text,
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+			TimeZone: option.TimeZone,
+		}
+		slots[option.ID] = slot
+		results.Slots = append(results.Slots, slot)
+	}
+
+	for _, availability := range availabilities {
+		for _, answer := range availability.Answers {
+			slot, ok := slots[answer.OptionID]
+
+			if !ok {
+				continue
+			}
+
+			switch answer.Answer {
+			case AnswerYes:
+				slot.Yes++
+				slot.Available++
+			case AnswerIfNeedBe:
+				slot.IfNeedBe++
+				slot.Available++
+			case AnswerNo:
+				slot.No++
+			}
+		}
+	}
+
+	ranked := make([]*SlotResult, len(results.Slots))
+	copy(ranked, results.Slots)
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Available != ranked[j].Available {
+			return ranked[i].Available > ranked[j].Available
+		}
+
+		if ranked[i].Yes != ranked[j].Yes {
+			return ranked[i].Yes > ranked[j].Yes
+		}
+
+		return ranked[i].StartsAt.Before(ranked[j].StartsAt)
+	})
+
+	if len(ranked) > 0 && ranked[0].Available > 0 {
+		results.Best = &ranked[0].OptionID
+	}
+
+	return results
+}
+
+// FinalOption returns the slot the scheduling poll was finalized on, or nil.
+func (p *Poll) FinalOption() *PollOption {
+	if p.FinalOptionID == nil {
+		return nil
+	}
+
+	return p.OptionByID(*p.FinalOptionID)
+}
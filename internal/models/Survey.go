@@ -0,0 +1,374 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"polling/internal/branching"
+	"polling/internal/validator"
+	"time"
+)
+
+// Survey statuses follow the statuses of polls.
+const (
+	SurveyStatusDraft     = "draft"
+	SurveyStatusPublished = "published"
+)
+
+// Question kinds. Rating questions are polls whose options are the points of
+// a scale, "1" to the size of the scale, so the rating given is the index of
+// the chosen option plus one. Text questions are text polls.
+const (
+	QuestionKindChoice = "choice"
+	QuestionKindRating = "rating"
+	QuestionKindText   = "text"
+)
+
+// MaxSurveyQuestions limits the number of questions of a single survey.
+const MaxSurveyQuestions = 100
+
+// Limits on the size of the scale of rating questions.
+const (
+	MinRatingScale = 2
+	MaxRatingScale = 10
+)
+
+// Survey is an ordered list of questions answered in one submission. Every
+// question is a poll of its own, owned by the owner of the survey. The
+// questions of a quiz are quiz polls, and answers to them cannot be changed.
+type Survey struct {
+	ID          int               `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	UserID      int               `json:"user_id"`
+	Status      string            `json:"status"`
+	Quiz        bool              `json:"quiz"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Questions   []*SurveyQuestion `json:"questions"`
+}
+
+// SurveyQuestion places a poll in a survey. Required questions have to be
+// answered for a response to count as complete, unless the answers to earlier
+// questions hide them. ShowIf and Skip refer to questions by position and to
+// options by their index.
+type SurveyQuestion struct {
+	Position int                   `json:"position"`
+	Kind     string                `json:"kind"`
+	Required bool                  `json:"required"`
+	ShowIf   []branching.Condition `json:"show_if"`
+	Skip     []branching.SkipRule  `json:"skip"`
+	Poll     *Poll                 `json:"poll"`
+}
+
+// SurveyAnswer picks an option of one of the questions of a survey, or gives
+// the text answering a text question.
+type SurveyAnswer struct {
+	PollID   int    `json:"poll_id"`
+	OptionID int    `json:"option_id,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+// SurveyResponse tracks the submissions of a user to a survey. A response
+// stays partial until every required question shown to the user has been
+// answered.
+type SurveyResponse struct {
+	SurveyID  int       `json:"survey_id"`
+	UserID    int       `json:"user_id"`
+	Completed bool      `json:"completed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SurveyProgress tells where a response stands. Hidden lists the polls of
+// answered questions that the other answers now hide.
+type SurveyProgress struct {
+	Completed bool            `json:"completed"`
+	Next      *SurveyQuestion `json:"next"`
+	Hidden    []int           `json:"-"`
+}
+
+// SurveyResponseStats counts the responses to a survey.
+type SurveyResponseStats struct {
+	Responses int `json:"responses"`
+	Completed int `json:"completed"`
+}
+
+// QuestionResults holds the results of one question of a survey. Answers
+// counts the users who answered the question, Average is the mean rating
+// given to rating questions.
+type QuestionResults struct {
+	Position int          `json:"position"`
+	Kind     string       `json:"kind"`
+	Title    string       `json:"title"`
+	Required bool         `json:"required"`
+	Answers  int          `json:"answers"`
+	Average  float64      `json:"average,omitempty"`
+	Results  *PollResults `json:"results"`
+}
+
+type SurveyResults struct {
+	SurveyID       int                `json:"survey_id"`
+	Responses      int                `json:"responses"`
+	Completed      int                `json:"completed"`
+	Partial        int                `json:"partial"`
+	CompletionRate float64            `json:"completion_rate"`
+	Questions      []*QuestionResults `json:"questions"`
+}
+
+func (s *Survey) IsPublished() bool {
+	return s.Status == SurveyStatusPublished
+}
+
+// Validate checks the survey fields and every question.
+func (s *Survey) Validate(v *validator.Validator) {
+	v.Required("title", s.Title)
+	v.MaxLength("title", s.Title, MaxTitleLength)
+
+	v.Check(len(s.Questions) > 0, "questions", validator.CodeRequired, "questions is required")
+	v.Check(len(s.Questions) <= MaxSurveyQuestions, "questions", validator.CodeInvalid, "a survey has too many questions")
+
+	for i, question := range s.Questions {
+		// questions report their problems under their own path
+		qv := validator.New()
+		question.Poll.Validate(qv)
+		qv.In("kind", question.Kind, QuestionKindChoice, QuestionKindRating, QuestionKindText)
+		qv.Check(question.Poll.IsText() == (question.Kind == QuestionKindText), "kind", validator.CodeInvalid,
+			"text questions are text polls")
+
+		if question.Kind == QuestionKindRating {
+			scale := len(question.Poll.Options)
+			qv.Check(scale >= MinRatingScale && scale <= MaxRatingScale, "scale", validator.CodeInvalid,
+				fmt.Sprintf("scale must be between %d and %d", MinRatingScale, MaxRatingScale))
+			qv.Check(!question.Poll.RandomizeOptions, "randomize_options", validator.CodeInvalid,
+				"the scale of a rating question cannot be shuffled")
+		}
+
+		if s.Quiz {
+			// locked answers cannot be dropped when a later answer hides them
+			qv.Check(len(question.ShowIf) == 0, "show_if", validator.CodeInvalid, "quiz questions cannot branch")
+			qv.Check(len(question.Skip) == 0, "skip", validator.CodeInvalid, "quiz questions cannot branch")
+			qv.Check(question.Kind == QuestionKindChoice, "kind", validator.CodeInvalid, "quiz questions are choice questions")
+		}
+
+		for _, e := range qv.Errors {
+			v.AddError(validator.Index("questions", i, e.Field), e.Code, e.Message)
+		}
+	}
+
+	for _, problem := range s.Flow().Validate() {
+		v.AddError(validator.Index("questions", problem.Index, problem.Field), validator.CodeInvalid, problem.Message)
+	}
+}
+
+// Flow returns the branching rules of the survey.
+func (s *Survey) Flow() branching.Flow {
+	flow := branching.Flow{}
+
+	for _, question := range s.Questions {
+		flow = append(flow, branching.Question{
+			Position: question.Position,
+			Rating:   question.Kind == QuestionKindRating,
+			Choices:  len(question.Poll.Options),
+			ShowIf:   question.ShowIf,
+			Skip:     question.Skip,
+		})
+	}
+
+	return flow
+}
+
+// branchingAnswers translates answers into the positions and option indexes
+// the branching rules work with. Answers that match no option are left out,
+// text answers have no option and count as -1.
+func (s *Survey) branchingAnswers(answers []SurveyAnswer) branching.Answers {
+	indexes := branching.Answers{}
+
+	for _, answer := range answers {
+		question := s.Question(answer.PollID)
+
+		if question == nil {
+			continue
+		}
+
+		if question.Poll.IsText() {
+			if answer.Text != "" {
+				indexes[question.Position] = -1
+			}
+
+			continue
+		}
+
+		for i, option := range question.Poll.Options {
+			if option.ID == answer.OptionID {
+				indexes[question.Position] = i
+			}
+		}
+	}
+
+	return indexes
+}
+
+// Progress works out where a response with answers stands: whether every
+// required question that is shown has been answered, which shown question
+// comes next, and which answers belong to questions that are now hidden.
+func (s *Survey) Progress(answers []SurveyAnswer) *SurveyProgress {
+	indexes := s.branchingAnswers(answers)
+	flow := s.Flow()
+	visible := map[int]bool{}
+
+	for _, position := range flow.Visible(indexes) {
+		visible[position] = true
+	}
+
+	progress := &SurveyProgress{Completed: true, Hidden: []int{}}
+
+	for _, question := range s.Questions {
+		_, answered := indexes[question.Position]
+
+		if answered && !visible[question.Position] {
+			progress.Hidden = append(progress.Hidden, question.Poll.ID)
+		}
+
+		if question.Required && visible[question.Position] && !answered {
+			progress.Completed = false
+		}
+	}
+
+	if position, ok := flow.Next(indexes); ok {
+		for _, question := range s.Questions {
+			if question.Position == position {
+				progress.Next = question
+			}
+		}
+	}
+
+	return progress
+}
+
+// CheckPublishable reports why the survey is not ready to be published at
+// now, or nil when it is.
+func (s *Survey) CheckPublishable(now time.Time) error {
+	if s.IsPublished() {
+		return errors.New("survey is already published")
+	}
+
+	for _, question := range s.Questions {
+		err := question.Poll.CheckPublishable(now)
+
+		if err != nil {
+			return errors.New(question.Poll.Title + ": " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// IsClosed reports whether every question of the survey has closed at now.
+func (s *Survey) IsClosed(now time.Time) bool {
+	for _, question := range s.Questions {
+		if !question.Poll.IsClosed(now) {
+			return false
+		}
+	}
+
+	return len(s.Questions) > 0
+}
+
+// Leaderboard ranks the users who answered the questions of the quiz.
+func (s *Survey) Leaderboard() *Leaderboard {
+	polls := []*Poll{}
+
+	for _, question := range s.Questions {
+		polls = append(polls, question.Poll)
+	}
+
+	return NewLeaderboard(polls)
+}
+
+// Question returns the question asked by the poll with the ID, or nil.
+func (s *Survey) Question(pollID int) *SurveyQuestion {
+	for _, question := range s.Questions {
+		if question.Poll.ID == pollID {
+			return question
+		}
+	}
+
+	return nil
+}
+
+// CheckAnswers validates a submission against the questions of the survey.
+// Every answer has to pick an option of a different question, or give the
+// text of a text question.
+func (s *Survey) CheckAnswers(v *validator.Validator, answers []SurveyAnswer) {
+	v.Check(len(answers) > 0, "answers", validator.CodeRequired, "answers is required")
+
+	answered := map[int]bool{}
+
+	for i, answer := range answers {
+		question := s.Question(answer.PollID)
+
+		if question == nil {
+			v.AddError(validator.Index("answers", i, "poll_id"), validator.CodeInvalid, "poll_id is not a question of this survey")
+			continue
+		}
+
+		if answered[answer.PollID] {
+			v.AddError(validator.Index("answers", i, "poll_id"), validator.CodeDuplicate, "the question is answered more than once")
+		}
+
+		answered[answer.PollID] = true
+
+		if question.Poll.IsText() {
+			ValidateTextAnswer(v, validator.Index("answers", i, "text"), question.Poll, answer.Text)
+			continue
+		}
+
+		if question.Poll.OptionByID(answer.OptionID) == nil {
+			v.AddError(validator.Index("answers", i, "option_id"), validator.CodeInvalid, "option_id is not an option of the question")
+		}
+	}
+}
+
+// Results combines the tallies of the questions with the response counts.
+func (s *Survey) Results(stats SurveyResponseStats) *SurveyResults {
+	results := &SurveyResults{
+		SurveyID:  s.ID,
+		Responses: stats.Responses,
+		Completed: stats.Completed,
+		Partial:   stats.Responses - stats.Completed,
+		Questions: []*QuestionResults{},
+	}
+
+	if stats.Responses > 0 {
+		results.CompletionRate = float64(stats.Completed) / float64(stats.Responses)
+	}
+
+	for _, question := range s.Questions {
+		tally := question.Poll.Tally()
+
+		questionResults := &QuestionResults{
+			Position: question.Position,
+			Kind:     question.Kind,
+			Title:    question.Poll.Title,
+			Required: question.Required,
+			Answers:  tally.TotalVotes,
+			Results:  tally,
+		}
+
+		if question.Poll.IsText() {
+			questionResults.Answers = question.Poll.TextAnswers
+		}
+
+		if question.Kind == QuestionKindRating && tally.TotalVotes > 0 {
+			sum := 0
+
+			for i, option := range tally.Options {
+				sum += (i + 1) * option.Votes
+			}
+
+			questionResults.Average = float64(sum) / float64(tally.TotalVotes)
+		}
+
+		results.Questions = append(results.Questions, questionResults)
+	}
+
+	return results
+}
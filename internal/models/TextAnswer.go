@@ -0,0 +1,65 @@
+package models
+
+import (
+	"polling/internal/validator"
+	"polling/internal/wordfreq"
+	"time"
+)
+
+// Page size limits of text answer listings.
+const (
+	DefaultTextAnswerPageSize = 20
+	MaxTextAnswerPageSize     = 100
+)
+
+// Limits on the number of words in a text summary.
+const (
+	DefaultSummaryWords = 20
+	MaxSummaryWords     = 100
+)
+
+// TextAnswer is the answer of a user to a text poll. Hidden answers are kept
+// out of summaries, flagged ones are marked for a closer look.
+type TextAnswer struct {
+	ID        int       `json:"id"`
+	PollID    int       `json:"poll_id"`
+	UserID    int       `json:"user_id"`
+	Text      string    `json:"text"`
+	Hidden    bool      `json:"hidden"`
+	Flagged   bool      `json:"flagged"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TextAnswerFilter narrows down the answers of a text poll. Search matches
+// answers containing the text, nil fields match everything. Page starts at 1.
+type TextAnswerFilter struct {
+	Search   string
+	Hidden   *bool
+	Flagged  *bool
+	Page     int
+	PageSize int
+}
+
+// TextAnswerPage is one page of the answers of a text poll. Total counts the
+// answers on every page.
+type TextAnswerPage struct {
+	Answers  []*TextAnswer `json:"answers"`
+	Total    int           `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// TextSummary lists the words used most often in the visible answers of a
+// text poll.
+type TextSummary struct {
+	PollID  int             `json:"poll_id"`
+	Answers int             `json:"answers"`
+	Words   []wordfreq.Word `json:"words"`
+}
+
+// ValidateTextAnswer checks an answer to the text poll found at field.
+func ValidateTextAnswer(v *validator.Validator, field string, poll *Poll, text string) {
+	v.Required(field, text)
+	v.MaxLength(field, text, poll.MaxAnswerLength)
+}
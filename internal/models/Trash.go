@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// DeletedOption is an option in the trash of a poll that is still live.
+type DeletedOption struct {
+	PollID    int       `json:"poll_id"`
+	OptionID  int       `json:"option_id"`
+	Text      string    `json:"text"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Trash lists the deleted polls and options of a user.
+type Trash struct {
+	Polls   []*Poll          `json:"polls"`
+	Options []*DeletedOption `json:"options"`
+}
@@ -1,11 +1,22 @@
 package models
 
 import (
+	"polling/internal/validator"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Bounds of usernames, first and last names and passwords. The names match the
+// columns of the users table.
+const (
+	MaxUsernameLength = 50
+	MinUsernameLength = 3
+	MaxNameLength     = 50
+	// bcrypt ignores everything past 72 bytes
+	MaxPasswordBytes = 72
+)
+
 type User struct {
 	ID        int       `json:"id"`
 	Username  string    `json:"username"`
@@ -31,3 +42,21 @@ func (u *User) CheckPassword(plainText string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(plainText))
 	return err == nil
 }
+
+// Validate checks the user fields. The plain text password is passed
+// separately since the struct only holds its hash.
+func (u *User) Validate(v *validator.Validator, password string) {
+	v.Required("username", u.Username)
+	v.MinLength("username", u.Username, MinUsernameLength)
+	v.MaxLength("username", u.Username, MaxUsernameLength)
+	v.Username("username", u.Username)
+
+	v.Required("password", password)
+	v.MaxBytes("password", password, MaxPasswordBytes)
+
+	v.Required("first_name", u.FirstName)
+	v.MaxLength("first_name", u.FirstName, MaxNameLength)
+
+	v.Required("last_name", u.LastName)
+	v.MaxLength("last_name", u.LastName, MaxNameLength)
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"polling/internal/validator"
+	"time"
+)
+
+// Limits on voter weights and on the size of the allowlist of a weighted
+// poll.
+const (
+	MaxVoterWeight  = 1_000_000_000
+	MaxVoterWeights = 10000
+)
+
+// VoterWeight is the weight, such as a share count, of a user allowed to vote
+// on a weighted poll.
+type VoterWeight struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Weight   int    `json:"weight"`
+}
+
+// validateWeighted checks the settings of a weighted poll.
+func (p *Poll) validateWeighted(v *validator.Validator) {
+	if !p.Weighted {
+		return
+	}
+
+	v.Check(p.Kind == "" || p.Kind == PollKindChoice, "weighted", validator.CodeInvalid, "only choice polls can be weighted")
+	v.Check(!p.AllowGuests, "allow_guests", validator.CodeInvalid, "weighted polls cannot be answered by guests")
+	v.Check(!p.Quiz, "quiz", validator.CodeInvalid, "quizzes cannot be weighted")
+}
+
+// ValidateVoterWeight checks a weight found at field.
+func ValidateVoterWeight(v *validator.Validator, field string, weight int) {
+	v.Check(weight >= 1 && weight <= MaxVoterWeight, field, validator.CodeInvalid,
+		fmt.Sprintf("weight must be between 1 and %d", MaxVoterWeight))
+}
+
+// WeightsFrozen reports whether the voter weights of the poll can no longer
+// change at now. They freeze when the poll opens, so the weights votes are
+// counted with stay the same while voting goes on.
+func (p *Poll) WeightsFrozen(now time.Time) bool {
+	return p.IsPublished() && (p.OpensAt == nil || !now.Before(*p.OpensAt))
+}
+
+// TallyWeighted counts the votes of every option of the weighted poll, both
+// one per voter and by the weights of the voters.
+func (p *Poll) TallyWeighted(weights []*VoterWeight) *PollResults {
+	byUser := map[int]int{}
+
+	for _, weight := range weights {
+		byUser[weight.UserID] = weight.Weight
+	}
+
+	results := p.Tally()
+	results.Weighted = true
+	total := 0
+
+	for i, option := range p.Options {
+		weighted := 0
+
+		for _, vote := range option.CurrentVotes() {
+			weighted += byUser[vote.UserID]
+		}
+
+		results.Options[i].WeightedVotes = &weighted
+		total += weighted
+	}
+
+	results.WeightedTotal = &total
+
+	return results
+}
@@ -0,0 +1,191 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+	"strings"
+)
+
+// SaveTextAnswer stores the answer of the user to a text poll, replacing an
+// earlier answer. Moderation flags stay as they were.
+func (m *DBRepo) SaveTextAnswer(pollID int, userID int, text string) (*models.TextAnswer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	return upsertTextAnswer(ctx, m.DB, pollID, userID, text)
+}
+
+func upsertTextAnswer(ctx context.Context, q querier, pollID int, userID int, text string) (*models.TextAnswer, error) {
+	query := `
+		INSERT INTO text_answers (poll_id, user_id, body)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (poll_id, user_id) DO UPDATE
+		SET body = EXCLUDED.body, updated_at = CURRENT_TIMESTAMP
+		RETURNING ` + textAnswerColumns
+
+	return scanTextAnswer(q.QueryRowContext(ctx, query, pollID, userID, text))
+}
+
+// textAnswerColumns lists the columns read by scanTextAnswer, in scan order.
+const textAnswerColumns = `id, poll_id, user_id, body, hidden, flagged, created_at, updated_at`
+
+func scanTextAnswer(row scanner) (*models.TextAnswer, error) {
+	var answer models.TextAnswer
+
+	err := row.Scan(
+		&answer.ID,
+		&answer.PollID,
+		&answer.UserID,
+		&answer.Text,
+		&answer.Hidden,
+		&answer.Flagged,
+		&answer.CreatedAt,
+		&answer.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &answer, nil
+}
+
+// GetTextAnswers returns a page of the answers to a text poll, newest first.
+func (m *DBRepo) GetTextAnswers(pollID int, filter models.TextAnswerFilter) (*models.TextAnswerPage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	page := &models.TextAnswerPage{
+		Answers:  []*models.TextAnswer{},
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}
+
+	// the search is matched literally, so LIKE wildcards are escaped
+	search := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(filter.Search)
+
+	where := `
+		WHERE poll_id = $1
+		AND ($2 = '' OR body ILIKE '%' || $2 || '%')
+		AND ($3::BOOLEAN IS NULL OR hidden = $3)
+		AND ($4::BOOLEAN IS NULL OR flagged = $4)
+	`
+
+	err := m.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM text_answers`+where, pollID, search, filter.Hidden,
+		filter.Flagged).Scan(&page.Total)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	query := `
+		SELECT ` + textAnswerColumns + `
+		FROM text_answers` + where + `
+		ORDER BY id DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID, search, filter.Hidden, filter.Flagged, filter.PageSize,
+		(filter.Page-1)*filter.PageSize)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		answer, err := scanTextAnswer(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		page.Answers = append(page.Answers, answer)
+	}
+
+	return page, dbError(rows.Err())
+}
+
+// GetVisibleTextAnswers returns the texts of the answers to a text poll that
+// have not been hidden.
+func (m *DBRepo) GetVisibleTextAnswers(pollID int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT body
+		FROM text_answers
+		WHERE poll_id = $1 AND NOT hidden
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	texts := []string{}
+
+	for rows.Next() {
+		var text string
+
+		err := rows.Scan(&text)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		texts = append(texts, text)
+	}
+
+	return texts, dbError(rows.Err())
+}
+
+// ModerateTextAnswer hides or flags an answer to a text poll. Nil leaves a
+// flag as it is.
+func (m *DBRepo) ModerateTextAnswer(pollID int, answerID int, hidden *bool, flagged *bool) (*models.TextAnswer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE text_answers
+		SET hidden = COALESCE($3, hidden), flagged = COALESCE($4, flagged)
+		WHERE id = $1 AND poll_id = $2
+		RETURNING ` + textAnswerColumns
+
+	answer, err := scanTextAnswer(m.DB.QueryRowContext(ctx, query, answerID, pollID, hidden, flagged))
+
+	if err != nil {
+		return nil, notFound(err, "answer_not_found", "answer not found")
+	}
+
+	return answer, nil
+}
+
+func countTextAnswers(ctx context.Context, q querier, pollID int) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM text_answers
+		WHERE poll_id = $1
+	`
+
+	var count int
+
+	err := q.QueryRowContext(ctx, query, pollID).Scan(&count)
+
+	return count, dbError(err)
+}
+
+// deleteTextAnswer removes the answer of the user to a text poll, if any.
+func deleteTextAnswer(ctx context.Context, q querier, pollID int, userID int) error {
+	query := `
+		DELETE FROM text_answers
+		WHERE poll_id = $1 AND user_id = $2
+	`
+
+	_, err := q.ExecContext(ctx, query, pollID, userID)
+	return dbError(err)
+}
@@ -0,0 +1,126 @@
+package dbrepo
+
+import (
+	"context"
+	"encoding/json"
+	"polling/internal/models"
+)
+
+// defaultAuditLimit caps the number of events returned when the filter does
+// not set a limit.
+const defaultAuditLimit = 100
+
+func (m *DBRepo) RecordAuditEvent(event models.AuditEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO audit_events (actor_id, action, target_type, target_id, poll_id, before, after, ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := m.DB.ExecContext(ctx, query,
+		event.ActorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.PollID,
+		nullJSON(event.Before),
+		nullJSON(event.After),
+		event.IP,
+		event.RequestID,
+	)
+
+	return dbError(err)
+}
+
+// GetAuditEvents returns the events matching the filter, newest first.
+func (m *DBRepo) GetAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	limit := filter.Limit
+
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+
+	events := []*models.AuditEvent{}
+
+	query := `
+		SELECT id, actor_id, action, target_type, target_id, poll_id, before, after, ip, request_id, created_at
+		FROM audit_events
+		WHERE ($1::INT IS NULL OR poll_id = $1)
+		AND ($2::INT IS NULL OR actor_id = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, filter.PollID, filter.ActorID, limit)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.AuditEvent
+		var before, after []byte
+
+		err := rows.Scan(
+			&event.ID,
+			&event.ActorID,
+			&event.Action,
+			&event.TargetType,
+			&event.TargetID,
+			&event.PollID,
+			&before,
+			&after,
+			&event.IP,
+			&event.RequestID,
+			&event.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		event.Before = before
+		event.After = after
+
+		events = append(events, &event)
+	}
+
+	return events, dbError(rows.Err())
+}
+
+func (m *DBRepo) IsAdmin(userID int) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT is_admin
+		FROM users
+		WHERE id = $1
+	`
+
+	var isAdmin bool
+
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&isAdmin)
+
+	if err != nil {
+		return false
+	}
+
+	return isAdmin
+}
+
+// nullJSON passes empty JSON values to the database as NULL.
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	return string(raw)
+}
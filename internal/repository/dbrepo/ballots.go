@@ -0,0 +1,107 @@
+package dbrepo
+
+import (
+	"context"
+	"encoding/json"
+	"polling/internal/models"
+)
+
+// SaveBallot stores the ballot of the user on a ranked poll, replacing an
+// earlier ballot.
+func (m *DBRepo) SaveBallot(pollID int, userID int, ranking []int) (*models.Ballot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	encoded, err := json.Marshal(ranking)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO ballots (poll_id, user_id, ranking)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (poll_id, user_id) DO UPDATE
+		SET ranking = EXCLUDED.ranking, updated_at = CURRENT_TIMESTAMP
+		RETURNING ` + ballotColumns
+
+	ballot, err := scanBallot(tx.QueryRowContext(ctx, query, pollID, userID, encoded))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ballot, dbError(tx.Commit())
+}
+
+// ballotColumns lists the columns read by scanBallot, in scan order.
+const ballotColumns = `id, poll_id, user_id, ranking, created_at, updated_at`
+
+func scanBallot(row scanner) (*models.Ballot, error) {
+	var ballot models.Ballot
+	var ranking []byte
+
+	err := row.Scan(
+		&ballot.ID,
+		&ballot.PollID,
+		&ballot.UserID,
+		&ranking,
+		&ballot.CreatedAt,
+		&ballot.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	err = json.Unmarshal(ranking, &ballot.Ranking)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ballot, nil
+}
+
+// GetBallots returns every ballot cast on a ranked poll, oldest first.
+func (m *DBRepo) GetBallots(pollID int) ([]*models.Ballot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + ballotColumns + `
+		FROM ballots
+		WHERE poll_id = $1
+		ORDER BY id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	ballots := []*models.Ballot{}
+
+	for rows.Next() {
+		ballot, err := scanBallot(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ballots = append(ballots, ballot)
+	}
+
+	return ballots, dbError(rows.Err())
+}
@@ -0,0 +1,168 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+	"polling/internal/repository"
+)
+
+// SaveBlankVote records that the user abstains on a choice poll or votes for
+// none of the above, dropping any votes they cast for its options.
+func (m *DBRepo) SaveBlankVote(pollID int, userID int, choice string) (*models.BlankVote, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM votes
+		WHERE user_id = $2 AND option_id IN (SELECT id FROM poll_options WHERE poll_id = $1)
+	`
+
+	_, err = tx.ExecContext(ctx, query, pollID, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	vote := &models.BlankVote{PollID: pollID, UserID: userID, Choice: choice}
+
+	query = `
+		INSERT INTO blank_votes (poll_id, user_id, choice)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (poll_id, user_id) DO UPDATE
+		SET choice = EXCLUDED.choice, created_at = CURRENT_TIMESTAMP
+		RETURNING created_at
+	`
+
+	err = tx.QueryRowContext(ctx, query, pollID, userID, choice).Scan(&vote.CreatedAt)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return vote, dbError(tx.Commit())
+}
+
+// RemoveBlankVote withdraws the blank vote of the user on a choice poll. Blank
+// votes cast before the poll was reopened can no longer be withdrawn.
+func (m *DBRepo) RemoveBlankVote(pollID int, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM blank_votes b
+		USING polls p
+		WHERE b.poll_id = $1 AND b.user_id = $2 AND p.id = b.poll_id AND b.created_at >= p.published_at
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, pollID, userID)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return checkVersion(result, repository.NotFound("blank_vote_not_found", "you have not cast a blank vote on this poll"))
+}
+
+// GetBlankVotes returns the blank votes cast on a choice poll since it was
+// last published, ordered by user.
+func (m *DBRepo) GetBlankVotes(pollID int) ([]*models.BlankVote, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT b.poll_id, b.user_id, b.choice, b.created_at
+		FROM blank_votes b
+		JOIN polls p ON p.id = b.poll_id
+		WHERE b.poll_id = $1 AND b.created_at >= p.published_at
+		ORDER BY b.user_id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	votes := []*models.BlankVote{}
+
+	for rows.Next() {
+		var vote models.BlankVote
+
+		err := rows.Scan(&vote.PollID, &vote.UserID, &vote.Choice, &vote.CreatedAt)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		votes = append(votes, &vote)
+	}
+
+	return votes, dbError(rows.Err())
+}
+
+// ReopenPoll turns a closed poll that is still at version back into a draft
+// without a schedule or an outcome, so it can be run again with other options.
+// The votes cast on it are kept: every option gets a new revision, recorded as
+// edited by editorID, which leaves the earlier votes outdated, and blank votes
+// older than the next publication no longer count.
+func (m *DBRepo) ReopenPoll(pollID int, version int, editorID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		UPDATE polls
+		SET status = 'draft', published_at = NULL, opens_at = NULL, closes_at = NULL,
+			outcome = '', outcome_option_id = NULL, decided_at = NULL, version = version + 1
+		WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, pollID, version)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	err = checkVersion(result, errPollVersionMismatch)
+
+	if err != nil {
+		return err
+	}
+
+	query = `
+		WITH updated AS (
+			UPDATE poll_options
+			SET revision = revision + 1, version = version + 1
+			WHERE poll_id = $1 AND deleted_at IS NULL
+			RETURNING id, revision, option_text, description, link_url
+		)
+		INSERT INTO option_revisions (option_id, revision, option_text, description, link_url, edited_by)
+		SELECT id, revision, option_text, description, link_url, $2 FROM updated
+		ON CONFLICT (option_id, revision) DO NOTHING
+	`
+
+	_, err = tx.ExecContext(ctx, query, pollID, editorID)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return dbError(tx.Commit())
+}
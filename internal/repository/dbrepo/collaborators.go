@@ -0,0 +1,101 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+)
+
+// AddPollCollaborator invites the user to the poll, or changes the role of an
+// existing collaborator.
+func (m *DBRepo) AddPollCollaborator(pollID int, userID int, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO poll_collaborators (poll_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (poll_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, pollID, userID, role)
+	return dbError(err)
+}
+
+func (m *DBRepo) GetPollCollaborators(pollID int) ([]*models.PollCollaborator, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	collaborators := []*models.PollCollaborator{}
+
+	query := `
+		SELECT pc.poll_id, pc.user_id, u.username, u.first_name, u.last_name, pc.role
+		FROM poll_collaborators pc
+		JOIN users u ON u.id = pc.user_id
+		WHERE pc.poll_id = $1
+		ORDER BY u.username
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var collaborator models.PollCollaborator
+		err := rows.Scan(
+			&collaborator.PollID,
+			&collaborator.UserID,
+			&collaborator.Username,
+			&collaborator.FirstName,
+			&collaborator.LastName,
+			&collaborator.Role,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		collaborators = append(collaborators, &collaborator)
+	}
+
+	return collaborators, dbError(rows.Err())
+}
+
+// GetPollCollaboratorRole returns the collaborator role of the user on the
+// poll, or an empty string when the user is not a collaborator.
+func (m *DBRepo) GetPollCollaboratorRole(pollID int, userID int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT role
+		FROM poll_collaborators
+		WHERE poll_id = $1 AND user_id = $2
+	`
+
+	var role string
+
+	err := m.DB.QueryRowContext(ctx, query, pollID, userID).Scan(&role)
+
+	if err != nil {
+		return ""
+	}
+
+	return role
+}
+
+func (m *DBRepo) RemovePollCollaborator(pollID int, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM poll_collaborators
+		WHERE poll_id = $1 AND user_id = $2
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, pollID, userID)
+	return dbError(err)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"polling/internal/models"
+	"polling/internal/repository"
 	"strings"
 	"time"
 )
@@ -15,25 +16,90 @@ type DBRepo struct {
 
 const dbTimeout = time.Second * 3
 
+// pollColumns lists the columns read by scanPoll, in scan order.
+const pollColumns = `id, title, description, user_id, organization_id, members_only, private_results, allow_guests,
+	randomize_options, allow_suggestions, suggestion_limit, kind, max_answer_length, quiz, time_bonus, credit_budget,
+	weighted, none_of_the_above, quorum, quorum_percent, threshold, outcome, outcome_option_id, decided_at,
+	final_option_id, status, opens_at, closes_at, published_at, revision, version, deleted_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanPoll(row scanner) (*models.Poll, error) {
+	var poll models.Poll
+
+	err := row.Scan(
+		&poll.ID,
+		&poll.Title,
+		&poll.Description,
+		&poll.UserID,
+		&poll.OrganizationID,
+		&poll.MembersOnly,
+		&poll.PrivateResults,
+		&poll.AllowGuests,
+		&poll.RandomizeOptions,
+		&poll.AllowSuggestions,
+		&poll.SuggestionLimit,
+		&poll.Kind,
+		&poll.MaxAnswerLength,
+		&poll.Quiz,
+		&poll.TimeBonus,
+		&poll.CreditBudget,
+		&poll.Weighted,
+		&poll.NoneOfTheAbove,
+		&poll.Quorum,
+		&poll.QuorumPercent,
+		&poll.Threshold,
+		&poll.Outcome,
+		&poll.OutcomeOptionID,
+		&poll.DecidedAt,
+		&poll.FinalOptionID,
+		&poll.Status,
+		&poll.OpensAt,
+		&poll.ClosesAt,
+		&poll.PublishedAt,
+		&poll.Revision,
+		&poll.Version,
+		&poll.DeletedAt,
+	)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &poll, nil
+}
+
 func (m *DBRepo) Connection() *sql.DB {
 	return m.DB
 }
 
-func (m *DBRepo) CreateUser(data models.User) error {
+func (m *DBRepo) CreateUser(data models.User) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
 	query := `
 		INSERT INTO users ( username, password, first_name, last_name, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
 
-	_, err := m.DB.ExecContext(ctx, query, data.Username, data.Password, data.FirstName, data.LastName, data.CreatedAt, data.UpdatedAt)
+	var id int
+
+	err := m.DB.QueryRowContext(ctx, query, data.Username, data.Password, data.FirstName, data.LastName, data.CreatedAt, data.UpdatedAt).Scan(&id)
 
 	if err != nil {
-		return err
+		return 0, dbError(err)
 	}
 
-	return nil
+	return id, nil
 }
 
 func (m *DBRepo) GetUserByUsername(username string) (*models.User, error) {
@@ -60,13 +126,51 @@ func (m *DBRepo) GetUserByUsername(username string) (*models.User, error) {
 		&user.UpdatedAt)
 
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "user_not_found", "user not found")
 	}
 
 	return &user, nil
 
 }
 
+// GetUserIDsByUsernames maps the usernames that belong to a user to the ID of
+// that user. Unknown usernames are left out.
+func (m *DBRepo) GetUserIDsByUsernames(usernames []string) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, username
+		FROM users
+		WHERE username = ANY($1)
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, usernames)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	ids := map[string]int{}
+
+	for rows.Next() {
+		var id int
+		var username string
+
+		err := rows.Scan(&id, &username)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		ids[username] = id
+	}
+
+	return ids, dbError(rows.Err())
+}
+
 func (m *DBRepo) GetPollOptions(id int) ([]*models.PollOption, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
@@ -74,15 +178,19 @@ func (m *DBRepo) GetPollOptions(id int) ([]*models.PollOption, error) {
 	var options []*models.PollOption
 
 	query := `
-		SELECT id, option_text
-		FROM poll_options
-		WHERE poll_id = $1
+		SELECT o.id, o.option_text, o.description, o.link_url, COALESCE(o.image_key, ''), COALESCE(o.thumbnail_key, ''),
+			o.position, o.status, o.suggested_by, o.is_correct, o.starts_at, o.ends_at, o.time_zone, o.revision, o.version,
+			(SELECT COUNT(*) FROM guest_votes gv WHERE gv.option_id = o.id AND gv.option_revision = o.revision),
+			(SELECT COUNT(*) FROM guest_votes gv WHERE gv.option_id = o.id AND gv.option_revision <> o.revision)
+		FROM poll_options o
+		WHERE o.poll_id = $1 AND o.status = 'approved' AND o.deleted_at IS NULL
+		ORDER BY o.position, o.id
 	`
 
 	rows, err := m.DB.QueryContext(ctx, query, id)
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	defer rows.Close()
@@ -92,16 +200,31 @@ func (m *DBRepo) GetPollOptions(id int) ([]*models.PollOption, error) {
 		err := rows.Scan(
 			&opt.ID,
 			&opt.Text,
+			&opt.Description,
+			&opt.LinkURL,
+			&opt.ImageKey,
+			&opt.ThumbnailKey,
+			&opt.Position,
+			&opt.Status,
+			&opt.SuggestedBy,
+			&opt.IsCorrect,
+			&opt.StartsAt,
+			&opt.EndsAt,
+			&opt.TimeZone,
+			&opt.Revision,
+			&opt.Version,
+			&opt.GuestVotes,
+			&opt.OutdatedGuestVotes,
 		)
 
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		votes, err := m.GetOptionVotes(opt.ID)
 
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		opt.Votes = votes
@@ -120,40 +243,35 @@ func (m *DBRepo) GetAllPolls() ([]*models.Poll, error) {
 	var polls []*models.Poll
 
 	query := `
-		SELECT id, title, description, user_id
+		SELECT ` + pollColumns + `
 		FROM polls
+		WHERE deleted_at IS NULL
 	`
 
 	rows, err := m.DB.QueryContext(ctx, query)
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
-		var poll models.Poll
-		err := rows.Scan(
-			&poll.ID,
-			&poll.Title,
-			&poll.Description,
-			&poll.UserID,
-		)
+		poll, err := scanPoll(rows)
 
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		options, err := m.GetPollOptions(poll.ID)
 
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		poll.Options = options
 
-		polls = append(polls, &poll)
+		polls = append(polls, poll)
 
 	}
 
@@ -165,46 +283,179 @@ func (m *DBRepo) CreatePoll(data models.Poll) (*models.Poll, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	query := `
-		INSERT INTO polls (title, description, user_id)
-		VALUES ($1, $2, $3)
-		RETURNING id, title, description, user_id`
+	poll, err := insertPoll(ctx, m.DB, data)
 
-	row := m.DB.QueryRowContext(ctx, query, data.Title, data.Description, data.UserID)
+	if err != nil {
+		return nil, dbError(err)
+	}
 
-	var result models.Poll
+	return poll, nil
+}
 
-	err := row.Scan(
-		&result.ID,
-		&result.Title,
-		&result.Description,
-		&result.UserID,
-	)
+// CreatePollWithOptions inserts the poll together with its options in a single
+// transaction, so a failure never leaves an empty poll behind.
+func (m *DBRepo) CreatePollWithOptions(data models.Poll) (*models.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	poll, err := insertPoll(ctx, tx, data)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	poll.Options, err = insertOptions(ctx, tx, poll.ID, data.Options, data.UserID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &result, nil
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return poll, nil
+}
+
+// insertOptions adds the options of a new poll in their given order, each
+// with its first revision.
+func insertOptions(ctx context.Context, q querier, pollID int, options []*models.PollOption, editorID int) ([]*models.PollOption, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO poll_options (poll_id, option_text, position, description, link_url, is_correct, starts_at, ends_at,
+				time_zone)
+			VALUES ($1, $2, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id, option_text, description, link_url, is_correct, starts_at, ends_at, time_zone, position, revision,
+				version
+		), first_revision AS (
+			INSERT INTO option_revisions (option_id, revision, option_text, description, link_url, edited_by)
+			SELECT id, revision, option_text, description, link_url, $3 FROM inserted
+		)
+		SELECT id, option_text, description, link_url, is_correct, starts_at, ends_at, time_zone, position, revision,
+			version
+		FROM inserted`
+
+	inserted := []*models.PollOption{}
+
+	for i, option := range options {
+		opt := models.PollOption{Votes: []*models.Vote{}}
+
+		err := q.QueryRowContext(ctx, query, pollID, option.Text, editorID, i, option.Description,
+			option.LinkURL, option.IsCorrect, option.StartsAt, option.EndsAt, option.TimeZone).Scan(
+			&opt.ID,
+			&opt.Text,
+			&opt.Description,
+			&opt.LinkURL,
+			&opt.IsCorrect,
+			&opt.StartsAt,
+			&opt.EndsAt,
+			&opt.TimeZone,
+			&opt.Position,
+			&opt.Revision,
+			&opt.Version,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		inserted = append(inserted, &opt)
+	}
+
+	return inserted, nil
 }
 
-func (m *DBRepo) AddPollOptions(pollId int, options []models.PollOption) error {
+func insertPoll(ctx context.Context, q querier, data models.Poll) (*models.Poll, error) {
+	if data.Status == "" {
+		data.Status = models.PollStatusDraft
+	}
+
+	if data.Kind == "" {
+		data.Kind = models.PollKindChoice
+	}
+
+	if data.MaxAnswerLength == 0 {
+		data.MaxAnswerLength = models.DefaultTextAnswerLength
+	}
+
+	if data.CreditBudget == 0 {
+		data.CreditBudget = models.DefaultCreditBudget
+	}
+
+	// the first revision is written in the same statement as the poll
+	query := `
+		WITH inserted AS (
+			INSERT INTO polls (title, description, user_id, organization_id, members_only, private_results, allow_guests,
+				status, opens_at, closes_at, randomize_options, allow_suggestions, suggestion_limit, kind, max_answer_length,
+				quiz, time_bonus, credit_budget, weighted, none_of_the_above, quorum, quorum_percent, threshold, published_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23,
+				CASE WHEN $8 = 'published' THEN CURRENT_TIMESTAMP END)
+			RETURNING *
+		), first_revision AS (
+			INSERT INTO poll_revisions (poll_id, revision, title, description, edited_by)
+			SELECT id, revision, title, description, user_id FROM inserted
+		)
+		SELECT ` + pollColumns + ` FROM inserted`
+
+	row := q.QueryRowContext(ctx, query, data.Title, data.Description, data.UserID, data.OrganizationID, data.MembersOnly,
+		data.PrivateResults, data.AllowGuests, data.Status, data.OpensAt, data.ClosesAt, data.RandomizeOptions,
+		data.AllowSuggestions, data.SuggestionLimit, data.Kind, data.MaxAnswerLength, data.Quiz, data.TimeBonus,
+		data.CreditBudget, data.Weighted, data.NoneOfTheAbove, data.Quorum, data.QuorumPercent, data.Threshold)
+
+	return scanPoll(row)
+}
+
+// AddPollOptions appends the options after the existing options of the poll.
+func (m *DBRepo) AddPollOptions(pollId int, options []models.PollOption, editorID int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
-	query := `INSERT INTO poll_options (poll_id, option_text) VALUES `
 
-	var args []any
+	args := []any{editorID, pollId}
 	var placeholders []string
 
 	for i, option := range options {
-		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
-		args = append(args, pollId, option.Text)
+		n := len(args)
+		placeholders = append(placeholders, fmt.Sprintf(
+			"($%d::TEXT, $%d::TEXT, $%d::TEXT, $%d::BOOLEAN, $%d::TIMESTAMPTZ, $%d::TIMESTAMPTZ, $%d::TEXT, %d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, i))
+		args = append(args, option.Text, option.Description, option.LinkURL, option.IsCorrect, option.StartsAt,
+			option.EndsAt, option.TimeZone)
 	}
 
-	query += strings.Join(placeholders, ", ")
+	query := `
+		WITH next AS (
+			SELECT COALESCE(MAX(position) + 1, 0) AS position
+			FROM poll_options
+			WHERE poll_id = $2 AND deleted_at IS NULL
+		), inserted AS (
+			INSERT INTO poll_options (poll_id, option_text, description, link_url, is_correct, starts_at, ends_at, time_zone,
+				position)
+			SELECT $2, v.option_text, v.description, v.link_url, v.is_correct, v.starts_at, v.ends_at, v.time_zone,
+				next.position + v.idx
+			FROM next, (VALUES ` + strings.Join(placeholders, ", ") + `)
+				AS v(option_text, description, link_url, is_correct, starts_at, ends_at, time_zone, idx)
+			RETURNING id, option_text, description, link_url, revision
+		)
+		INSERT INTO option_revisions (option_id, revision, option_text, description, link_url, edited_by)
+		SELECT id, revision, option_text, description, link_url, $1 FROM inserted`
 
 	_, err := m.DB.ExecContext(ctx, query, args...)
-	return err
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return touchPoll(ctx, m.DB, pollId)
 }
 
 func (m *DBRepo) GetPollByID(id int) (*models.Poll, error) {
@@ -212,89 +463,218 @@ func (m *DBRepo) GetPollByID(id int) (*models.Poll, error) {
 	defer cancel()
 
 	query := `
-		SELECT id, title, description, user_id
+		SELECT ` + pollColumns + `
 		FROM polls 
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	var poll models.Poll
-
 	row := m.DB.QueryRowContext(ctx, query, id)
 
-	err := row.Scan(
-		&poll.ID,
-		&poll.Title,
-		&poll.Description,
-		&poll.UserID,
-	)
+	poll, err := scanPoll(row)
 
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "poll_not_found", "poll not found")
 	}
 
 	options, err := m.GetPollOptions(id)
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	poll.Options = options
 
-	return &poll, nil
+	if poll.IsText() {
+		poll.TextAnswers, err = countTextAnswers(ctx, m.DB, id)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return poll, nil
 }
 
-func (m *DBRepo) UpdatePollByID(id int, data models.Poll) error {
+// UpdatePollByID updates the poll if it is still at data.Version, and records a
+// new revision when its title or description changes.
+func (m *DBRepo) UpdatePollByID(id int, data models.Poll, editorID int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
+	if data.CreditBudget == 0 {
+		data.CreditBudget = models.DefaultCreditBudget
+	}
+
 	query := `
-		UPDATE polls
-		SET title = $1, description = $2
-		WHERE id = $3
+		WITH updated AS (
+			UPDATE polls
+			SET title = $1, description = $2, opens_at = $3, closes_at = $4,
+				members_only = $8, private_results = $9, allow_guests = $10, randomize_options = $11,
+				allow_suggestions = $12, suggestion_limit = $13, max_answer_length = $14, time_bonus = $15,
+				credit_budget = $16, weighted = $17, none_of_the_above = $18, quorum = $19, quorum_percent = $20,
+				threshold = $21,
+				revision = CASE WHEN title IS DISTINCT FROM $1 OR description IS DISTINCT FROM $2
+					THEN revision + 1 ELSE revision END,
+				version = version + 1
+			WHERE id = $5 AND version = $7 AND deleted_at IS NULL
+			RETURNING id, revision, title, description
+		), new_revision AS (
+			INSERT INTO poll_revisions (poll_id, revision, title, description, edited_by)
+			SELECT id, revision, title, description, $6 FROM updated
+			ON CONFLICT (poll_id, revision) DO NOTHING
+		)
+		SELECT COUNT(*) FROM updated
 	`
 
-	_, err := m.DB.ExecContext(ctx, query, data.Title, data.Description, id)
-	return err
+	var updated int
+
+	err := m.DB.QueryRowContext(ctx, query, data.Title, data.Description, data.OpensAt, data.ClosesAt, id, editorID,
+		data.Version, data.MembersOnly, data.PrivateResults, data.AllowGuests, data.RandomizeOptions,
+		data.AllowSuggestions, data.SuggestionLimit, data.MaxAnswerLength, data.TimeBonus, data.CreditBudget,
+		data.Weighted, data.NoneOfTheAbove, data.Quorum, data.QuorumPercent, data.Threshold).Scan(&updated)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	if updated == 0 {
+		return errPollVersionMismatch
+	}
+
+	return nil
 }
 
-func (m *DBRepo) DeletePollByID(id int) error {
+func (m *DBRepo) PublishPoll(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
 	query := `
-		DELETE FROM polls
-		WHERE id = $1
+		UPDATE polls
+		SET status = 'published', published_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND status = 'draft'
 	`
 
 	_, err := m.DB.ExecContext(ctx, query, id)
-	return err
+	return dbError(err)
 }
 
-func (m *DBRepo) UpdateOptionByID(id int, text string) error {
+// DeletePollByID moves the poll to the trash if it is still at the given
+// version. Its options and votes are kept until the poll is restored or purged.
+func (m *DBRepo) DeletePollByID(id int, version int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
 	query := `
-		UPDATE poll_options
-		SET option_text = $1
-		WHERE id = $2
+		UPDATE polls
+		SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND version = $2 AND deleted_at IS NULL
 	`
 
-	_, err := m.DB.ExecContext(ctx, query, text, id)
-	return err
+	result, err := m.DB.ExecContext(ctx, query, id, version)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return checkVersion(result, errPollVersionMismatch)
 }
 
-func (m *DBRepo) DeleteOptionByID(id int) error {
+// UpdateOptionByID updates the option if it is still at data.Version, and
+// records a new revision when its wording changes. Existing votes stay linked
+// to the revision they were cast against, unless resetVotes is set, in which
+// case they are deleted.
+func (m *DBRepo) UpdateOptionByID(id int, data models.PollOption, editorID int, resetVotes bool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
 	query := `
-		DELETE FROM poll_options
-		WHERE id = $1
+		WITH updated AS (
+			UPDATE poll_options
+			SET option_text = $1, description = $5, link_url = $6, is_correct = $7, starts_at = $8, ends_at = $9,
+				time_zone = $10,
+				revision = CASE WHEN (option_text, description, link_url) IS DISTINCT FROM ($1, $5, $6)
+					THEN revision + 1 ELSE revision END,
+				version = version + 1
+			WHERE id = $2 AND version = $4 AND deleted_at IS NULL
+			RETURNING id, poll_id, revision, option_text, description, link_url
+		), new_revision AS (
+			INSERT INTO option_revisions (option_id, revision, option_text, description, link_url, edited_by)
+			SELECT id, revision, option_text, description, link_url, $3 FROM updated
+			ON CONFLICT (option_id, revision) DO NOTHING
+		)
+		SELECT poll_id FROM updated
 	`
 
-	_, err := m.DB.ExecContext(ctx, query, id)
-	return err
+	var pollID int
+
+	err = tx.QueryRowContext(ctx, query, data.Text, id, editorID, data.Version, data.Description,
+		data.LinkURL, data.IsCorrect, data.StartsAt, data.EndsAt, data.TimeZone).Scan(&pollID)
+
+	if err == sql.ErrNoRows {
+		return errOptionVersionMismatch
+	}
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	if resetVotes {
+		_, err = tx.ExecContext(ctx, `DELETE FROM votes WHERE option_id = $1`, id)
+
+		if err != nil {
+			return dbError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM guest_votes WHERE option_id = $1`, id)
+
+		if err != nil {
+			return dbError(err)
+		}
+	}
+
+	err = touchPoll(ctx, tx, pollID)
+
+	if err != nil {
+		return err
+	}
+
+	return dbError(tx.Commit())
+}
+
+// DeleteOptionByID moves the option to the trash if it is still at the given
+// version.
+func (m *DBRepo) DeleteOptionByID(id int, version int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE poll_options
+		SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+		RETURNING poll_id
+	`
+
+	var pollID int
+
+	err := m.DB.QueryRowContext(ctx, query, id, version).Scan(&pollID)
+
+	if err == sql.ErrNoRows {
+		return errOptionVersionMismatch
+	}
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return touchPoll(ctx, m.DB, pollID)
 }
 
 func (m *DBRepo) Vote(poll_id int, option_id int, user_id int) error {
@@ -302,27 +682,41 @@ func (m *DBRepo) Vote(poll_id int, option_id int, user_id int) error {
 	defer cancel()
 
 	query := `
-		INSERT INTO votes (option_id, user_id)
-		VALUES ($1, $2)
+		INSERT INTO votes (option_id, option_revision, user_id)
+		SELECT id, revision, $2 FROM poll_options WHERE id = $1
 		ON CONFLICT (option_id, user_id) DO NOTHING
 	`
 
 	options, err := m.GetPollOptions(poll_id)
 
 	if err != nil {
-		return err
+		return dbError(err)
+	}
+
+	// check the option before dropping the previous vote
+	poll := models.Poll{ID: poll_id, Options: options}
+
+	if poll.OptionByID(option_id) == nil {
+		return repository.NotFound("option_not_found", "option does not belong to this poll")
 	}
 
 	for _, option := range options {
-		err = m.Unvote(option.ID, user_id)
+		err = m.Unvote(poll_id, option.ID, user_id)
 		if err != nil {
-			return err
+			return dbError(err)
 		}
 	}
 
+	// voting for an option withdraws an abstention
+	_, err = m.DB.ExecContext(ctx, `DELETE FROM blank_votes WHERE poll_id = $1 AND user_id = $2`, poll_id, user_id)
+
+	if err != nil {
+		return dbError(err)
+	}
+
 	_, err = m.DB.ExecContext(ctx, query, option_id, user_id)
 
-	return err
+	return dbError(err)
 }
 
 func (m *DBRepo) GetOptionVotes(option_id int) ([]*models.Vote, error) {
@@ -332,7 +726,7 @@ func (m *DBRepo) GetOptionVotes(option_id int) ([]*models.Vote, error) {
 	votes := []*models.Vote{}
 
 	query := `
-		SELECT id, option_id, user_id
+		SELECT id, option_id, option_revision, user_id, created_at
 		FROM votes
 		WHERE option_id = $1
 	`
@@ -340,7 +734,7 @@ func (m *DBRepo) GetOptionVotes(option_id int) ([]*models.Vote, error) {
 	rows, err := m.DB.QueryContext(ctx, query, option_id)
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	defer rows.Close()
@@ -350,11 +744,13 @@ func (m *DBRepo) GetOptionVotes(option_id int) ([]*models.Vote, error) {
 		err := rows.Scan(
 			&vote.ID,
 			&vote.OptionID,
+			&vote.OptionRevision,
 			&vote.UserID,
+			&vote.CreatedAt,
 		)
 
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		votes = append(votes, &vote)
@@ -377,15 +773,91 @@ func (m *DBRepo) IsPollOwner(pollID int, userID int) bool {
 	return true
 }
 
-func (m *DBRepo) Unvote(option_id int, user_id int) error {
+func (m *DBRepo) Unvote(poll_id int, option_id int, user_id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM votes
+		WHERE option_id = $1 AND user_id = $2 AND option_id IN (SELECT id FROM poll_options WHERE poll_id = $3)
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, option_id, user_id, poll_id)
+	return dbError(err)
+}
+
+// GuestVote records the vote of a guest voter. A guest token holds a single
+// vote per poll, voting again moves it to the new option.
+func (m *DBRepo) GuestVote(pollID int, optionID int, guestToken string, ip string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO guest_votes (poll_id, option_id, option_revision, guest_token, ip)
+		SELECT $1, id, revision, $3, $4 FROM poll_options
+		WHERE id = $2 AND poll_id = $1 AND status = 'approved' AND deleted_at IS NULL
+		ON CONFLICT (poll_id, guest_token)
+		DO UPDATE SET option_id = EXCLUDED.option_id, option_revision = EXCLUDED.option_revision,
+			ip = EXCLUDED.ip, created_at = CURRENT_TIMESTAMP
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, pollID, optionID, guestToken, ip)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	if affected == 0 {
+		return repository.NotFound("option_not_found", "option does not belong to this poll")
+	}
+
+	return nil
+}
+
+func (m *DBRepo) GuestUnvote(pollID int, guestToken string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
 	query := `
-		DELETE FROM votes 
-		WHERE option_id = $1 AND user_id = $2
+		DELETE FROM guest_votes
+		WHERE poll_id = $1 AND guest_token = $2
 	`
 
-	_, err := m.DB.ExecContext(ctx, query, option_id, user_id)
-	return err
+	_, err := m.DB.ExecContext(ctx, query, pollID, guestToken)
+	return dbError(err)
+}
+
+// touchPoll bumps the version of the poll after a change to its options, so
+// editors holding an older version have to reload it. Votes leave the version
+// alone, or every edit of an active poll would fail its If-Match check.
+func touchPoll(ctx context.Context, q querier, pollID int) error {
+	query := `
+		UPDATE polls
+		SET version = version + 1
+		WHERE id = $1
+	`
+
+	_, err := q.ExecContext(ctx, query, pollID)
+	return dbError(err)
+}
+
+// checkVersion returns mismatch when a versioned update touched no rows.
+func checkVersion(result sql.Result, mismatch error) error {
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	if affected == 0 {
+		return mismatch
+	}
+
+	return nil
 }
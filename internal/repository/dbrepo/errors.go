@@ -0,0 +1,71 @@
+package dbrepo
+
+import (
+	"database/sql"
+	"errors"
+	"polling/internal/repository"
+
+	"github.com/jackc/pgconn"
+)
+
+// constraintErrors maps constraint names to the domain error reported when the
+// constraint is violated.
+var constraintErrors = map[string]*repository.Error{
+	"users_username_key":        repository.Conflict("username_taken", "username is already taken"),
+	"organization_members_pkey": repository.Conflict("already_member", "user is already a member of this organization"),
+}
+
+var (
+	errPollVersionMismatch   = repository.PreconditionFailed("version_mismatch", "the poll has been changed since it was read")
+	errOptionVersionMismatch = repository.PreconditionFailed("version_mismatch", "the option has been changed since it was read")
+	errIncompleteOrder       = repository.Validation("incomplete_order", "the order must list every option of the poll exactly once")
+)
+
+// dbError translates driver errors into domain errors, so raw driver messages
+// never reach clients. Errors it does not know are returned unchanged.
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var domainErr *repository.Error
+
+	if errors.As(err, &domainErr) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.NotFound("not_found", "resource not found").Wrap(err)
+	}
+
+	var pgErr *pgconn.PgError
+
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	if mapped, ok := constraintErrors[pgErr.ConstraintName]; ok {
+		return mapped.Wrap(err)
+	}
+
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return repository.Conflict("conflict", "resource already exists").Wrap(err)
+	case "23503": // foreign_key_violation
+		return repository.NotFound("reference_not_found", "a referenced resource does not exist").Wrap(err)
+	case "23502", "23514", "22001", "22P02": // not null, check, string too long, invalid text
+		return repository.Validation("invalid_value", "a value is invalid").Wrap(err)
+	}
+
+	return err
+}
+
+// notFound translates sql.ErrNoRows into a not found error with the given code
+// and message, and any other error through dbError.
+func notFound(err error, code string, message string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.NotFound(code, message).Wrap(err)
+	}
+
+	return dbError(err)
+}
@@ -0,0 +1,231 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"polling/internal/models"
+	"polling/internal/repository"
+)
+
+// lockPollVersion bumps the version of the poll inside tx, failing when the
+// poll is no longer at version.
+func lockPollVersion(ctx context.Context, q querier, pollID int, version int) error {
+	query := `
+		UPDATE polls
+		SET version = version + 1
+		WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := q.ExecContext(ctx, query, pollID, version)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return checkVersion(result, errPollVersionMismatch)
+}
+
+// livePollOptionIDs returns the IDs of the approved options of the poll that
+// are not in the trash.
+func livePollOptionIDs(ctx context.Context, q querier, pollID int) (map[int]bool, error) {
+	query := `
+		SELECT id
+		FROM poll_options
+		WHERE poll_id = $1 AND status = 'approved' AND deleted_at IS NULL
+	`
+
+	rows, err := q.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	ids := map[int]bool{}
+
+	for rows.Next() {
+		var id int
+
+		err := rows.Scan(&id)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		ids[id] = true
+	}
+
+	return ids, dbError(rows.Err())
+}
+
+// ReorderOptions moves the options of the poll into the order of optionIDs,
+// which has to list every live option exactly once.
+func (m *DBRepo) ReorderOptions(pollID int, optionIDs []int, version int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	err = lockPollVersion(ctx, tx, pollID, version)
+
+	if err != nil {
+		return err
+	}
+
+	live, err := livePollOptionIDs(ctx, tx, pollID)
+
+	if err != nil {
+		return err
+	}
+
+	if len(live) != len(optionIDs) {
+		return errIncompleteOrder
+	}
+
+	query := `
+		UPDATE poll_options
+		SET position = $1
+		WHERE id = $2
+	`
+
+	for position, id := range optionIDs {
+		if !live[id] {
+			return errIncompleteOrder
+		}
+
+		delete(live, id)
+
+		_, err = tx.ExecContext(ctx, query, position, id)
+
+		if err != nil {
+			return dbError(err)
+		}
+	}
+
+	return dbError(tx.Commit())
+}
+
+// ReplacePollOptions makes options the option set of the poll in a single
+// transaction. Options with an ID are updated, options without one are
+// created and live options missing from the set are moved to the trash. The
+// position of every option is its index in options.
+func (m *DBRepo) ReplacePollOptions(pollID int, options []models.PollOption, version int, editorID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	err = lockPollVersion(ctx, tx, pollID, version)
+
+	if err != nil {
+		return err
+	}
+
+	live, err := livePollOptionIDs(ctx, tx, pollID)
+
+	if err != nil {
+		return err
+	}
+
+	updateQuery := `
+		WITH updated AS (
+			UPDATE poll_options
+			SET option_text = $1, description = $5, link_url = $6, position = $3, is_correct = $7, starts_at = $8,
+				ends_at = $9, time_zone = $10,
+				revision = CASE WHEN (option_text, description, link_url) IS DISTINCT FROM ($1, $5, $6)
+					THEN revision + 1 ELSE revision END,
+				version = CASE WHEN (option_text, description, link_url, position, is_correct, starts_at, ends_at, time_zone)
+					IS DISTINCT FROM ($1, $5, $6, $3, $7, $8, $9, $10) THEN version + 1 ELSE version END
+			WHERE id = $2
+			RETURNING id, revision, option_text, description, link_url
+		)
+		INSERT INTO option_revisions (option_id, revision, option_text, description, link_url, edited_by)
+		SELECT id, revision, option_text, description, link_url, $4 FROM updated
+		ON CONFLICT (option_id, revision) DO NOTHING
+	`
+
+	insertQuery := `
+		WITH inserted AS (
+			INSERT INTO poll_options (poll_id, option_text, position, description, link_url, is_correct, starts_at, ends_at,
+				time_zone)
+			VALUES ($1, $2, $3, $5, $6, $7, $8, $9, $10)
+			RETURNING id, revision, option_text, description, link_url
+		)
+		INSERT INTO option_revisions (option_id, revision, option_text, description, link_url, edited_by)
+		SELECT id, revision, option_text, description, link_url, $4 FROM inserted
+	`
+
+	for position, option := range options {
+		if option.ID == 0 {
+			_, err = tx.ExecContext(ctx, insertQuery, pollID, option.Text, position, editorID, option.Description,
+				option.LinkURL, option.IsCorrect, option.StartsAt, option.EndsAt, option.TimeZone)
+		} else if live[option.ID] {
+			_, err = tx.ExecContext(ctx, updateQuery, option.Text, option.ID, position, editorID, option.Description,
+				option.LinkURL, option.IsCorrect, option.StartsAt, option.EndsAt, option.TimeZone)
+			delete(live, option.ID)
+		} else {
+			return repository.NotFound("option_not_found", "option does not belong to this poll")
+		}
+
+		if err != nil {
+			return dbError(err)
+		}
+	}
+
+	deleteQuery := `
+		UPDATE poll_options
+		SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1
+	`
+
+	for id := range live {
+		_, err = tx.ExecContext(ctx, deleteQuery, id)
+
+		if err != nil {
+			return dbError(err)
+		}
+	}
+
+	return dbError(tx.Commit())
+}
+
+// SetOptionImage stores the blob keys of the image of an option and its
+// thumbnail if the option is still at version. Empty keys remove the image.
+func (m *DBRepo) SetOptionImage(optionID int, version int, imageKey string, thumbnailKey string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE poll_options
+		SET image_key = NULLIF($2, ''), thumbnail_key = NULLIF($3, ''), version = version + 1
+		WHERE id = $1 AND version = $4 AND deleted_at IS NULL
+		RETURNING poll_id
+	`
+
+	var pollID int
+
+	err := m.DB.QueryRowContext(ctx, query, optionID, imageKey, thumbnailKey, version).Scan(&pollID)
+
+	if err == sql.ErrNoRows {
+		return errOptionVersionMismatch
+	}
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return touchPoll(ctx, m.DB, pollID)
+}
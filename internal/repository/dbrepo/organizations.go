@@ -0,0 +1,275 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+)
+
+func (m *DBRepo) CreateOrganization(data models.Organization, ownerID int) (*models.Organization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO organizations (name)
+		VALUES ($1)
+		RETURNING id, name, created_at`
+
+	var org models.Organization
+
+	err = tx.QueryRowContext(ctx, query, data.Name).Scan(
+		&org.ID,
+		&org.Name,
+		&org.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	query = `
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)`
+
+	_, err = tx.ExecContext(ctx, query, org.ID, ownerID, models.OrgRoleOwner)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &org, nil
+}
+
+func (m *DBRepo) GetOrganizationByID(id int) (*models.Organization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, name, created_at
+		FROM organizations
+		WHERE id = $1
+	`
+
+	var org models.Organization
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&org.ID,
+		&org.Name,
+		&org.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, notFound(err, "organization_not_found", "organization not found")
+	}
+
+	members, err := m.GetOrganizationMembers(id)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	org.Members = members
+
+	return &org, nil
+}
+
+func (m *DBRepo) GetUserOrganizations(userID int) ([]*models.Organization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	orgs := []*models.Organization{}
+
+	query := `
+		SELECT o.id, o.name, o.created_at
+		FROM organizations o
+		JOIN organization_members om ON om.organization_id = o.id
+		WHERE om.user_id = $1
+		ORDER BY o.name
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var org models.Organization
+		err := rows.Scan(
+			&org.ID,
+			&org.Name,
+			&org.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		orgs = append(orgs, &org)
+	}
+
+	return orgs, dbError(rows.Err())
+}
+
+func (m *DBRepo) GetOrganizationMembers(orgID int) ([]*models.OrganizationMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	members := []*models.OrganizationMember{}
+
+	query := `
+		SELECT om.organization_id, om.user_id, u.username, u.first_name, u.last_name, om.role
+		FROM organization_members om
+		JOIN users u ON u.id = om.user_id
+		WHERE om.organization_id = $1
+		ORDER BY u.username
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, orgID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.OrganizationMember
+		err := rows.Scan(
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Username,
+			&member.FirstName,
+			&member.LastName,
+			&member.Role,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		members = append(members, &member)
+	}
+
+	return members, dbError(rows.Err())
+}
+
+// GetOrganizationRole returns the role of the user in the organization, or an
+// empty string when the user is not a member.
+func (m *DBRepo) GetOrganizationRole(orgID int, userID int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT role
+		FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`
+
+	var role string
+
+	err := m.DB.QueryRowContext(ctx, query, orgID, userID).Scan(&role)
+
+	if err != nil {
+		return ""
+	}
+
+	return role
+}
+
+func (m *DBRepo) AddOrganizationMember(orgID int, userID int, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, orgID, userID, role)
+	return dbError(err)
+}
+
+func (m *DBRepo) UpdateOrganizationMemberRole(orgID int, userID int, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE organization_members
+		SET role = $1
+		WHERE organization_id = $2 AND user_id = $3
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, role, orgID, userID)
+	return dbError(err)
+}
+
+func (m *DBRepo) RemoveOrganizationMember(orgID int, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`
+
+	_, err := m.DB.ExecContext(ctx, query, orgID, userID)
+	return dbError(err)
+}
+
+func (m *DBRepo) GetOrganizationPolls(orgID int) ([]*models.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	polls := []*models.Poll{}
+
+	query := `
+		SELECT ` + pollColumns + `
+		FROM polls
+		WHERE organization_id = $1 AND deleted_at IS NULL
+		ORDER BY id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, orgID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		options, err := m.GetPollOptions(poll.ID)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		poll.Options = options
+
+		polls = append(polls, poll)
+	}
+
+	return polls, dbError(rows.Err())
+}
@@ -0,0 +1,81 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"time"
+)
+
+// GetUndecidedPolls returns the published polls with decision rules that
+// closed before now and have no outcome yet, with their options.
+func (m *DBRepo) GetUndecidedPolls(now time.Time) ([]*models.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + pollColumns + `
+		FROM polls
+		WHERE deleted_at IS NULL AND status = 'published' AND outcome = ''
+			AND closes_at <= $1
+			AND (quorum > 0 OR quorum_percent > 0 OR threshold <> '')
+		ORDER BY closes_at, id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, now)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	polls := []*models.Poll{}
+
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		polls = append(polls, poll)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for _, poll := range polls {
+		poll.Options, err = m.GetPollOptions(poll.ID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return polls, nil
+}
+
+// SetPollOutcome stores the outcome of a closed poll. The outcome of a poll
+// is only decided once.
+func (m *DBRepo) SetPollOutcome(pollID int, outcome models.Outcome) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE polls
+		SET outcome = $2, outcome_option_id = $3, decided_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND outcome = '' AND deleted_at IS NULL
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, pollID, outcome.Result, outcome.OptionID)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return checkVersion(result, repository.Conflict("outcome_decided", "the outcome of this poll has already been decided"))
+}
@@ -0,0 +1,98 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+	"time"
+)
+
+// SaveQuadraticBallot replaces the votes of the user on a quadratic poll in a
+// single transaction.
+func (m *DBRepo) SaveQuadraticBallot(pollID int, userID int, votes []models.QuadraticVote) (*models.QuadraticBallot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM quadratic_votes
+		WHERE poll_id = $1 AND user_id = $2
+	`
+
+	_, err = tx.ExecContext(ctx, query, pollID, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	ballot := &models.QuadraticBallot{PollID: pollID, UserID: userID, Votes: votes, Credits: models.Credits(votes)}
+
+	query = `
+		INSERT INTO quadratic_votes (poll_id, user_id, option_id, votes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	for _, vote := range votes {
+		err = tx.QueryRowContext(ctx, query, pollID, userID, vote.OptionID, vote.Votes).Scan(&ballot.CreatedAt)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+	}
+
+	return ballot, dbError(tx.Commit())
+}
+
+// GetQuadraticBallots returns the ballots cast on a quadratic poll, ordered
+// by user.
+func (m *DBRepo) GetQuadraticBallots(pollID int) ([]*models.QuadraticBallot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT user_id, option_id, votes, created_at
+		FROM quadratic_votes
+		WHERE poll_id = $1
+		ORDER BY user_id, option_id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	ballots := []*models.QuadraticBallot{}
+	var ballot *models.QuadraticBallot
+
+	for rows.Next() {
+		var userID int
+		var vote models.QuadraticVote
+		var createdAt time.Time
+
+		err := rows.Scan(&userID, &vote.OptionID, &vote.Votes, &createdAt)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		if ballot == nil || ballot.UserID != userID {
+			ballot = &models.QuadraticBallot{PollID: pollID, UserID: userID, Votes: []models.QuadraticVote{}, CreatedAt: createdAt}
+			ballots = append(ballots, ballot)
+		}
+
+		ballot.Votes = append(ballot.Votes, vote)
+		ballot.Credits += vote.Votes * vote.Votes
+	}
+
+	return ballots, dbError(rows.Err())
+}
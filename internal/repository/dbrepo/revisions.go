@@ -0,0 +1,99 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"polling/internal/models"
+)
+
+// GetPollHistory returns every revision of the poll and of its options.
+func (m *DBRepo) GetPollHistory(pollID int) (*models.PollHistory, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	history := &models.PollHistory{
+		PollID:    pollID,
+		Revisions: []*models.PollRevision{},
+		Options:   []*models.OptionRevision{},
+	}
+
+	query := `
+		SELECT revision, title, description, edited_by, created_at
+		FROM poll_revisions
+		WHERE poll_id = $1
+		ORDER BY revision
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision models.PollRevision
+		var description sql.NullString
+
+		err := rows.Scan(
+			&revision.Revision,
+			&revision.Title,
+			&description,
+			&revision.EditedBy,
+			&revision.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		revision.Description = description.String
+
+		history.Revisions = append(history.Revisions, &revision)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	query = `
+		SELECT r.option_id, r.revision, r.option_text, r.description, r.link_url, r.edited_by, r.created_at
+		FROM option_revisions r
+		JOIN poll_options o ON o.id = r.option_id
+		WHERE o.poll_id = $1
+		ORDER BY r.option_id, r.revision
+	`
+
+	optionRows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer optionRows.Close()
+
+	for optionRows.Next() {
+		var revision models.OptionRevision
+
+		err := optionRows.Scan(
+			&revision.OptionID,
+			&revision.Revision,
+			&revision.Text,
+			&revision.Description,
+			&revision.LinkURL,
+			&revision.EditedBy,
+			&revision.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		history.Options = append(history.Options, &revision)
+	}
+
+	return history, dbError(optionRows.Err())
+}
@@ -0,0 +1,174 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"time"
+)
+
+// SaveAvailability replaces the answers of the user to the slots of a
+// scheduling poll. Slots left out of answers are dropped.
+func (m *DBRepo) SaveAvailability(pollID int, userID int, answers []models.SlotAnswer) (*models.Availability, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM schedule_answers
+		WHERE poll_id = $1 AND user_id = $2
+	`
+
+	_, err = tx.ExecContext(ctx, query, pollID, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	availability := &models.Availability{PollID: pollID, UserID: userID, Answers: answers}
+
+	query = `
+		INSERT INTO schedule_answers (poll_id, user_id, option_id, answer)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	for _, answer := range answers {
+		err = tx.QueryRowContext(ctx, query, pollID, userID, answer.OptionID, answer.Answer).Scan(&availability.CreatedAt)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+	}
+
+	return availability, dbError(tx.Commit())
+}
+
+// GetAvailabilities returns the answers of every voter of a scheduling poll,
+// ordered by user.
+func (m *DBRepo) GetAvailabilities(pollID int) ([]*models.Availability, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT user_id, option_id, answer, created_at
+		FROM schedule_answers
+		WHERE poll_id = $1
+		ORDER BY user_id, option_id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	availabilities := []*models.Availability{}
+	var availability *models.Availability
+
+	for rows.Next() {
+		var userID int
+		var answer models.SlotAnswer
+		var createdAt time.Time
+
+		err := rows.Scan(&userID, &answer.OptionID, &answer.Answer, &createdAt)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		if availability == nil || availability.UserID != userID {
+			availability = &models.Availability{PollID: pollID, UserID: userID, Answers: []models.SlotAnswer{},
+				CreatedAt: createdAt}
+			availabilities = append(availabilities, availability)
+		}
+
+		availability.Answers = append(availability.Answers, answer)
+	}
+
+	return availabilities, dbError(rows.Err())
+}
+
+// FinalizePoll settles a scheduling poll on the slot of the option.
+func (m *DBRepo) FinalizePoll(pollID int, optionID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE polls
+		SET final_option_id = $2, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, pollID, optionID)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return checkVersion(result, repository.NotFound("poll_not_found", "poll not found"))
+}
+
+// GetFinalizedMeetings returns the finalized scheduling polls the user
+// organizes or said they can make on the final slot, ordered by start.
+func (m *DBRepo) GetFinalizedMeetings(userID int) ([]*models.Meeting, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT p.id, o.id, p.title, COALESCE(p.description, ''), o.option_text, o.starts_at, o.ends_at, o.time_zone,
+			p.user_id
+		FROM polls p
+		JOIN poll_options o ON o.id = p.final_option_id
+		WHERE p.deleted_at IS NULL AND o.deleted_at IS NULL
+			AND o.starts_at IS NOT NULL AND o.ends_at IS NOT NULL
+			AND (p.user_id = $1 OR EXISTS (
+				SELECT 1 FROM schedule_answers a
+				WHERE a.option_id = o.id AND a.user_id = $1 AND a.answer IN ('yes', 'if_need_be')
+			))
+		ORDER BY o.starts_at, p.id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	meetings := []*models.Meeting{}
+
+	for rows.Next() {
+		var meeting models.Meeting
+
+		err := rows.Scan(
+			&meeting.PollID,
+			&meeting.OptionID,
+			&meeting.Title,
+			&meeting.Description,
+			&meeting.Slot,
+			&meeting.StartsAt,
+			&meeting.EndsAt,
+			&meeting.TimeZone,
+			&meeting.OrganizerID,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		meetings = append(meetings, &meeting)
+	}
+
+	return meetings, dbError(rows.Err())
+}
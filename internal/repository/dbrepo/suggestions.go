@@ -0,0 +1,171 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"polling/internal/models"
+	"polling/internal/repository"
+)
+
+// SuggestOption adds a pending option suggested by the user. The poll row is
+// locked while the user's earlier suggestions are counted, so concurrent
+// requests cannot slip past the suggestion limit of the poll.
+func (m *DBRepo) SuggestOption(pollID int, option models.PollOption, userID int) (*models.PollOption, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	var limit int
+
+	query := `
+		SELECT suggestion_limit
+		FROM polls
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	err = tx.QueryRowContext(ctx, query, pollID).Scan(&limit)
+
+	if err != nil {
+		return nil, notFound(err, "poll_not_found", "poll not found")
+	}
+
+	var suggested int
+
+	query = `
+		SELECT COUNT(*)
+		FROM poll_options
+		WHERE poll_id = $1 AND suggested_by = $2 AND deleted_at IS NULL
+	`
+
+	err = tx.QueryRowContext(ctx, query, pollID, userID).Scan(&suggested)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	if suggested >= limit {
+		return nil, repository.Forbidden("suggestion_limit_reached", "you have reached the suggestion limit of this poll")
+	}
+
+	query = `
+		WITH inserted AS (
+			INSERT INTO poll_options (poll_id, option_text, description, link_url, status, suggested_by, position)
+			SELECT $1, $2, $3, $4, 'pending', $5, COALESCE(MAX(position) + 1, 0)
+			FROM poll_options
+			WHERE poll_id = $1
+			RETURNING id, option_text, description, link_url, status, suggested_by, position, revision, version
+		), first_revision AS (
+			INSERT INTO option_revisions (option_id, revision, option_text, description, link_url, edited_by)
+			SELECT id, revision, option_text, description, link_url, suggested_by FROM inserted
+		)
+		SELECT id, option_text, description, link_url, status, suggested_by, position, revision, version FROM inserted
+	`
+
+	created := models.PollOption{Votes: []*models.Vote{}}
+
+	err = tx.QueryRowContext(ctx, query, pollID, option.Text, option.Description, option.LinkURL, userID).Scan(
+		&created.ID,
+		&created.Text,
+		&created.Description,
+		&created.LinkURL,
+		&created.Status,
+		&created.SuggestedBy,
+		&created.Position,
+		&created.Revision,
+		&created.Version,
+	)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &created, nil
+}
+
+// GetOptionSuggestions returns the options suggested for the poll, oldest
+// first. An empty status returns suggestions in every status.
+func (m *DBRepo) GetOptionSuggestions(pollID int, status string) ([]*models.PollOption, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, option_text, description, link_url, status, suggested_by, position, revision, version
+		FROM poll_options
+		WHERE poll_id = $1 AND suggested_by IS NOT NULL AND ($2 = '' OR status = $2) AND deleted_at IS NULL
+		ORDER BY id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID, status)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	suggestions := []*models.PollOption{}
+
+	for rows.Next() {
+		option := models.PollOption{Votes: []*models.Vote{}}
+
+		err := rows.Scan(
+			&option.ID,
+			&option.Text,
+			&option.Description,
+			&option.LinkURL,
+			&option.Status,
+			&option.SuggestedBy,
+			&option.Position,
+			&option.Revision,
+			&option.Version,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		suggestions = append(suggestions, &option)
+	}
+
+	return suggestions, dbError(rows.Err())
+}
+
+// ReviewSuggestion approves or rejects a suggested option. Approved options
+// join the poll like any other option.
+func (m *DBRepo) ReviewSuggestion(pollID int, optionID int, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE poll_options
+		SET status = $3, version = version + 1
+		WHERE id = $1 AND poll_id = $2 AND suggested_by IS NOT NULL AND deleted_at IS NULL
+		RETURNING id
+	`
+
+	err := m.DB.QueryRowContext(ctx, query, optionID, pollID, status).Scan(&optionID)
+
+	if err == sql.ErrNoRows {
+		return repository.NotFound("suggestion_not_found", "suggestion not found")
+	}
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return touchPoll(ctx, m.DB, pollID)
+}
@@ -0,0 +1,420 @@
+package dbrepo
+
+import (
+	"context"
+	"encoding/json"
+	"polling/internal/models"
+	"polling/internal/repository"
+)
+
+// CreateSurvey stores the survey together with the polls of its questions.
+// The polls are owned by the owner of the survey and share its status.
+func (m *DBRepo) CreateSurvey(data models.Survey) (*models.Survey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	if data.Status == "" {
+		data.Status = models.SurveyStatusDraft
+	}
+
+	query := `
+		INSERT INTO surveys (title, description, user_id, status, quiz)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	survey := data
+	survey.Questions = []*models.SurveyQuestion{}
+
+	err = tx.QueryRowContext(ctx, query, data.Title, data.Description, data.UserID, data.Status, data.Quiz).Scan(
+		&survey.ID,
+		&survey.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	query = `
+		INSERT INTO survey_questions (survey_id, poll_id, position, kind, required, show_if, skip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for i, question := range data.Questions {
+		pollData := *question.Poll
+		pollData.UserID = data.UserID
+		pollData.Status = data.Status
+
+		poll, err := insertPoll(ctx, tx, pollData)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		poll.Options, err = insertOptions(ctx, tx, poll.ID, pollData.Options, data.UserID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		showIf, skip, err := marshalRules(question)
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, query, survey.ID, poll.ID, i, question.Kind, question.Required, showIf, skip)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		survey.Questions = append(survey.Questions, &models.SurveyQuestion{
+			Position: i,
+			Kind:     question.Kind,
+			Required: question.Required,
+			ShowIf:   question.ShowIf,
+			Skip:     question.Skip,
+			Poll:     poll,
+		})
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &survey, nil
+}
+
+// GetSurveyByID returns the survey with its questions in order. Questions
+// whose poll has been deleted are left out.
+func (m *DBRepo) GetSurveyByID(id int) (*models.Survey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, title, description, user_id, status, quiz, created_at
+		FROM surveys
+		WHERE id = $1
+	`
+
+	survey := models.Survey{Questions: []*models.SurveyQuestion{}}
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&survey.ID,
+		&survey.Title,
+		&survey.Description,
+		&survey.UserID,
+		&survey.Status,
+		&survey.Quiz,
+		&survey.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, notFound(err, "survey_not_found", "survey not found")
+	}
+
+	query = `
+		SELECT q.poll_id, q.position, q.kind, q.required, q.show_if, q.skip
+		FROM survey_questions q
+		JOIN polls p ON p.id = q.poll_id
+		WHERE q.survey_id = $1 AND p.deleted_at IS NULL
+		ORDER BY q.position
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	pollIDs := []int{}
+
+	for rows.Next() {
+		var pollID int
+		var showIf, skip []byte
+		question := models.SurveyQuestion{}
+
+		err := rows.Scan(&pollID, &question.Position, &question.Kind, &question.Required, &showIf, &skip)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		err = json.Unmarshal(showIf, &question.ShowIf)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(skip, &question.Skip)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pollIDs = append(pollIDs, pollID)
+		survey.Questions = append(survey.Questions, &question)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	for i, pollID := range pollIDs {
+		survey.Questions[i].Poll, err = m.GetPollByID(pollID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &survey, nil
+}
+
+// PublishSurvey publishes the survey and the polls of all its questions at
+// once.
+func (m *DBRepo) PublishSurvey(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		UPDATE surveys
+		SET status = 'published'
+		WHERE id = $1 AND status = 'draft'
+	`
+
+	_, err = tx.ExecContext(ctx, query, id)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	query = `
+		UPDATE polls
+		SET status = 'published', published_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE status = 'draft' AND id IN (SELECT poll_id FROM survey_questions WHERE survey_id = $1)
+	`
+
+	_, err = tx.ExecContext(ctx, query, id)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	return dbError(tx.Commit())
+}
+
+// SubmitSurveyResponse stores the answers of the user in one transaction,
+// replacing earlier answers to the same questions and dropping the answers to
+// the polls in cleared. Whether the response is complete depends on the
+// branching rules of the survey, so the caller works it out.
+func (m *DBRepo) SubmitSurveyResponse(surveyID int, userID int, answers []models.SurveyAnswer, cleared []int,
+	completed bool) (*models.SurveyResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	unvote := `
+		DELETE FROM votes
+		WHERE user_id = $2 AND option_id IN (SELECT id FROM poll_options WHERE poll_id = $1)
+	`
+
+	vote := `
+		INSERT INTO votes (option_id, option_revision, user_id)
+		SELECT id, revision, $3 FROM poll_options
+		WHERE id = $2 AND poll_id = $1 AND status = 'approved' AND deleted_at IS NULL
+	`
+
+	for _, pollID := range cleared {
+		_, err = tx.ExecContext(ctx, unvote, pollID, userID)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		err = deleteTextAnswer(ctx, tx, pollID, userID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, answer := range answers {
+		// answers to text questions carry their text instead of an option
+		if answer.Text != "" {
+			_, err = upsertTextAnswer(ctx, tx, answer.PollID, userID, answer.Text)
+
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, unvote, answer.PollID, userID)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		result, err := tx.ExecContext(ctx, vote, answer.PollID, answer.OptionID, userID)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		if affected == 0 {
+			return nil, repository.NotFound("option_not_found", "option does not belong to this question")
+		}
+	}
+
+	query := `
+		INSERT INTO survey_responses (survey_id, user_id, completed)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (survey_id, user_id) DO UPDATE
+		SET completed = EXCLUDED.completed, updated_at = CURRENT_TIMESTAMP
+		RETURNING survey_id, user_id, completed, updated_at
+	`
+
+	var response models.SurveyResponse
+
+	err = tx.QueryRowContext(ctx, query, surveyID, userID, completed).Scan(
+		&response.SurveyID,
+		&response.UserID,
+		&response.Completed,
+		&response.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &response, nil
+}
+
+// GetSurveyAnswers returns the answers the user has given to the questions of
+// the survey so far.
+func (m *DBRepo) GetSurveyAnswers(surveyID int, userID int) ([]models.SurveyAnswer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT q.position, o.poll_id, o.id, ''
+		FROM votes v
+		JOIN poll_options o ON o.id = v.option_id
+		JOIN survey_questions q ON q.poll_id = o.poll_id
+		WHERE q.survey_id = $1 AND v.user_id = $2 AND o.deleted_at IS NULL
+		UNION ALL
+		SELECT q.position, a.poll_id, 0, a.body
+		FROM text_answers a
+		JOIN survey_questions q ON q.poll_id = a.poll_id
+		WHERE q.survey_id = $1 AND a.user_id = $2
+		ORDER BY 1
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, surveyID, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	answers := []models.SurveyAnswer{}
+
+	for rows.Next() {
+		var position int
+		var answer models.SurveyAnswer
+
+		err := rows.Scan(&position, &answer.PollID, &answer.OptionID, &answer.Text)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		answers = append(answers, answer)
+	}
+
+	return answers, dbError(rows.Err())
+}
+
+// marshalRules encodes the branching rules of a question for its JSONB
+// columns.
+func marshalRules(question *models.SurveyQuestion) ([]byte, []byte, error) {
+	showIf, err := json.Marshal(question.ShowIf)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	skip, err := json.Marshal(question.Skip)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return showIf, skip, nil
+}
+
+// GetSurveyResponseStats counts the complete and partial responses to the
+// survey.
+func (m *DBRepo) GetSurveyResponseStats(surveyID int) (*models.SurveyResponseStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE completed)
+		FROM survey_responses
+		WHERE survey_id = $1
+	`
+
+	var stats models.SurveyResponseStats
+
+	err := m.DB.QueryRowContext(ctx, query, surveyID).Scan(&stats.Responses, &stats.Completed)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &stats, nil
+}
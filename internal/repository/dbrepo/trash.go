@@ -0,0 +1,226 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+	"polling/internal/repository"
+	"time"
+)
+
+// managedByUser matches the polls p that the user $1 created or manages as an
+// owner or admin of their organization, the rule of models.CanManagePolls.
+const managedByUser = `(p.user_id = $1 OR EXISTS (
+	SELECT 1 FROM organization_members m
+	WHERE m.organization_id = p.organization_id AND m.user_id = $1 AND m.role IN ('owner', 'admin')
+))`
+
+// GetTrash returns the deleted polls the user manages, and the deleted options
+// of the polls the user manages that are still live.
+func (m *DBRepo) GetTrash(userID int) (*models.Trash, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	trash := &models.Trash{
+		Polls:   []*models.Poll{},
+		Options: []*models.DeletedOption{},
+	}
+
+	query := `
+		SELECT ` + pollColumns + `
+		FROM polls p
+		WHERE ` + managedByUser + ` AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		options, err := m.GetPollOptions(poll.ID)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		poll.Options = options
+
+		trash.Polls = append(trash.Polls, poll)
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	query = `
+		SELECT o.poll_id, o.id, o.option_text, o.deleted_at
+		FROM poll_options o
+		JOIN polls p ON p.id = o.poll_id
+		WHERE ` + managedByUser + ` AND p.deleted_at IS NULL AND o.deleted_at IS NOT NULL
+		ORDER BY o.deleted_at DESC
+	`
+
+	optionRows, err := m.DB.QueryContext(ctx, query, userID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer optionRows.Close()
+
+	for optionRows.Next() {
+		var option models.DeletedOption
+
+		err := optionRows.Scan(
+			&option.PollID,
+			&option.OptionID,
+			&option.Text,
+			&option.DeletedAt,
+		)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		trash.Options = append(trash.Options, &option)
+	}
+
+	return trash, dbError(optionRows.Err())
+}
+
+// GetDeletedPollByID returns a poll from the trash.
+func (m *DBRepo) GetDeletedPollByID(id int) (*models.Poll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + pollColumns + `
+		FROM polls
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	poll, err := scanPoll(m.DB.QueryRowContext(ctx, query, id))
+
+	if err != nil {
+		return nil, notFound(err, "poll_not_found", "poll not found in trash")
+	}
+
+	return poll, nil
+}
+
+func (m *DBRepo) RestorePoll(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE polls
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	if affected == 0 {
+		return repository.NotFound("poll_not_found", "poll not found in trash")
+	}
+
+	return nil
+}
+
+func (m *DBRepo) RestoreOption(pollID int, optionID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE poll_options
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND poll_id = $2 AND deleted_at IS NOT NULL
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, optionID, pollID)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	if affected == 0 {
+		return repository.NotFound("option_not_found", "option not found in trash")
+	}
+
+	return touchPoll(ctx, m.DB, pollID)
+}
+
+// PurgeDeleted permanently removes the polls and options that have been
+// deleted for longer than retention, together with their votes. The cutoff is
+// taken from the database clock that stamped deleted_at, so the time zone of
+// the server does not shift it. It returns the number of polls and options
+// removed.
+func (m *DBRepo) PurgeDeleted(retention time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	var purged int64
+
+	for _, query := range []string{
+		`DELETE FROM polls WHERE deleted_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'`,
+		`DELETE FROM poll_options WHERE deleted_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'`,
+	} {
+		result, err := tx.ExecContext(ctx, query, int64(retention.Seconds()))
+
+		if err != nil {
+			return 0, dbError(err)
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return 0, dbError(err)
+		}
+
+		purged += affected
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,194 @@
+package dbrepo
+
+import (
+	"context"
+	"polling/internal/models"
+	"polling/internal/repository"
+)
+
+// GetVoterWeights returns the allowlist of a weighted poll, ordered by
+// username.
+func (m *DBRepo) GetVoterWeights(pollID int) ([]*models.VoterWeight, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT w.user_id, u.username, w.weight
+		FROM voter_weights w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.poll_id = $1
+		ORDER BY u.username
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pollID)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer rows.Close()
+
+	weights := []*models.VoterWeight{}
+
+	for rows.Next() {
+		var weight models.VoterWeight
+
+		err := rows.Scan(&weight.UserID, &weight.Username, &weight.Weight)
+
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		weights = append(weights, &weight)
+	}
+
+	return weights, dbError(rows.Err())
+}
+
+// GetVoterWeight returns the weight of the user on a weighted poll.
+func (m *DBRepo) GetVoterWeight(pollID int, userID int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `
+		SELECT weight
+		FROM voter_weights
+		WHERE poll_id = $1 AND user_id = $2
+	`
+
+	var weight int
+
+	err := m.DB.QueryRowContext(ctx, query, pollID, userID).Scan(&weight)
+
+	if err != nil {
+		return 0, notFound(err, "voter_not_found", "the user is not on the allowlist of this poll")
+	}
+
+	return weight, nil
+}
+
+// SetVoterWeight adds the user to the allowlist of a weighted poll, or changes
+// their weight.
+func (m *DBRepo) SetVoterWeight(pollID int, userID int, weight int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO voter_weights (poll_id, user_id, weight)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (poll_id, user_id) DO UPDATE
+		SET weight = EXCLUDED.weight
+	`
+
+	_, err = tx.ExecContext(ctx, query, pollID, userID, weight)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	err = touchPoll(ctx, tx, pollID)
+
+	if err != nil {
+		return err
+	}
+
+	return dbError(tx.Commit())
+}
+
+// RemoveVoterWeight takes the user off the allowlist of a weighted poll.
+func (m *DBRepo) RemoveVoterWeight(pollID int, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM voter_weights
+		WHERE poll_id = $1 AND user_id = $2
+	`
+
+	result, err := tx.ExecContext(ctx, query, pollID, userID)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	if affected == 0 {
+		return repository.NotFound("voter_not_found", "the user is not on the allowlist of this poll")
+	}
+
+	err = touchPoll(ctx, tx, pollID)
+
+	if err != nil {
+		return err
+	}
+
+	return dbError(tx.Commit())
+}
+
+// ReplaceVoterWeights swaps the whole allowlist of a weighted poll for
+// weights, as an import does.
+func (m *DBRepo) ReplaceVoterWeights(pollID int, weights []models.VoterWeight) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM voter_weights
+		WHERE poll_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, pollID)
+
+	if err != nil {
+		return dbError(err)
+	}
+
+	query = `
+		INSERT INTO voter_weights (poll_id, user_id, weight)
+		VALUES ($1, $2, $3)
+	`
+
+	for _, weight := range weights {
+		_, err = tx.ExecContext(ctx, query, pollID, weight.UserID, weight.Weight)
+
+		if err != nil {
+			return dbError(err)
+		}
+	}
+
+	err = touchPoll(ctx, tx, pollID)
+
+	if err != nil {
+		return err
+	}
+
+	return dbError(tx.Commit())
+}
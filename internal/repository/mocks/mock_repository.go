@@ -3,28 +3,39 @@ package mocks
 import (
 	"database/sql"
 	"errors"
+	"polling/internal/branching"
 	"polling/internal/models"
+	"polling/internal/repository"
+	"strings"
+	"time"
 )
 
+// mockVersion is the version of every mock poll and option.
+const mockVersion = 1
+
+var errMockVersionMismatch = repository.PreconditionFailed("version_mismatch", "the poll has been changed since it was read")
+
 // MockDBRepo implements the repository.Repository interface for testing
 type MockDBRepo struct {
-	ShouldFail bool
-	MockUser   *models.User
-	MockError  error
+	ShouldFail  bool
+	MockUser    *models.User
+	MockError   error
+	AuditEvents []models.AuditEvent
+	Outcomes    map[int]models.Outcome
 }
 
 func (m *MockDBRepo) Connection() *sql.DB {
 	return nil
 }
 
-func (m *MockDBRepo) CreateUser(data models.User) error {
+func (m *MockDBRepo) CreateUser(data models.User) (int, error) {
 	if m.ShouldFail {
-		return errors.New("database error")
+		return 0, errors.New("database error")
 	}
 	if data.Username == "existing_user" {
-		return errors.New("user already exists")
+		return 0, repository.Conflict("username_taken", "username is already taken")
 	}
-	return nil
+	return 100, nil
 }
 
 func (m *MockDBRepo) GetUserByUsername(username string) (*models.User, error) {
@@ -34,6 +45,19 @@ func (m *MockDBRepo) GetUserByUsername(username string) (*models.User, error) {
 	return m.MockUser, nil
 }
 
+// mockUsernames are the users the mock knows by name
+var mockUsernames = map[string]int{"alice": 2, "bob": 3, "carol": 4, "dave": 5}
+
+func (m *MockDBRepo) GetUserIDsByUsernames(usernames []string) (map[string]int, error) {
+	ids := map[string]int{}
+	for _, username := range usernames {
+		if id, ok := mockUsernames[username]; ok {
+			ids[username] = id
+		}
+	}
+	return ids, nil
+}
+
 func (m *MockDBRepo) CreatePoll(data models.Poll) (*models.Poll, error) {
 	if m.ShouldFail {
 		return nil, errors.New("database error")
@@ -43,23 +67,193 @@ func (m *MockDBRepo) CreatePoll(data models.Poll) (*models.Poll, error) {
 	return &data, nil
 }
 
+func (m *MockDBRepo) CreatePollWithOptions(data models.Poll) (*models.Poll, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	data.ID = 1
+	options := []*models.PollOption{}
+	for i, option := range data.Options {
+		options = append(options, &models.PollOption{ID: i + 1, Text: option.Text, Revision: 1, Version: 1, Votes: []*models.Vote{}})
+	}
+	data.Options = options
+	return &data, nil
+}
+
 func (m *MockDBRepo) GetAllPolls() ([]*models.Poll, error) {
-	return nil, nil
+	polls := []*models.Poll{}
+	for _, id := range []int{1, 2, 4} {
+		poll, _ := m.GetPollByID(id)
+		polls = append(polls, poll)
+	}
+	return polls, nil
 }
 
 func (m *MockDBRepo) GetPollByID(id int) (*models.Poll, error) {
-	return nil, nil
+	options := []*models.PollOption{
+		{ID: 1, Text: "Yes", Revision: 1, Version: 1, Votes: []*models.Vote{}},
+		{ID: 2, Text: "No", Revision: 1, Version: 1, Votes: []*models.Vote{{ID: 1, OptionID: 2, OptionRevision: 1, UserID: 2}}},
+	}
+
+	switch id {
+	case 1:
+		return &models.Poll{ID: id, Title: "Draft Poll", Version: 1, UserID: 1, Status: models.PollStatusDraft, Options: options}, nil
+	case 2:
+		return &models.Poll{ID: id, Title: "Empty Draft Poll", Version: 1, UserID: 1, Status: models.PollStatusDraft}, nil
+	case 3:
+		// poll 3 belongs to organization 1 and was created by a plain member
+		orgID := 1
+		return &models.Poll{ID: id, Title: "Org Poll", Version: 1, UserID: 3, OrganizationID: &orgID, MembersOnly: true, Status: models.PollStatusPublished, Options: options}, nil
+	case 4:
+		return &models.Poll{ID: id, Title: "Guest Poll", Version: 1, UserID: 1, AllowGuests: true, Status: models.PollStatusPublished, Options: options}, nil
+	case 5:
+		return &models.Poll{ID: id, Title: "Private Poll", Version: 1, UserID: 1, PrivateResults: true, Status: models.PollStatusPublished, Options: options}, nil
+	case 8:
+		return &models.Poll{ID: id, Title: "Brainstorm Poll", Version: 1, UserID: 1, AllowSuggestions: true, SuggestionLimit: 2, Status: models.PollStatusPublished, Options: options}, nil
+	case 7:
+		// poll 7 shows its options in a random order to everyone but its editors
+		letters := []*models.PollOption{}
+		for i, text := range []string{"A", "B", "C", "D", "E", "F"} {
+			letters = append(letters, &models.PollOption{ID: i + 1, Text: text, Position: i, Revision: 1, Version: 1, Votes: []*models.Vote{}})
+		}
+		return &models.Poll{ID: id, Title: "Randomized Poll", UserID: 1, RandomizeOptions: true, Version: 1, Status: models.PollStatusPublished, Options: letters}, nil
+	case 10, 11:
+		return mockQuizPoll(id), nil
+	case 12:
+		candidates := []*models.PollOption{}
+		for i, text := range []string{"A", "B", "C", "D", "E"} {
+			candidates = append(candidates, &models.PollOption{ID: i + 1, Text: text, Position: i, Revision: 1, Version: 1, Votes: []*models.Vote{}})
+		}
+		return &models.Poll{ID: id, Title: "Board Election", Version: 1, UserID: 1, Kind: models.PollKindRanked, Status: models.PollStatusPublished, Options: candidates}, nil
+	case 13:
+		features := []*models.PollOption{
+			{ID: 1, Text: "Dark mode", Revision: 1, Version: 1, Votes: []*models.Vote{}},
+			{ID: 2, Text: "Offline sync", Revision: 1, Version: 1, Votes: []*models.Vote{}},
+			{ID: 3, Text: "Calendar export", Revision: 1, Version: 1, Votes: []*models.Vote{}},
+		}
+		return &models.Poll{ID: id, Title: "Roadmap", Version: 1, UserID: 1, Kind: models.PollKindQuadratic, CreditBudget: 25, Status: models.PollStatusPublished, Options: features}, nil
+	case 14:
+		// poll 14 is open, so its voter weights are frozen
+		shares := []*models.PollOption{
+			{ID: 1, Text: "Yes", Revision: 1, Version: 1, Votes: []*models.Vote{{ID: 1, OptionID: 1, OptionRevision: 1, UserID: 2}, {ID: 2, OptionID: 1, OptionRevision: 1, UserID: 3}}},
+			{ID: 2, Text: "No", Revision: 1, Version: 1, Votes: []*models.Vote{{ID: 3, OptionID: 2, OptionRevision: 1, UserID: 4}}},
+		}
+		return &models.Poll{ID: id, Title: "Shareholder Vote", Version: 1, UserID: 1, Weighted: true, Status: models.PollStatusPublished, Options: shares}, nil
+	case 15:
+		return &models.Poll{ID: id, Title: "Committee Vote", Version: 1, UserID: 1, Weighted: true, Status: models.PollStatusDraft, Options: options}, nil
+	case 16, 17:
+		return mockSchedulePoll(id), nil
+	case 18:
+		// poll 18 has closed, and more voters rejected both options than picked either
+		closesAt := time.Now().Add(-time.Hour)
+		return &models.Poll{ID: id, Title: "Charter Amendment", Version: 1, UserID: 1, NoneOfTheAbove: true, ClosesAt: &closesAt, Status: models.PollStatusPublished, Options: options}, nil
+	case 19:
+		return &models.Poll{ID: id, Title: "Board Motion", Version: 1, UserID: 1, NoneOfTheAbove: true, Status: models.PollStatusPublished, Options: options}, nil
+	case 20, 21, 22:
+		poll := mockDecisionPoll(id)
+		if outcome, decided := m.Outcomes[id]; decided {
+			poll.Outcome = outcome.Result
+			poll.OutcomeOptionID = outcome.OptionID
+		}
+		return poll, nil
+	case 9:
+		return &models.Poll{ID: id, Title: "Feedback Poll", Version: 1, UserID: 1, Kind: models.PollKindText, MaxAnswerLength: 20, TextAnswers: len(mockTextAnswers), Status: models.PollStatusPublished, Options: []*models.PollOption{}}, nil
+	}
+	return nil, repository.NotFound("poll_not_found", "poll not found")
+}
+
+// mockQuizStart is when the mock quiz polls opened
+var mockQuizStart = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// mockQuizPoll returns quiz poll 10, which is still open and gives a one
+// minute time bonus, or quiz poll 11, which has closed.
+func mockQuizPoll(id int) *models.Poll {
+	vote := func(optionID int, userID int, seconds int) *models.Vote {
+		return &models.Vote{OptionID: optionID, OptionRevision: 1, UserID: userID, CreatedAt: mockQuizStart.Add(time.Duration(seconds) * time.Second)}
+	}
+	if id == 10 {
+		return &models.Poll{ID: id, Title: "Capital of France", Version: 1, UserID: 1, Quiz: true, TimeBonus: 60, OpensAt: &mockQuizStart, Status: models.PollStatusPublished,
+			Options: []*models.PollOption{
+				{ID: 1, Text: "Paris", IsCorrect: true, Revision: 1, Version: 1, Votes: []*models.Vote{vote(1, 2, 15), vote(1, 4, 45)}},
+				{ID: 2, Text: "London", Revision: 1, Version: 1, Votes: []*models.Vote{vote(2, 3, 5)}},
+			}}
+	}
+	closesAt := mockQuizStart.Add(time.Hour)
+	return &models.Poll{ID: id, Title: "Two plus two", Version: 1, UserID: 1, Quiz: true, OpensAt: &mockQuizStart, ClosesAt: &closesAt, Status: models.PollStatusPublished,
+		Options: []*models.PollOption{
+			{ID: 1, Text: "Four", IsCorrect: true, Revision: 1, Version: 1, Votes: []*models.Vote{vote(1, 3, 10), vote(1, 4, 20)}},
+			{ID: 2, Text: "Five", Revision: 1, Version: 1, Votes: []*models.Vote{vote(2, 2, 30)}},
+		}}
+}
+
+// mockDecisionPoll returns one of the closed polls with decision rules:
+// poll 20 needs half of organization 1 to vote and two thirds for an option,
+// poll 21 needs five voters and poll 22 is a weighted poll needing unanimity.
+func mockDecisionPoll(id int) *models.Poll {
+	orgID := 1
+	closesAt := time.Now().Add(-time.Hour)
+	vote := func(optionID int, userID int) *models.Vote {
+		return &models.Vote{OptionID: optionID, OptionRevision: 1, UserID: userID}
+	}
+	options := []*models.PollOption{
+		{ID: 1, Text: "Yes", Revision: 1, Version: 1, Votes: []*models.Vote{vote(1, 2), vote(1, 3)}},
+		{ID: 2, Text: "No", Revision: 1, Version: 1, Votes: []*models.Vote{vote(2, 4)}},
+	}
+	switch id {
+	case 20:
+		return &models.Poll{ID: id, Title: "Budget Approval", Version: 1, UserID: 1, OrganizationID: &orgID, MembersOnly: true, QuorumPercent: 50, Threshold: models.ThresholdSupermajority, ClosesAt: &closesAt, Status: models.PollStatusPublished, Options: options}
+	case 21:
+		return &models.Poll{ID: id, Title: "Bylaw Change", Version: 1, UserID: 1, Quorum: 5, Threshold: models.ThresholdMajority, ClosesAt: &closesAt, Status: models.PollStatusPublished, Options: options}
+	}
+	return &models.Poll{ID: id, Title: "Merger", Version: 1, UserID: 1, Weighted: true, Threshold: models.ThresholdUnanimity, ClosesAt: &closesAt, Status: models.PollStatusPublished, Options: options}
+}
+
+// mockSlotStart is when the first slot of the mock scheduling polls starts
+var mockSlotStart = time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+
+// mockSchedulePoll returns scheduling poll 16, which is still open, or
+// scheduling poll 17, which was finalized on its second slot.
+func mockSchedulePoll(id int) *models.Poll {
+	slot := func(optionID int, text string, days int, timeZone string) *models.PollOption {
+		startsAt := mockSlotStart.AddDate(0, 0, days)
+		endsAt := startsAt.Add(2 * time.Hour)
+		return &models.PollOption{ID: optionID, Text: text, StartsAt: &startsAt, EndsAt: &endsAt, TimeZone: timeZone, Position: optionID - 1, Revision: 1, Version: 1, Votes: []*models.Vote{}}
+	}
+	poll := &models.Poll{ID: id, Title: "Team Offsite", Description: "Planning for next year", Version: 1, UserID: 1, Kind: models.PollKindSchedule, Status: models.PollStatusPublished,
+		Options: []*models.PollOption{
+			slot(1, "Monday morning", 0, "Europe/Berlin"),
+			slot(2, "Tuesday morning", 1, "Europe/Berlin"),
+			slot(3, "Wednesday, New York office", 2, "America/New_York"),
+		}}
+	if id == 17 {
+		finalOptionID := 2
+		poll.FinalOptionID = &finalOptionID
+	}
+	return poll
 }
 
 func (m *MockDBRepo) GetPollOptions(id int) ([]*models.PollOption, error) {
 	return nil, nil
 }
 
-func (m *MockDBRepo) UpdatePollByID(id int, data models.Poll) error {
+func (m *MockDBRepo) UpdatePollByID(id int, data models.Poll, editorID int) error {
+	if data.Version != mockVersion {
+		return errMockVersionMismatch
+	}
 	return nil
 }
 
-func (m *MockDBRepo) DeletePollByID(id int) error {
+func (m *MockDBRepo) PublishPoll(id int) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) DeletePollByID(id int, version int) error {
+	if version != mockVersion {
+		return errMockVersionMismatch
+	}
 	if id == 1 {
 		return nil
 	}
@@ -67,18 +261,99 @@ func (m *MockDBRepo) DeletePollByID(id int) error {
 	return errors.New("database error")
 }
 
-func (m *MockDBRepo) AddPollOptions(pollId int, options []models.PollOption) error {
+func (m *MockDBRepo) AddPollOptions(pollId int, options []models.PollOption, editorID int) error {
 	if pollId == 2 {
 		return errors.New("database error")
 	}
 	return nil
 }
 
-func (m *MockDBRepo) UpdateOptionByID(id int, text string) error {
+func (m *MockDBRepo) UpdateOptionByID(id int, data models.PollOption, editorID int, resetVotes bool) error {
+	if data.Version != mockVersion {
+		return errMockVersionMismatch
+	}
+	return nil
+}
+
+func (m *MockDBRepo) GetPollHistory(pollID int) (*models.PollHistory, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	return &models.PollHistory{
+		PollID:    pollID,
+		Revisions: []*models.PollRevision{{Revision: 1, Title: "Test Poll"}},
+		Options:   []*models.OptionRevision{{OptionID: 1, Revision: 1, Text: "Yes"}, {OptionID: 2, Revision: 1, Text: "No"}},
+	}, nil
+}
+
+func (m *MockDBRepo) DeleteOptionByID(id int, version int) error {
+	if version != mockVersion {
+		return errMockVersionMismatch
+	}
+	return nil
+}
+
+func (m *MockDBRepo) SetOptionImage(optionID int, version int, imageKey string, thumbnailKey string) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	if version != mockVersion {
+		return errMockVersionMismatch
+	}
+	return nil
+}
+
+// mockSuggestionLimitUser has used up the suggestion limit of every poll
+const mockSuggestionLimitUser = 7
+
+func (m *MockDBRepo) SuggestOption(pollID int, option models.PollOption, userID int) (*models.PollOption, error) {
+	if userID == mockSuggestionLimitUser {
+		return nil, repository.Forbidden("suggestion_limit_reached", "you have reached the suggestion limit of this poll")
+	}
+	option.ID = 12
+	option.Status = models.OptionStatusPending
+	option.SuggestedBy = &userID
+	return &option, nil
+}
+
+func (m *MockDBRepo) GetOptionSuggestions(pollID int, status string) ([]*models.PollOption, error) {
+	suggestions := []*models.PollOption{}
+	if pollID != 8 {
+		return suggestions, nil
+	}
+	suggester := 4
+	for _, option := range []*models.PollOption{
+		{ID: 10, Text: "Pizza party", Status: models.OptionStatusPending, SuggestedBy: &suggester},
+		{ID: 11, Text: "Bowling", Status: models.OptionStatusRejected, SuggestedBy: &suggester},
+	} {
+		if status == "" || option.Status == status {
+			suggestions = append(suggestions, option)
+		}
+	}
+	return suggestions, nil
+}
+
+func (m *MockDBRepo) ReviewSuggestion(pollID int, optionID int, status string) error {
+	if pollID == 8 && (optionID == 10 || optionID == 11) {
+		return nil
+	}
+	return repository.NotFound("suggestion_not_found", "suggestion not found")
+}
+
+func (m *MockDBRepo) ReorderOptions(pollID int, optionIDs []int, version int) error {
+	if version != mockVersion {
+		return errMockVersionMismatch
+	}
 	return nil
 }
 
-func (m *MockDBRepo) DeleteOptionByID(id int) error {
+func (m *MockDBRepo) ReplacePollOptions(pollID int, options []models.PollOption, version int, editorID int) error {
+	if version != mockVersion {
+		return errMockVersionMismatch
+	}
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
 	return nil
 }
 
@@ -86,7 +361,18 @@ func (m *MockDBRepo) Vote(pollID int, optionID int, userID int) error {
 	return nil
 }
 
-func (m *MockDBRepo) Unvote(optionID int, userID int) error {
+func (m *MockDBRepo) Unvote(pollID int, optionID int, userID int) error {
+	return nil
+}
+
+func (m *MockDBRepo) GuestVote(pollID int, optionID int, guestToken string, ip string) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) GuestUnvote(pollID int, guestToken string) error {
 	return nil
 }
 
@@ -95,8 +381,551 @@ func (m *MockDBRepo) GetOptionVotes(optionID int) ([]*models.Vote, error) {
 }
 
 func (m *MockDBRepo) IsPollOwner(pollID int, userID int) bool {
-	if pollID == 1 && userID == 1 || pollID == 2 && userID == 1 {
+	if (pollID == 1 || pollID == 2 || pollID == 4 || pollID == 5 || pollID == 7 || pollID == 8 || pollID == 9 || pollID == 10 || pollID == 11 || pollID >= 12 && pollID <= 22) && userID == 1 || pollID == 3 && userID == 3 {
 		return true
 	}
 	return false
 }
+
+// mockOrgRoles maps user IDs to their role in organization 1
+var mockOrgRoles = map[int]string{
+	1: models.OrgRoleOwner,
+	2: models.OrgRoleAdmin,
+	3: models.OrgRoleMember,
+}
+
+func (m *MockDBRepo) CreateOrganization(data models.Organization, ownerID int) (*models.Organization, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	data.ID = 1
+	return &data, nil
+}
+
+func (m *MockDBRepo) GetOrganizationByID(id int) (*models.Organization, error) {
+	if id != 1 {
+		return nil, repository.NotFound("organization_not_found", "organization not found")
+	}
+	return &models.Organization{ID: 1, Name: "Test Org"}, nil
+}
+
+func (m *MockDBRepo) GetUserOrganizations(userID int) ([]*models.Organization, error) {
+	return []*models.Organization{}, nil
+}
+
+func (m *MockDBRepo) GetOrganizationMembers(orgID int) ([]*models.OrganizationMember, error) {
+	members := []*models.OrganizationMember{}
+	if orgID != 1 {
+		return members, nil
+	}
+	for userID, role := range mockOrgRoles {
+		members = append(members, &models.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role})
+	}
+	return members, nil
+}
+
+func (m *MockDBRepo) GetOrganizationRole(orgID int, userID int) string {
+	if orgID != 1 {
+		return ""
+	}
+	return mockOrgRoles[userID]
+}
+
+func (m *MockDBRepo) AddOrganizationMember(orgID int, userID int, role string) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) UpdateOrganizationMemberRole(orgID int, userID int, role string) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) RemoveOrganizationMember(orgID int, userID int) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) GetOrganizationPolls(orgID int) ([]*models.Poll, error) {
+	return []*models.Poll{}, nil
+}
+
+// mockCollaboratorRoles maps user IDs to their collaborator role on polls 1 and 5
+var mockCollaboratorRoles = map[int]string{
+	5: models.CollaboratorRoleEditor,
+	6: models.CollaboratorRoleViewer,
+}
+
+func (m *MockDBRepo) AddPollCollaborator(pollID int, userID int, role string) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) GetPollCollaborators(pollID int) ([]*models.PollCollaborator, error) {
+	collaborators := []*models.PollCollaborator{}
+	if pollID != 1 && pollID != 5 {
+		return collaborators, nil
+	}
+	for userID, role := range mockCollaboratorRoles {
+		collaborators = append(collaborators, &models.PollCollaborator{PollID: pollID, UserID: userID, Role: role})
+	}
+	return collaborators, nil
+}
+
+func (m *MockDBRepo) GetPollCollaboratorRole(pollID int, userID int) string {
+	if pollID != 1 && pollID != 5 {
+		return ""
+	}
+	return mockCollaboratorRoles[userID]
+}
+
+func (m *MockDBRepo) RemovePollCollaborator(pollID int, userID int) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) CreateSurvey(data models.Survey) (*models.Survey, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	data.ID = 1
+	for i, question := range data.Questions {
+		question.Position = i
+		question.Poll.ID = i + 1
+		question.Poll.UserID = data.UserID
+		question.Poll.Status = data.Status
+	}
+	return &data, nil
+}
+
+func (m *MockDBRepo) GetSurveyByID(id int) (*models.Survey, error) {
+	question := func(position int, pollID int, required bool) *models.SurveyQuestion {
+		poll, _ := m.GetPollByID(pollID)
+		kind := models.QuestionKindChoice
+		if poll.IsText() {
+			kind = models.QuestionKindText
+		}
+		return &models.SurveyQuestion{Position: position, Kind: kind, Required: required, Poll: poll}
+	}
+
+	switch id {
+	case 1:
+		// poll 4 is required, poll 5 is optional
+		return &models.Survey{ID: id, Title: "Team Survey", UserID: 1, Status: models.SurveyStatusPublished,
+			Questions: []*models.SurveyQuestion{question(0, 4, true), question(1, 5, false)}}, nil
+	case 2:
+		return &models.Survey{ID: id, Title: "Draft Survey", UserID: 1, Status: models.SurveyStatusDraft,
+			Questions: []*models.SurveyQuestion{question(0, 1, true)}}, nil
+	case 3:
+		// answering No to the first question skips the second
+		first := question(0, 4, true)
+		first.Skip = []branching.SkipRule{{When: branching.Condition{Question: 0, Operator: branching.OpEquals, Option: 1}, To: 2}}
+		return &models.Survey{ID: id, Title: "Branching Survey", UserID: 1, Status: models.SurveyStatusPublished,
+			Questions: []*models.SurveyQuestion{first, question(1, 5, true), question(2, 8, true)}}, nil
+	case 5:
+		// poll 10 is still open, so the quiz has not closed yet
+		return &models.Survey{ID: id, Title: "Quiz", UserID: 1, Status: models.SurveyStatusPublished, Quiz: true,
+			Questions: []*models.SurveyQuestion{question(0, 10, true), question(1, 11, true)}}, nil
+	case 6:
+		return &models.Survey{ID: id, Title: "Finished Quiz", UserID: 1, Status: models.SurveyStatusPublished, Quiz: true,
+			Questions: []*models.SurveyQuestion{question(0, 11, true)}}, nil
+	case 4:
+		// poll 9 asks for free text
+		return &models.Survey{ID: id, Title: "Feedback Survey", UserID: 1, Status: models.SurveyStatusPublished,
+			Questions: []*models.SurveyQuestion{question(0, 4, true), question(1, 9, true)}}, nil
+	}
+	return nil, repository.NotFound("survey_not_found", "survey not found")
+}
+
+func (m *MockDBRepo) PublishSurvey(id int) error {
+	return nil
+}
+
+func (m *MockDBRepo) SubmitSurveyResponse(surveyID int, userID int, answers []models.SurveyAnswer, cleared []int, completed bool) (*models.SurveyResponse, error) {
+	return &models.SurveyResponse{SurveyID: surveyID, UserID: userID, Completed: completed}, nil
+}
+
+// GetSurveyAnswers remembers that user 3 answered Yes and No to the first two
+// questions of survey 3
+func (m *MockDBRepo) GetSurveyAnswers(surveyID int, userID int) ([]models.SurveyAnswer, error) {
+	if surveyID == 3 && userID == 3 {
+		return []models.SurveyAnswer{{PollID: 4, OptionID: 1}, {PollID: 5, OptionID: 2}}, nil
+	}
+	if surveyID == 5 && userID == 2 {
+		return []models.SurveyAnswer{{PollID: 10, OptionID: 1}}, nil
+	}
+	return []models.SurveyAnswer{}, nil
+}
+
+func (m *MockDBRepo) GetSurveyResponseStats(surveyID int) (*models.SurveyResponseStats, error) {
+	return &models.SurveyResponseStats{Responses: 4, Completed: 3}, nil
+}
+
+// mockAdminID is the only user with admin rights
+const mockAdminID = 9
+
+// mockTextAnswers are the answers to poll 9, newest first
+var mockTextAnswers = []*models.TextAnswer{
+	{ID: 3, PollID: 9, UserID: 4, Text: "More coffee please", Flagged: true},
+	{ID: 2, PollID: 9, UserID: 3, Text: "Buy a coffee machine", Hidden: true},
+	{ID: 1, PollID: 9, UserID: 2, Text: "Better coffee"},
+}
+
+func (m *MockDBRepo) SaveTextAnswer(pollID int, userID int, text string) (*models.TextAnswer, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	return &models.TextAnswer{ID: 4, PollID: pollID, UserID: userID, Text: text}, nil
+}
+
+func (m *MockDBRepo) GetTextAnswers(pollID int, filter models.TextAnswerFilter) (*models.TextAnswerPage, error) {
+	matches := []*models.TextAnswer{}
+	for _, answer := range mockTextAnswers {
+		if answer.PollID != pollID || !strings.Contains(strings.ToLower(answer.Text), strings.ToLower(filter.Search)) {
+			continue
+		}
+		if filter.Hidden != nil && answer.Hidden != *filter.Hidden || filter.Flagged != nil && answer.Flagged != *filter.Flagged {
+			continue
+		}
+		matches = append(matches, answer)
+	}
+	page := &models.TextAnswerPage{Answers: []*models.TextAnswer{}, Total: len(matches), Page: filter.Page, PageSize: filter.PageSize}
+	for i := (filter.Page - 1) * filter.PageSize; i < len(matches) && i < filter.Page*filter.PageSize; i++ {
+		page.Answers = append(page.Answers, matches[i])
+	}
+	return page, nil
+}
+
+func (m *MockDBRepo) GetVisibleTextAnswers(pollID int) ([]string, error) {
+	texts := []string{}
+	for _, answer := range mockTextAnswers {
+		if answer.PollID == pollID && !answer.Hidden {
+			texts = append(texts, answer.Text)
+		}
+	}
+	return texts, nil
+}
+
+func (m *MockDBRepo) ModerateTextAnswer(pollID int, answerID int, hidden *bool, flagged *bool) (*models.TextAnswer, error) {
+	for _, answer := range mockTextAnswers {
+		if answer.PollID == pollID && answer.ID == answerID {
+			moderated := *answer
+			if hidden != nil {
+				moderated.Hidden = *hidden
+			}
+			if flagged != nil {
+				moderated.Flagged = *flagged
+			}
+			return &moderated, nil
+		}
+	}
+	return nil, repository.NotFound("answer_not_found", "answer not found")
+}
+
+// mockBallots are the ballots of poll 12, the example election of the
+// Wikipedia article on the Schulze method. Instant-runoff elects A, the
+// Schulze method elects E.
+func mockBallots() []*models.Ballot {
+	groups := []struct {
+		count   int
+		ranking []int
+	}{
+		{5, []int{1, 3, 2, 5, 4}},
+		{5, []int{1, 4, 5, 3, 2}},
+		{8, []int{2, 5, 4, 1, 3}},
+		{3, []int{3, 1, 2, 5, 4}},
+		{7, []int{3, 1, 5, 2, 4}},
+		{2, []int{3, 2, 1, 4, 5}},
+		{7, []int{4, 3, 5, 2, 1}},
+		{8, []int{5, 2, 1, 4, 3}},
+	}
+	ballots := []*models.Ballot{}
+	for _, group := range groups {
+		for range group.count {
+			ballots = append(ballots, &models.Ballot{ID: len(ballots) + 1, PollID: 12, UserID: len(ballots) + 100, Ranking: group.ranking})
+		}
+	}
+	return ballots
+}
+
+func (m *MockDBRepo) SaveBallot(pollID int, userID int, ranking []int) (*models.Ballot, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	return &models.Ballot{ID: 46, PollID: pollID, UserID: userID, Ranking: ranking}, nil
+}
+
+func (m *MockDBRepo) GetBallots(pollID int) ([]*models.Ballot, error) {
+	if pollID != 12 {
+		return []*models.Ballot{}, nil
+	}
+	return mockBallots(), nil
+}
+
+func (m *MockDBRepo) SaveQuadraticBallot(pollID int, userID int, votes []models.QuadraticVote) (*models.QuadraticBallot, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	return &models.QuadraticBallot{PollID: pollID, UserID: userID, Votes: votes, Credits: models.Credits(votes)}, nil
+}
+
+// GetQuadraticBallots returns the ballots of poll 13. User 3 spends the whole
+// budget of 25 credits, user 2 spent 4 of 21 credits on option 4, which has
+// been removed since.
+func (m *MockDBRepo) GetQuadraticBallots(pollID int) ([]*models.QuadraticBallot, error) {
+	if pollID != 13 {
+		return []*models.QuadraticBallot{}, nil
+	}
+	return []*models.QuadraticBallot{
+		{PollID: 13, UserID: 3, Votes: []models.QuadraticVote{{OptionID: 1, Votes: 3}, {OptionID: 2, Votes: 4}}, Credits: 25},
+		{PollID: 13, UserID: 2, Votes: []models.QuadraticVote{{OptionID: 1, Votes: 4}, {OptionID: 3, Votes: 1}, {OptionID: 4, Votes: 2}}, Credits: 21},
+	}, nil
+}
+
+// mockVoterWeights is the allowlist of the weighted polls 14 and 15
+var mockVoterWeights = []*models.VoterWeight{
+	{UserID: 2, Username: "alice", Weight: 100},
+	{UserID: 3, Username: "bob", Weight: 50},
+	{UserID: 4, Username: "carol", Weight: 300},
+	{UserID: 5, Username: "dave", Weight: 10},
+}
+
+func (m *MockDBRepo) GetVoterWeights(pollID int) ([]*models.VoterWeight, error) {
+	if pollID != 14 && pollID != 15 && pollID != 22 {
+		return []*models.VoterWeight{}, nil
+	}
+	return mockVoterWeights, nil
+}
+
+func (m *MockDBRepo) GetVoterWeight(pollID int, userID int) (int, error) {
+	weights, _ := m.GetVoterWeights(pollID)
+	for _, weight := range weights {
+		if weight.UserID == userID {
+			return weight.Weight, nil
+		}
+	}
+	return 0, repository.NotFound("voter_not_found", "the user is not on the allowlist of this poll")
+}
+
+func (m *MockDBRepo) SetVoterWeight(pollID int, userID int, weight int) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) RemoveVoterWeight(pollID int, userID int) error {
+	_, err := m.GetVoterWeight(pollID, userID)
+	return err
+}
+
+func (m *MockDBRepo) ReplaceVoterWeights(pollID int, weights []models.VoterWeight) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+// mockAvailabilities are the answers to the scheduling polls 16 and 17. The
+// second slot suits everyone, the first and third suit two voters each.
+var mockAvailabilities = []*models.Availability{
+	{UserID: 2, Answers: []models.SlotAnswer{{OptionID: 1, Answer: models.AnswerYes}, {OptionID: 2, Answer: models.AnswerIfNeedBe}, {OptionID: 3, Answer: models.AnswerNo}}},
+	{UserID: 3, Answers: []models.SlotAnswer{{OptionID: 1, Answer: models.AnswerNo}, {OptionID: 2, Answer: models.AnswerYes}, {OptionID: 3, Answer: models.AnswerYes}}},
+	{UserID: 4, Answers: []models.SlotAnswer{{OptionID: 1, Answer: models.AnswerYes}, {OptionID: 2, Answer: models.AnswerIfNeedBe}, {OptionID: 3, Answer: models.AnswerYes}}},
+}
+
+func (m *MockDBRepo) SaveAvailability(pollID int, userID int, answers []models.SlotAnswer) (*models.Availability, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	return &models.Availability{PollID: pollID, UserID: userID, Answers: answers}, nil
+}
+
+func (m *MockDBRepo) GetAvailabilities(pollID int) ([]*models.Availability, error) {
+	if pollID != 16 && pollID != 17 {
+		return []*models.Availability{}, nil
+	}
+	return mockAvailabilities, nil
+}
+
+func (m *MockDBRepo) FinalizePoll(pollID int, optionID int) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	return nil
+}
+
+// GetFinalizedMeetings returns poll 17 to its owner and to the voters who can
+// make its final slot.
+func (m *MockDBRepo) GetFinalizedMeetings(userID int) ([]*models.Meeting, error) {
+	poll := mockSchedulePoll(17)
+	option := poll.FinalOption()
+	meeting := &models.Meeting{PollID: poll.ID, OptionID: option.ID, Title: poll.Title, Description: poll.Description, Slot: option.Text,
+		StartsAt: *option.StartsAt, EndsAt: *option.EndsAt, TimeZone: option.TimeZone, OrganizerID: poll.UserID}
+
+	if userID == poll.UserID {
+		return []*models.Meeting{meeting}, nil
+	}
+	for _, availability := range mockAvailabilities {
+		for _, answer := range availability.Answers {
+			if availability.UserID == userID && answer.OptionID == option.ID && answer.Answer != models.AnswerNo {
+				return []*models.Meeting{meeting}, nil
+			}
+		}
+	}
+	return []*models.Meeting{}, nil
+}
+
+// mockBlankVotes are the blank votes of the polls 18 and 19. Users 3 and 4
+// vote for none of the above, user 5 abstains.
+var mockBlankVotes = []*models.BlankVote{
+	{UserID: 3, Choice: models.BlankNoneOfTheAbove},
+	{UserID: 4, Choice: models.BlankNoneOfTheAbove},
+	{UserID: 5, Choice: models.BlankAbstain},
+}
+
+func (m *MockDBRepo) SaveBlankVote(pollID int, userID int, choice string) (*models.BlankVote, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	return &models.BlankVote{PollID: pollID, UserID: userID, Choice: choice}, nil
+}
+
+func (m *MockDBRepo) RemoveBlankVote(pollID int, userID int) error {
+	votes, _ := m.GetBlankVotes(pollID)
+	for _, vote := range votes {
+		if vote.UserID == userID {
+			return nil
+		}
+	}
+	return repository.NotFound("blank_vote_not_found", "you have not cast a blank vote on this poll")
+}
+
+func (m *MockDBRepo) GetBlankVotes(pollID int) ([]*models.BlankVote, error) {
+	if pollID != 18 && pollID != 19 {
+		return []*models.BlankVote{}, nil
+	}
+	return mockBlankVotes, nil
+}
+
+func (m *MockDBRepo) ReopenPoll(pollID int, version int, editorID int) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	if version != mockVersion {
+		return errMockVersionMismatch
+	}
+	return nil
+}
+
+func (m *MockDBRepo) GetUndecidedPolls(now time.Time) ([]*models.Poll, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	polls := []*models.Poll{}
+	for _, id := range []int{20, 21, 22} {
+		poll := mockDecisionPoll(id)
+		if _, decided := m.Outcomes[id]; !decided && poll.AwaitsOutcome(now) {
+			polls = append(polls, poll)
+		}
+	}
+	return polls, nil
+}
+
+func (m *MockDBRepo) SetPollOutcome(pollID int, outcome models.Outcome) error {
+	if _, decided := m.Outcomes[pollID]; decided {
+		return repository.Conflict("outcome_decided", "the outcome of this poll has already been decided")
+	}
+	if m.Outcomes == nil {
+		m.Outcomes = map[int]models.Outcome{}
+	}
+	m.Outcomes[pollID] = outcome
+	return nil
+}
+
+func (m *MockDBRepo) RecordAuditEvent(event models.AuditEvent) error {
+	if m.ShouldFail {
+		return errors.New("database error")
+	}
+	m.AuditEvents = append(m.AuditEvents, event)
+	return nil
+}
+
+func (m *MockDBRepo) GetAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	events := []*models.AuditEvent{}
+	for i := range m.AuditEvents {
+		event := m.AuditEvents[i]
+		if filter.PollID != nil && (event.PollID == nil || *event.PollID != *filter.PollID) {
+			continue
+		}
+		if filter.ActorID != nil && (event.ActorID == nil || *event.ActorID != *filter.ActorID) {
+			continue
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+func (m *MockDBRepo) IsAdmin(userID int) bool {
+	return userID == mockAdminID
+}
+
+// mockDeletedPollID is the only poll in the trash, owned by user 1. Option 3
+// of poll 1 is the only option in the trash.
+const mockDeletedPollID = 6
+
+func (m *MockDBRepo) GetTrash(userID int) (*models.Trash, error) {
+	if m.ShouldFail {
+		return nil, errors.New("database error")
+	}
+	trash := &models.Trash{Polls: []*models.Poll{}, Options: []*models.DeletedOption{}}
+	poll, _ := m.GetDeletedPollByID(mockDeletedPollID)
+	if poll.UserID == userID || models.CanManagePolls(m.GetOrganizationRole(*poll.OrganizationID, userID)) {
+		trash.Polls = append(trash.Polls, poll)
+	}
+	if userID == 1 {
+		trash.Options = append(trash.Options, &models.DeletedOption{PollID: 1, OptionID: 3, Text: "Maybe", DeletedAt: time.Now()})
+	}
+	return trash, nil
+}
+
+func (m *MockDBRepo) GetDeletedPollByID(id int) (*models.Poll, error) {
+	if id != mockDeletedPollID {
+		return nil, repository.NotFound("poll_not_found", "poll not found in trash")
+	}
+	// the deleted poll belongs to organization 1, so its admins manage it too
+	orgID := 1
+	deletedAt := time.Now()
+	return &models.Poll{ID: id, Title: "Deleted Poll", UserID: 1, OrganizationID: &orgID, Status: models.PollStatusPublished, DeletedAt: &deletedAt}, nil
+}
+
+func (m *MockDBRepo) RestorePoll(id int) error {
+	if id != mockDeletedPollID {
+		return repository.NotFound("poll_not_found", "poll not found in trash")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) RestoreOption(pollID int, optionID int) error {
+	if pollID != 1 || optionID != 3 {
+		return repository.NotFound("option_not_found", "option not found in trash")
+	}
+	return nil
+}
+
+func (m *MockDBRepo) PurgeDeleted(retention time.Duration) (int64, error) {
+	if m.ShouldFail {
+		return 0, errors.New("database error")
+	}
+	return 0, nil
+}
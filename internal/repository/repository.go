@@ -3,23 +3,96 @@ package repository
 import (
 	"database/sql"
 	"polling/internal/models"
+	"time"
 )
 
 type Repository interface {
 	Connection() *sql.DB
-	CreateUser(data models.User) error
+	CreateUser(data models.User) (int, error)
 	GetUserByUsername(username string) (*models.User, error)
+	GetUserIDsByUsernames(usernames []string) (map[string]int, error)
 	CreatePoll(data models.Poll) (*models.Poll, error)
+	CreatePollWithOptions(data models.Poll) (*models.Poll, error)
 	GetAllPolls() ([]*models.Poll, error)
 	GetPollOptions(id int) ([]*models.PollOption, error)
-	AddPollOptions(pollId int, options []models.PollOption) error
+	AddPollOptions(pollId int, options []models.PollOption, editorID int) error
 	GetPollByID(id int) (*models.Poll, error)
-	UpdatePollByID(id int, data models.Poll) error
-	DeletePollByID(id int) error
-	UpdateOptionByID(id int, text string) error
-	DeleteOptionByID(id int) error
+	UpdatePollByID(id int, data models.Poll, editorID int) error
+	PublishPoll(id int) error
+	DeletePollByID(id int, version int) error
+	UpdateOptionByID(id int, data models.PollOption, editorID int, resetVotes bool) error
+	DeleteOptionByID(id int, version int) error
+	SetOptionImage(optionID int, version int, imageKey string, thumbnailKey string) error
+	SuggestOption(pollID int, option models.PollOption, userID int) (*models.PollOption, error)
+	GetOptionSuggestions(pollID int, status string) ([]*models.PollOption, error)
+	ReviewSuggestion(pollID int, optionID int, status string) error
+	ReorderOptions(pollID int, optionIDs []int, version int) error
+	ReplacePollOptions(pollID int, options []models.PollOption, version int, editorID int) error
 	Vote(poll_id int, option_id int, user_id int) error
 	GetOptionVotes(option_id int) ([]*models.Vote, error)
 	IsPollOwner(pollID int, userID int) bool
-	Unvote(option_id int, user_id int) error
+	Unvote(poll_id int, option_id int, user_id int) error
+	GuestVote(pollID int, optionID int, guestToken string, ip string) error
+	GuestUnvote(pollID int, guestToken string) error
+	GetPollHistory(pollID int) (*models.PollHistory, error)
+	GetTrash(userID int) (*models.Trash, error)
+	GetDeletedPollByID(id int) (*models.Poll, error)
+	RestorePoll(id int) error
+	RestoreOption(pollID int, optionID int) error
+	PurgeDeleted(retention time.Duration) (int64, error)
+
+	CreateOrganization(data models.Organization, ownerID int) (*models.Organization, error)
+	GetOrganizationByID(id int) (*models.Organization, error)
+	GetUserOrganizations(userID int) ([]*models.Organization, error)
+	GetOrganizationMembers(orgID int) ([]*models.OrganizationMember, error)
+	GetOrganizationRole(orgID int, userID int) string
+	AddOrganizationMember(orgID int, userID int, role string) error
+	UpdateOrganizationMemberRole(orgID int, userID int, role string) error
+	RemoveOrganizationMember(orgID int, userID int) error
+	GetOrganizationPolls(orgID int) ([]*models.Poll, error)
+
+	AddPollCollaborator(pollID int, userID int, role string) error
+	GetPollCollaborators(pollID int) ([]*models.PollCollaborator, error)
+	GetPollCollaboratorRole(pollID int, userID int) string
+	RemovePollCollaborator(pollID int, userID int) error
+
+	CreateSurvey(data models.Survey) (*models.Survey, error)
+	GetSurveyByID(id int) (*models.Survey, error)
+	PublishSurvey(id int) error
+	SubmitSurveyResponse(surveyID int, userID int, answers []models.SurveyAnswer, cleared []int, completed bool) (*models.SurveyResponse, error)
+	GetSurveyAnswers(surveyID int, userID int) ([]models.SurveyAnswer, error)
+	GetSurveyResponseStats(surveyID int) (*models.SurveyResponseStats, error)
+
+	SaveTextAnswer(pollID int, userID int, text string) (*models.TextAnswer, error)
+	GetTextAnswers(pollID int, filter models.TextAnswerFilter) (*models.TextAnswerPage, error)
+	GetVisibleTextAnswers(pollID int) ([]string, error)
+	ModerateTextAnswer(pollID int, answerID int, hidden *bool, flagged *bool) (*models.TextAnswer, error)
+
+	SaveBallot(pollID int, userID int, ranking []int) (*models.Ballot, error)
+	GetBallots(pollID int) ([]*models.Ballot, error)
+	SaveQuadraticBallot(pollID int, userID int, votes []models.QuadraticVote) (*models.QuadraticBallot, error)
+	GetQuadraticBallots(pollID int) ([]*models.QuadraticBallot, error)
+
+	GetVoterWeights(pollID int) ([]*models.VoterWeight, error)
+	GetVoterWeight(pollID int, userID int) (int, error)
+	SetVoterWeight(pollID int, userID int, weight int) error
+	RemoveVoterWeight(pollID int, userID int) error
+	ReplaceVoterWeights(pollID int, weights []models.VoterWeight) error
+
+	SaveAvailability(pollID int, userID int, answers []models.SlotAnswer) (*models.Availability, error)
+	GetAvailabilities(pollID int) ([]*models.Availability, error)
+	FinalizePoll(pollID int, optionID int) error
+	GetFinalizedMeetings(userID int) ([]*models.Meeting, error)
+
+	SaveBlankVote(pollID int, userID int, choice string) (*models.BlankVote, error)
+	RemoveBlankVote(pollID int, userID int) error
+	GetBlankVotes(pollID int) ([]*models.BlankVote, error)
+	ReopenPoll(pollID int, version int, editorID int) error
+
+	GetUndecidedPolls(now time.Time) ([]*models.Poll, error)
+	SetPollOutcome(pollID int, outcome models.Outcome) error
+
+	RecordAuditEvent(event models.AuditEvent) error
+	GetAuditEvents(filter models.AuditFilter) ([]*models.AuditEvent, error)
+	IsAdmin(userID int) bool
 }
@@ -0,0 +1,43 @@
+// Package storage keeps uploaded files, such as option images, behind a
+// small interface so the backend can be swapped without touching handlers.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when no blob is stored under a key.
+	ErrNotFound = errors.New("blob not found")
+
+	// ErrInvalidKey is returned for keys that are empty, absolute or try to
+	// climb out of the store with "..".
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore stores blobs under slash separated keys such as
+// "options/12/5f3a.png".
+type BlobStore interface {
+	// Put stores the content of r under key, replacing any earlier blob.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Get opens the blob stored under key. The caller has to close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under key. Deleting a missing blob is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey reports whether key can be used with a BlobStore.
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+
+	return path.Clean(key) == key && key != "." && !strings.HasPrefix(key, "../") && key != ".."
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"options/1/image.png", true},
+		{"image.png", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../secret", false},
+		{"options/../../secret", false},
+		{"options//image.png", false},
+		{"options/./image.png", false},
+		{`options\image.png`, false},
+		{"..", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.valid {
+			t.Errorf("ValidKey(%q) = %v, expected %v", tt.key, got, tt.valid)
+		}
+	}
+}
+
+func TestStores(t *testing.T) {
+	local, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create local store: %v", err)
+	}
+
+	stores := map[string]BlobStore{
+		"local":  local,
+		"memory": NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			err := store.Put(ctx, "options/1/a.png", strings.NewReader("first"))
+			if err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+
+			err = store.Put(ctx, "options/1/a.png", strings.NewReader("second"))
+			if err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+
+			blob, err := store.Get(ctx, "options/1/a.png")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+
+			data, _ := io.ReadAll(blob)
+			blob.Close()
+
+			if string(data) != "second" {
+				t.Errorf("expected the blob to be replaced, got %q", data)
+			}
+
+			err = store.Delete(ctx, "options/1/a.png")
+			if err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+
+			_, err = store.Get(ctx, "options/1/a.png")
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound after delete, got %v", err)
+			}
+
+			err = store.Delete(ctx, "options/1/a.png")
+			if err != nil {
+				t.Errorf("expected deleting a missing blob to succeed, got %v", err)
+			}
+
+			err = store.Put(ctx, "../escape", strings.NewReader("x"))
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("expected ErrInvalidKey, got %v", err)
+			}
+		})
+	}
+}
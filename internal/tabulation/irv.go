@@ -0,0 +1,110 @@
+package tabulation
+
+// Count is the number of ballots counting for a candidate in a round.
+type Count struct {
+	Candidate int `json:"candidate"`
+	Votes     int `json:"votes"`
+}
+
+// Round is one round of an instant-runoff count. Exhausted counts the
+// ballots that rank none of the remaining candidates.
+type Round struct {
+	Counts     []Count `json:"counts"`
+	Exhausted  int     `json:"exhausted"`
+	Eliminated []int   `json:"eliminated"`
+}
+
+// RunoffResult is the outcome of an instant-runoff count. There is more than
+// one winner only when the last candidates standing are tied.
+type RunoffResult struct {
+	Rounds  []Round `json:"rounds"`
+	Winners []int   `json:"winners"`
+}
+
+// InstantRunoff counts the ballots by instant-runoff. Each round every ballot
+// counts for its highest ranked remaining candidate. A candidate with more
+// than half of the ballots still counting wins, otherwise the candidates with
+// the fewest votes are all eliminated together. When that would eliminate
+// everyone left, they win together instead.
+func InstantRunoff(candidates []int, ballots []Ballot) *RunoffResult {
+	remaining := map[int]bool{}
+
+	for _, candidate := range candidates {
+		remaining[candidate] = true
+	}
+
+	result := &RunoffResult{Rounds: []Round{}, Winners: []int{}}
+
+	for len(remaining) > 0 {
+		votes := map[int]int{}
+		round := Round{Counts: []Count{}, Eliminated: []int{}}
+
+		for _, ballot := range ballots {
+			counted := false
+
+			for _, candidate := range ballot {
+				if remaining[candidate] {
+					votes[candidate]++
+					counted = true
+					break
+				}
+			}
+
+			if !counted {
+				round.Exhausted++
+			}
+		}
+
+		live := len(ballots) - round.Exhausted
+		fewest := -1
+
+		for _, candidate := range sortedCopy(keys(remaining)) {
+			round.Counts = append(round.Counts, Count{Candidate: candidate, Votes: votes[candidate]})
+
+			if fewest == -1 || votes[candidate] < fewest {
+				fewest = votes[candidate]
+			}
+		}
+
+		for _, count := range round.Counts {
+			if 2*count.Votes > live {
+				result.Rounds = append(result.Rounds, round)
+				result.Winners = []int{count.Candidate}
+
+				return result
+			}
+		}
+
+		for _, count := range round.Counts {
+			if count.Votes == fewest {
+				round.Eliminated = append(round.Eliminated, count.Candidate)
+			}
+		}
+
+		if len(round.Eliminated) == len(remaining) {
+			round.Eliminated = []int{}
+			result.Rounds = append(result.Rounds, round)
+			result.Winners = sortedCopy(keys(remaining))
+
+			return result
+		}
+
+		for _, candidate := range round.Eliminated {
+			delete(remaining, candidate)
+		}
+
+		result.Rounds = append(result.Rounds, round)
+	}
+
+	return result
+}
+
+func keys(set map[int]bool) []int {
+	list := []int{}
+
+	for key := range set {
+		list = append(list, key)
+	}
+
+	return list
+}
@@ -0,0 +1,98 @@
+package tabulation
+
+// SchulzeResult is the outcome of the Schulze method. StrongestPaths[i][j] is
+// the strength of the strongest path from Candidates[i] to Candidates[j],
+// measured in winning votes. Ranking groups the candidates from first to
+// last place, candidates sharing a place are tied. Winners is the first
+// place, every candidate no other candidate beats on strongest paths. Cycles
+// lists the Condorcet cycles the method had to resolve.
+type SchulzeResult struct {
+	Candidates      []int   `json:"candidates"`
+	Preferences     [][]int `json:"preferences"`
+	StrongestPaths  [][]int `json:"strongest_paths"`
+	Winners         []int   `json:"winners"`
+	Ranking         [][]int `json:"ranking"`
+	CondorcetWinner *int    `json:"condorcet_winner"`
+	Cycles          [][]int `json:"cycles"`
+}
+
+// Schulze counts the ballots by the Schulze method.
+func Schulze(candidates []int, ballots []Ballot) *SchulzeResult {
+	m := Pairwise(candidates, ballots)
+	n := len(m.Candidates)
+	p := square(n)
+
+	for i := range n {
+		for j := range n {
+			if i != j && m.Beats(i, j) {
+				p[i][j] = m.Preferences[i][j]
+			}
+		}
+	}
+
+	// widest paths, as in Floyd-Warshall
+	for i := range n {
+		for j := range n {
+			if i == j {
+				continue
+			}
+
+			for k := range n {
+				if k != i && k != j {
+					p[j][k] = max(p[j][k], min(p[j][i], p[i][k]))
+				}
+			}
+		}
+	}
+
+	result := &SchulzeResult{
+		Candidates:     m.Candidates,
+		Preferences:    m.Preferences,
+		StrongestPaths: p,
+		Winners:        []int{},
+		Ranking:        [][]int{},
+		Cycles:         m.Cycles(),
+	}
+
+	if winner, ok := m.CondorcetWinner(); ok {
+		result.CondorcetWinner = &winner
+	}
+
+	// the winners are the candidates no one beats on strongest paths, the
+	// places below them are found by setting the winners aside and repeating
+	remaining := make([]int, n)
+
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	for len(remaining) > 0 {
+		place, rest := []int{}, []int{}
+
+		for _, i := range remaining {
+			beaten := false
+
+			for _, j := range remaining {
+				if p[j][i] > p[i][j] {
+					beaten = true
+					break
+				}
+			}
+
+			if beaten {
+				rest = append(rest, i)
+			} else {
+				place = append(place, m.Candidates[i])
+			}
+		}
+
+		result.Ranking = append(result.Ranking, place)
+		remaining = rest
+	}
+
+	if len(result.Ranking) > 0 {
+		result.Winners = result.Ranking[0]
+	}
+
+	return result
+}
@@ -0,0 +1,152 @@
+// Package tabulation counts ranked ballots, by instant-runoff or by the
+// Schulze method.
+package tabulation
+
+import "sort"
+
+// Ballot lists candidates from the most to the least preferred. Candidates
+// left off a ballot rank below every listed candidate and equal to each other.
+type Ballot []int
+
+// Matrix holds the pairwise preferences of the ballots. Preferences[i][j]
+// counts the ballots that rank Candidates[i] above Candidates[j].
+type Matrix struct {
+	Candidates  []int   `json:"candidates"`
+	Preferences [][]int `json:"preferences"`
+}
+
+// Pairwise builds the preference matrix of the candidates. Candidates are
+// sorted, unknown candidates are ignored and so are repeated mentions of a
+// candidate on the same ballot.
+func Pairwise(candidates []int, ballots []Ballot) *Matrix {
+	m := &Matrix{Candidates: sortedCopy(candidates)}
+	index := indexOf(m.Candidates)
+	n := len(m.Candidates)
+
+	m.Preferences = square(n)
+
+	for _, ballot := range ballots {
+		ranked := make([]bool, n)
+
+		for _, candidate := range ballot {
+			i, ok := index[candidate]
+
+			if !ok || ranked[i] {
+				continue
+			}
+
+			ranked[i] = true
+
+			// a listed candidate beats everyone not listed before it
+			for j := range n {
+				if !ranked[j] {
+					m.Preferences[i][j]++
+				}
+			}
+		}
+	}
+
+	return m
+}
+
+// Beats reports whether more ballots rank candidate i above candidate j than
+// the other way around. i and j are indexes into Candidates.
+func (m *Matrix) Beats(i int, j int) bool {
+	return m.Preferences[i][j] > m.Preferences[j][i]
+}
+
+// CondorcetWinner returns the candidate who beats every other candidate head
+// to head.
+func (m *Matrix) CondorcetWinner() (int, bool) {
+	for i := range m.Candidates {
+		winner := true
+
+		for j := range m.Candidates {
+			if i != j && !m.Beats(i, j) {
+				winner = false
+				break
+			}
+		}
+
+		if winner {
+			return m.Candidates[i], true
+		}
+	}
+
+	return 0, false
+}
+
+// Cycles returns the groups of candidates caught in a Condorcet cycle, where
+// each candidate of a group is beaten, directly or through others, by every
+// other candidate of the group. Groups and their members are sorted.
+func (m *Matrix) Cycles() [][]int {
+	n := len(m.Candidates)
+	reaches := square(n)
+
+	for i := range n {
+		for j := range n {
+			if i != j && m.Beats(i, j) {
+				reaches[i][j] = 1
+			}
+		}
+	}
+
+	for k := range n {
+		for i := range n {
+			for j := range n {
+				if reaches[i][k] == 1 && reaches[k][j] == 1 {
+					reaches[i][j] = 1
+				}
+			}
+		}
+	}
+
+	cycles := [][]int{}
+	grouped := make([]bool, n)
+
+	for i := range n {
+		if grouped[i] || reaches[i][i] == 0 {
+			continue
+		}
+
+		cycle := []int{}
+
+		for j := range n {
+			if i == j || reaches[i][j] == 1 && reaches[j][i] == 1 {
+				cycle = append(cycle, m.Candidates[j])
+				grouped[j] = true
+			}
+		}
+
+		cycles = append(cycles, cycle)
+	}
+
+	return cycles
+}
+
+func indexOf(candidates []int) map[int]int {
+	index := map[int]int{}
+
+	for i, candidate := range candidates {
+		index[candidate] = i
+	}
+
+	return index
+}
+
+func sortedCopy(candidates []int) []int {
+	sorted := append([]int{}, candidates...)
+	sort.Ints(sorted)
+
+	return sorted
+}
+
+func square(n int) [][]int {
+	rows := make([][]int, n)
+
+	for i := range rows {
+		rows[i] = make([]int, n)
+	}
+
+	return rows
+}
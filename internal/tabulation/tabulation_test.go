@@ -0,0 +1,451 @@
+package tabulation
+
+import (
+	"reflect"
+	"testing"
+)
+
+// repeat returns count copies of the ballot.
+func repeat(count int, ballot ...int) []Ballot {
+	ballots := []Ballot{}
+
+	for range count {
+		ballots = append(ballots, Ballot(ballot))
+	}
+
+	return ballots
+}
+
+// join concatenates groups of ballots.
+func join(groups ...[]Ballot) []Ballot {
+	ballots := []Ballot{}
+
+	for _, group := range groups {
+		ballots = append(ballots, group...)
+	}
+
+	return ballots
+}
+
+const (
+	a = iota + 1
+	b
+	c
+	d
+	e
+)
+
+// wikipedia is the example election of the Wikipedia article on the Schulze
+// method, with 45 voters and five candidates.
+func wikipedia() []Ballot {
+	return join(
+		repeat(5, a, c, b, e, d),
+		repeat(5, a, d, e, c, b),
+		repeat(8, b, e, d, a, c),
+		repeat(3, c, a, b, e, d),
+		repeat(7, c, a, e, b, d),
+		repeat(2, c, b, a, d, e),
+		repeat(7, d, c, e, b, a),
+		repeat(8, e, b, a, d, c),
+	)
+}
+
+// rockPaperScissors makes a beat b, b beat c and c beat a.
+func rockPaperScissors() []Ballot {
+	return join(
+		repeat(4, a, b, c),
+		repeat(3, b, c, a),
+		repeat(2, c, a, b),
+	)
+}
+
+func TestPairwise(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []int
+		ballots    []Ballot
+		expected   [][]int
+	}{
+		{
+			name:       "full ballots",
+			candidates: []int{a, b, c},
+			ballots:    join(repeat(2, a, b, c), repeat(1, c, b, a)),
+			expected:   [][]int{{0, 2, 2}, {1, 0, 2}, {1, 1, 0}},
+		},
+		{
+			name:       "unranked candidates come last",
+			candidates: []int{a, b, c},
+			ballots:    repeat(1, b),
+			expected:   [][]int{{0, 0, 0}, {1, 0, 1}, {0, 0, 0}},
+		},
+		{
+			name:       "unknown and repeated candidates are ignored",
+			candidates: []int{a, b},
+			ballots:    repeat(1, 9, b, b, a, b),
+			expected:   [][]int{{0, 0}, {1, 0}},
+		},
+		{
+			name:       "candidates are sorted",
+			candidates: []int{c, a},
+			ballots:    repeat(1, c, a),
+			expected:   [][]int{{0, 0}, {1, 0}},
+		},
+		{
+			name:       "no ballots",
+			candidates: []int{a, b},
+			ballots:    nil,
+			expected:   [][]int{{0, 0}, {0, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Pairwise(tt.candidates, tt.ballots)
+
+			if !reflect.DeepEqual(m.Preferences, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, m.Preferences)
+			}
+		})
+	}
+}
+
+func TestPairwiseDoesNotChangeCandidates(t *testing.T) {
+	candidates := []int{c, a, b}
+	Pairwise(candidates, nil)
+
+	if !reflect.DeepEqual(candidates, []int{c, a, b}) {
+		t.Errorf("expected candidates to stay [3 1 2], got %v", candidates)
+	}
+}
+
+func TestCondorcetWinner(t *testing.T) {
+	tests := []struct {
+		name     string
+		ballots  []Ballot
+		expected int
+		ok       bool
+	}{
+		{"clear winner", join(repeat(3, b, a, c), repeat(2, c, a, b)), b, true},
+		{"winner without a first choice majority", join(repeat(2, a, b, c), repeat(2, c, b, a), repeat(1, b, a, c)), b, true},
+		{"cycle", rockPaperScissors(), 0, false},
+		{"tie at the top", join(repeat(1, a, b, c), repeat(1, b, a, c)), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Pairwise([]int{a, b, c}, tt.ballots).CondorcetWinner()
+
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []int
+		ballots    []Ballot
+		expected   [][]int
+	}{
+		{"no cycle", []int{a, b, c}, repeat(1, a, b, c), [][]int{}},
+		{"rock paper scissors", []int{a, b, c}, rockPaperScissors(), [][]int{{a, b, c}}},
+		{
+			name:       "cycle above a loser",
+			candidates: []int{a, b, c, d},
+			ballots:    join(repeat(4, a, b, c, d), repeat(3, b, c, a, d), repeat(2, c, a, b, d)),
+			expected:   [][]int{{a, b, c}},
+		},
+		{"ties are not cycles", []int{a, b}, join(repeat(1, a, b), repeat(1, b, a)), [][]int{}},
+		{"wikipedia", []int{a, b, c, d, e}, wikipedia(), [][]int{{a, b, c, d, e}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Pairwise(tt.candidates, tt.ballots).Cycles()
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSchulzeWikipedia(t *testing.T) {
+	result := Schulze([]int{a, b, c, d, e}, wikipedia())
+
+	preferences := [][]int{
+		{0, 20, 26, 30, 22},
+		{25, 0, 16, 33, 18},
+		{19, 29, 0, 17, 24},
+		{15, 12, 28, 0, 14},
+		{23, 27, 21, 31, 0},
+	}
+
+	if !reflect.DeepEqual(result.Preferences, preferences) {
+		t.Errorf("expected preferences %v, got %v", preferences, result.Preferences)
+	}
+
+	paths := [][]int{
+		{0, 28, 28, 30, 24},
+		{25, 0, 28, 33, 24},
+		{25, 29, 0, 29, 24},
+		{25, 28, 28, 0, 24},
+		{25, 28, 28, 31, 0},
+	}
+
+	if !reflect.DeepEqual(result.StrongestPaths, paths) {
+		t.Errorf("expected strongest paths %v, got %v", paths, result.StrongestPaths)
+	}
+
+	ranking := [][]int{{e}, {a}, {c}, {b}, {d}}
+
+	if !reflect.DeepEqual(result.Ranking, ranking) {
+		t.Errorf("expected ranking %v, got %v", ranking, result.Ranking)
+	}
+
+	if !reflect.DeepEqual(result.Winners, []int{e}) {
+		t.Errorf("expected winner %d, got %v", e, result.Winners)
+	}
+
+	if result.CondorcetWinner != nil {
+		t.Errorf("expected no Condorcet winner, got %d", *result.CondorcetWinner)
+	}
+}
+
+func TestSchulze(t *testing.T) {
+	tests := []struct {
+		name            string
+		candidates      []int
+		ballots         []Ballot
+		ranking         [][]int
+		condorcetWinner int
+		cycles          [][]int
+	}{
+		{
+			name:            "Condorcet winner",
+			candidates:      []int{a, b, c},
+			ballots:         join(repeat(2, a, b, c), repeat(2, c, b, a), repeat(1, b, a, c)),
+			ranking:         [][]int{{b}, {a}, {c}},
+			condorcetWinner: b,
+			cycles:          [][]int{},
+		},
+		{
+			name:       "cycle broken at its weakest link",
+			candidates: []int{a, b, c},
+			ballots:    rockPaperScissors(),
+			ranking:    [][]int{{a}, {b}, {c}},
+			cycles:     [][]int{{a, b, c}},
+		},
+		{
+			name:       "two way tie",
+			candidates: []int{a, b},
+			ballots:    join(repeat(1, a, b), repeat(1, b, a)),
+			ranking:    [][]int{{a, b}},
+			cycles:     [][]int{},
+		},
+		{
+			name:            "tie below the winner",
+			candidates:      []int{a, b, c},
+			ballots:         join(repeat(1, a, b, c), repeat(1, a, c, b)),
+			ranking:         [][]int{{a}, {b, c}},
+			condorcetWinner: a,
+			cycles:          [][]int{},
+		},
+		{
+			name:       "perfectly balanced cycle",
+			candidates: []int{a, b, c},
+			ballots:    join(repeat(1, a, b, c), repeat(1, b, c, a), repeat(1, c, a, b)),
+			ranking:    [][]int{{a, b, c}},
+			cycles:     [][]int{{a, b, c}},
+		},
+		{
+			name:       "tie for first",
+			candidates: []int{a, b, c},
+			ballots:    join(repeat(1, a, b, c), repeat(1, c, a, b)),
+			ranking:    [][]int{{a, c}, {b}},
+			cycles:     [][]int{},
+		},
+		{
+			name:       "cycle tied for first",
+			candidates: []int{a, b, c, d},
+			ballots:    join(repeat(1, a, b, c, d), repeat(1, b, c, a, d), repeat(1, c, a, b, d)),
+			ranking:    [][]int{{a, b, c}, {d}},
+			cycles:     [][]int{{a, b, c}},
+		},
+		{
+			name:       "no ballots",
+			candidates: []int{a, b, c},
+			ballots:    nil,
+			ranking:    [][]int{{a, b, c}},
+			cycles:     [][]int{},
+		},
+		{
+			name:       "no candidates",
+			candidates: nil,
+			ballots:    repeat(1, a),
+			ranking:    [][]int{},
+			cycles:     [][]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Schulze(tt.candidates, tt.ballots)
+
+			if !reflect.DeepEqual(result.Ranking, tt.ranking) {
+				t.Errorf("expected ranking %v, got %v", tt.ranking, result.Ranking)
+			}
+
+			winners := []int{}
+			if len(tt.ranking) > 0 {
+				winners = tt.ranking[0]
+			}
+
+			if !reflect.DeepEqual(result.Winners, winners) {
+				t.Errorf("expected winners %v, got %v", winners, result.Winners)
+			}
+
+			if !reflect.DeepEqual(result.Cycles, tt.cycles) {
+				t.Errorf("expected cycles %v, got %v", tt.cycles, result.Cycles)
+			}
+
+			switch {
+			case tt.condorcetWinner == 0 && result.CondorcetWinner != nil:
+				t.Errorf("expected no Condorcet winner, got %d", *result.CondorcetWinner)
+			case tt.condorcetWinner != 0 && (result.CondorcetWinner == nil || *result.CondorcetWinner != tt.condorcetWinner):
+				t.Errorf("expected Condorcet winner %d, got %v", tt.condorcetWinner, result.CondorcetWinner)
+			}
+		})
+	}
+}
+
+func TestSchulzeStrongestPathsThroughCycle(t *testing.T) {
+	result := Schulze([]int{a, b, c}, rockPaperScissors())
+
+	// a beats b 6 to 3, b beats c 7 to 2 and c beats a 5 to 4
+	paths := [][]int{
+		{0, 6, 6},
+		{5, 0, 7},
+		{5, 5, 0},
+	}
+
+	if !reflect.DeepEqual(result.StrongestPaths, paths) {
+		t.Errorf("expected strongest paths %v, got %v", paths, result.StrongestPaths)
+	}
+}
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []int
+		ballots    []Ballot
+		winners    []int
+		rounds     []Round
+	}{
+		{
+			name:       "first round majority",
+			candidates: []int{a, b},
+			ballots:    join(repeat(2, a), repeat(1, b)),
+			winners:    []int{a},
+			rounds: []Round{
+				{Counts: []Count{{a, 2}, {b, 1}}, Eliminated: []int{}},
+			},
+		},
+		{
+			name:       "transfers decide",
+			candidates: []int{a, b, c},
+			ballots:    join(repeat(4, a), repeat(3, b, a), repeat(2, c, b)),
+			winners:    []int{b},
+			rounds: []Round{
+				{Counts: []Count{{a, 4}, {b, 3}, {c, 2}}, Eliminated: []int{c}},
+				{Counts: []Count{{a, 4}, {b, 5}}, Eliminated: []int{}},
+			},
+		},
+		{
+			name:       "candidates tied for last go together",
+			candidates: []int{a, b, c, d},
+			ballots:    join(repeat(3, a), repeat(1, b, a), repeat(1, c, a), repeat(2, d)),
+			winners:    []int{a},
+			rounds: []Round{
+				{Counts: []Count{{a, 3}, {b, 1}, {c, 1}, {d, 2}}, Eliminated: []int{b, c}},
+				{Counts: []Count{{a, 5}, {d, 2}}, Eliminated: []int{}},
+			},
+		},
+		{
+			name:       "exhausted ballots leave a tie",
+			candidates: []int{a, b, c},
+			ballots:    join(repeat(2, a), repeat(2, b), repeat(1, c)),
+			winners:    []int{a, b},
+			rounds: []Round{
+				{Counts: []Count{{a, 2}, {b, 2}, {c, 1}}, Eliminated: []int{c}},
+				{Counts: []Count{{a, 2}, {b, 2}}, Exhausted: 1, Eliminated: []int{}},
+			},
+		},
+		{
+			name:       "exhausted ballots do not count toward the majority",
+			candidates: []int{a, b, c},
+			ballots:    join(repeat(3, a), repeat(2, b), repeat(2, c)),
+			winners:    []int{a},
+			rounds: []Round{
+				{Counts: []Count{{a, 3}, {b, 2}, {c, 2}}, Eliminated: []int{b, c}},
+				{Counts: []Count{{a, 3}}, Exhausted: 4, Eliminated: []int{}},
+			},
+		},
+		{
+			name:       "candidate without votes",
+			candidates: []int{a, b, c},
+			ballots:    join(repeat(2, a, b), repeat(2, b, a)),
+			winners:    []int{a, b},
+			rounds: []Round{
+				{Counts: []Count{{a, 2}, {b, 2}, {c, 0}}, Eliminated: []int{c}},
+				{Counts: []Count{{a, 2}, {b, 2}}, Eliminated: []int{}},
+			},
+		},
+		{
+			name:       "no ballots",
+			candidates: []int{a, b},
+			ballots:    nil,
+			winners:    []int{a, b},
+			rounds: []Round{
+				{Counts: []Count{{a, 0}, {b, 0}}, Eliminated: []int{}},
+			},
+		},
+		{
+			name:       "no candidates",
+			candidates: nil,
+			ballots:    repeat(1, a),
+			winners:    []int{},
+			rounds:     []Round{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := InstantRunoff(tt.candidates, tt.ballots)
+
+			if !reflect.DeepEqual(result.Winners, tt.winners) {
+				t.Errorf("expected winners %v, got %v", tt.winners, result.Winners)
+			}
+
+			if !reflect.DeepEqual(result.Rounds, tt.rounds) {
+				t.Errorf("expected rounds %+v, got %+v", tt.rounds, result.Rounds)
+			}
+		})
+	}
+}
+
+// The Condorcet winner of a centre squeeze loses instant-runoff, which is why
+// governance votes need the Schulze method.
+func TestCentreSqueeze(t *testing.T) {
+	ballots := join(repeat(4, a, b, c), repeat(2, b, a, c), repeat(3, c, b, a))
+
+	if got := InstantRunoff([]int{a, b, c}, ballots).Winners; !reflect.DeepEqual(got, []int{a}) {
+		t.Errorf("expected instant-runoff to elect %d, got %v", a, got)
+	}
+
+	if got := Schulze([]int{a, b, c}, ballots).Winners; !reflect.DeepEqual(got, []int{b}) {
+		t.Errorf("expected Schulze to elect %d, got %v", b, got)
+	}
+}
@@ -0,0 +1,74 @@
+// Package thumbnail scales images down using only the standard library.
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+)
+
+// Fit returns the size of a w by h image scaled down to fit into a size by
+// size square, keeping its aspect ratio. Images that already fit keep their
+// size.
+func Fit(w int, h int, size int) (int, int) {
+	if w <= size && h <= size {
+		return w, h
+	}
+
+	if w >= h {
+		return size, max(1, h*size/w)
+	}
+
+	return max(1, w*size/h), size
+}
+
+// Make scales src down to fit into a size by size square. Every pixel of the
+// thumbnail is the average of the source pixels it covers, which keeps fine
+// detail from turning into noise the way nearest neighbour sampling would.
+// Transparent areas are flattened onto white.
+func Make(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dw, dh := Fit(sw, sh, size)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0 := bounds.Min.Y + y*sh/dh
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*sh/dh)
+
+		for x := 0; x < dw; x++ {
+			x0 := bounds.Min.X + x*sw/dw
+			x1 := max(x0+1, bounds.Min.X+(x+1)*sw/dw)
+
+			dst.SetRGBA(x, y, average(src, x0, y0, x1, y1))
+		}
+	}
+
+	return dst
+}
+
+// average returns the mean colour of the pixels in [x0,x1) x [y0,y1) of src,
+// composited onto white.
+func average(src image.Image, x0 int, y0 int, x1 int, y1 int) color.RGBA {
+	var r, g, b, n uint64
+
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			pr, pg, pb, pa := src.At(x, y).RGBA()
+
+			// the channels are premultiplied, so white shows through by the
+			// missing alpha
+			r += uint64(pr + 0xffff - pa)
+			g += uint64(pg + 0xffff - pa)
+			b += uint64(pb + 0xffff - pa)
+			n++
+		}
+	}
+
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(b / n >> 8),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,66 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		w, h, size int
+		ew, eh     int
+	}{
+		{400, 200, 200, 200, 100},
+		{200, 400, 200, 100, 200},
+		{300, 300, 100, 100, 100},
+		{50, 80, 200, 50, 80},
+		{1000, 1, 100, 100, 1},
+	}
+
+	for _, tt := range tests {
+		w, h := Fit(tt.w, tt.h, tt.size)
+
+		if w != tt.ew || h != tt.eh {
+			t.Errorf("Fit(%d, %d, %d) = %dx%d, expected %dx%d", tt.w, tt.h, tt.size, w, h, tt.ew, tt.eh)
+		}
+	}
+}
+
+func TestMake(t *testing.T) {
+	// left half black, right half white
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			if x >= 20 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	thumb := Make(src, 4)
+
+	if thumb.Bounds().Dx() != 4 || thumb.Bounds().Dy() != 2 {
+		t.Fatalf("expected a 4x2 thumbnail, got %v", thumb.Bounds())
+	}
+
+	if c := thumb.RGBAAt(0, 0); c.R != 0 {
+		t.Errorf("expected a black left edge, got %v", c)
+	}
+
+	if c := thumb.RGBAAt(3, 1); c.R != 0xff {
+		t.Errorf("expected a white right edge, got %v", c)
+	}
+}
+
+func TestMakeFlattensTransparency(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+
+	thumb := Make(src, 1)
+
+	if c := thumb.RGBAAt(0, 0); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("expected transparent pixels to turn white, got %v", c)
+	}
+}
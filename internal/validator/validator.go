@@ -0,0 +1,162 @@
+// Package validator collects field level problems found in request payloads.
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error codes clients can branch on.
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeTooShort      = "too_short"
+	CodeDuplicate     = "duplicate"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalid       = "invalid"
+)
+
+var usernameRX = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// FieldError describes why the value at Field, a path into the request
+// payload such as "options[1].text", was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Validator struct {
+	Errors []FieldError
+}
+
+func New() *Validator {
+	return &Validator{Errors: []FieldError{}}
+}
+
+// Valid reports whether no errors were recorded.
+func (v *Validator) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// HasError reports whether an error was recorded for field.
+func (v *Validator) HasError(field string) bool {
+	for _, e := range v.Errors {
+		if e.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *Validator) AddError(field string, code string, message string) {
+	v.Errors = append(v.Errors, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check records an error unless ok is true.
+func (v *Validator) Check(ok bool, field string, code string, message string) {
+	if !ok {
+		v.AddError(field, code, message)
+	}
+}
+
+// Required records an error when value is blank.
+func (v *Validator) Required(field string, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, CodeRequired, field+" is required")
+}
+
+// MaxLength records an error when value has more than max characters, the way
+// a VARCHAR(max) column counts them.
+func (v *Validator) MaxLength(field string, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, CodeTooLong, fmt.Sprintf("%s must be at most %d characters long", field, max))
+}
+
+// MinLength records an error when value has fewer than min characters.
+func (v *Validator) MinLength(field string, value string, min int) {
+	v.Check(utf8.RuneCountInString(value) >= min, field, CodeTooShort, fmt.Sprintf("%s must be at least %d characters long", field, min))
+}
+
+// MaxBytes records an error when value is longer than max bytes.
+func (v *Validator) MaxBytes(field string, value string, max int) {
+	v.Check(len(value) <= max, field, CodeTooLong, fmt.Sprintf("%s must be at most %d bytes long", field, max))
+}
+
+// Username records an error when value contains anything but letters, digits,
+// dots, dashes and underscores.
+func (v *Validator) Username(field string, value string) {
+	v.Check(value == "" || usernameRX.MatchString(value), field, CodeInvalidFormat, field+" may only contain letters, digits, '.', '-' and '_'")
+}
+
+// URL records an error when value is neither empty nor an absolute http or
+// https URL.
+func (v *Validator) URL(field string, value string) {
+	if value == "" {
+		return
+	}
+
+	u, err := url.ParseRequestURI(value)
+
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, CodeInvalidFormat,
+		field+" must be an http or https URL")
+}
+
+// In records an error when value is not one of allowed.
+func (v *Validator) In(field string, value string, allowed ...string) {
+	v.Check(slices.Contains(allowed, value), field, CodeInvalid, fmt.Sprintf("%s must be one of ['%s']", field, strings.Join(allowed, "','")))
+}
+
+// NoDuplicates records an error for every entry of values that repeats an
+// earlier entry or one of existing. Values are compared case-insensitively and
+// ignoring surrounding whitespace. Errors are reported at path(i).
+func (v *Validator) NoDuplicates(values []string, existing []string, path func(i int) string) {
+	seen := make(map[string]bool)
+
+	for _, value := range existing {
+		seen[normalize(value)] = true
+	}
+
+	for i, value := range values {
+		key := normalize(value)
+
+		if key == "" {
+			continue
+		}
+
+		if seen[key] {
+			v.AddError(path(i), CodeDuplicate, fmt.Sprintf("'%s' is a duplicate", value))
+		}
+
+		seen[key] = true
+	}
+}
+
+// Index builds the path of a field of the i-th element of a list, such as
+// "options[1].text".
+func Index(list string, i int, field string) string {
+	path := fmt.Sprintf("%s[%d]", list, i)
+
+	if field != "" {
+		path += "." + field
+	}
+
+	return path
+}
+
+// Field builds the path of a field of the object at parent, such as
+// "options[1].text". An empty parent stands for the top level object.
+func Field(parent string, field string) string {
+	if parent == "" {
+		return field
+	}
+
+	return parent + "." + field
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
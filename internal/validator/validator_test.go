@@ -0,0 +1,98 @@
+package validator
+
+import "testing"
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		check    func(v *Validator)
+		expected []FieldError
+	}{
+		{
+			name:     "required",
+			check:    func(v *Validator) { v.Required("title", "  ") },
+			expected: []FieldError{{Field: "title", Code: CodeRequired}},
+		},
+		{
+			name:     "max length counts characters",
+			check:    func(v *Validator) { v.MaxLength("title", "ééé", 3) },
+			expected: []FieldError{},
+		},
+		{
+			name:     "too long",
+			check:    func(v *Validator) { v.MaxLength("title", "abcd", 3) },
+			expected: []FieldError{{Field: "title", Code: CodeTooLong}},
+		},
+		{
+			name:     "username format",
+			check:    func(v *Validator) { v.Username("username", "john doe") },
+			expected: []FieldError{{Field: "username", Code: CodeInvalidFormat}},
+		},
+		{
+			name:     "valid username",
+			check:    func(v *Validator) { v.Username("username", "john.doe-1_") },
+			expected: []FieldError{},
+		},
+		{
+			name:     "in",
+			check:    func(v *Validator) { v.In("role", "root", "owner", "member") },
+			expected: []FieldError{{Field: "role", Code: CodeInvalid}},
+		},
+		{
+			name:     "url",
+			check:    func(v *Validator) { v.URL("link_url", "https://example.com/mockups/1") },
+			expected: []FieldError{},
+		},
+		{
+			name:     "empty url",
+			check:    func(v *Validator) { v.URL("link_url", "") },
+			expected: []FieldError{},
+		},
+		{
+			name: "invalid urls",
+			check: func(v *Validator) {
+				v.URL("a", "example.com")
+				v.URL("b", "javascript:alert(1)")
+				v.URL("c", "https://")
+			},
+			expected: []FieldError{
+				{Field: "a", Code: CodeInvalidFormat},
+				{Field: "b", Code: CodeInvalidFormat},
+				{Field: "c", Code: CodeInvalidFormat},
+			},
+		},
+		{
+			name: "duplicates",
+			check: func(v *Validator) {
+				v.NoDuplicates([]string{"Yes", "No", " yes ", "Maybe"}, []string{"maybe"}, func(i int) string {
+					return Index("options", i, "text")
+				})
+			},
+			expected: []FieldError{
+				{Field: "options[2].text", Code: CodeDuplicate},
+				{Field: "options[3].text", Code: CodeDuplicate},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			tt.check(v)
+
+			if len(v.Errors) != len(tt.expected) {
+				t.Fatalf("expected %d errors, got %v", len(tt.expected), v.Errors)
+			}
+
+			for i, e := range tt.expected {
+				if v.Errors[i].Field != e.Field || v.Errors[i].Code != e.Code {
+					t.Errorf("expected %s/%s, got %s/%s", e.Field, e.Code, v.Errors[i].Field, v.Errors[i].Code)
+				}
+			}
+
+			if v.Valid() != (len(tt.expected) == 0) {
+				t.Errorf("Valid() returned %v", v.Valid())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package wordfreq summarizes free text answers by the words used most often.
+package wordfreq
+
+import (
+	"polling/internal/fuzzy"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Word is a word and the number of times it was used.
+type Word struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// stopWords are common English words that say nothing about an answer.
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`a about after all also am an and any are as at be because been but by can
+		could did do does for from get got had has have he her him his how i if in into is it its just like me more
+		most my no not of on only or our out over she so some than that the their them then there these they this to
+		too up us very was we were what when where which who why will with would you your`) {
+		stopWords[word] = true
+	}
+}
+
+// Count returns the limit most frequent words of texts, most frequent first
+// and alphabetically among equals. Words are compared the way fuzzy.Normalize
+// sees them, and stop words, numbers and single letters are left out. A limit
+// of 0 or less returns every word.
+func Count(texts []string, limit int) []Word {
+	counts := map[string]int{}
+
+	for _, text := range texts {
+		for _, word := range strings.Fields(fuzzy.Normalize(text)) {
+			if utf8.RuneCountInString(word) < 2 || stopWords[word] || isNumber(word) {
+				continue
+			}
+
+			counts[word]++
+		}
+	}
+
+	words := make([]Word, 0, len(counts))
+
+	for word, count := range counts {
+		words = append(words, Word{Word: word, Count: count})
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+
+		return words[i].Word < words[j].Word
+	})
+
+	if limit > 0 && len(words) > limit {
+		words = words[:limit]
+	}
+
+	return words
+}
+
+func isNumber(word string) bool {
+	return strings.Trim(word, "0123456789") == ""
+}
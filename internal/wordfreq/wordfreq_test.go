@@ -0,0 +1,59 @@
+package wordfreq
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	texts := []string{
+		"More coffee in the kitchen!",
+		"Better coffee, and a bigger kitchen.",
+		"COFFEE. Also: 2 more desks",
+		"",
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		expected []Word
+	}{
+		{
+			name:  "top words",
+			limit: 2,
+			expected: []Word{
+				{Word: "coffee", Count: 3},
+				{Word: "kitchen", Count: 2},
+			},
+		},
+		{
+			name:  "every word",
+			limit: 0,
+			expected: []Word{
+				{Word: "coffee", Count: 3},
+				{Word: "kitchen", Count: 2},
+				{Word: "better", Count: 1},
+				{Word: "bigger", Count: 1},
+				{Word: "desks", Count: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Count(texts, tt.limit); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCountNothing(t *testing.T) {
+	if got := Count(nil, 10); len(got) != 0 {
+		t.Errorf("expected no words, got %v", got)
+	}
+
+	if got := Count([]string{"the and of", "42 x"}, 10); len(got) != 0 {
+		t.Errorf("expected stop words, numbers and letters to be skipped, got %v", got)
+	}
+}